@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// Resources are the parts of the API a token can be scoped to
+var Resources = []string{"commands", "quotes", "timers", "badwords", "permits", "modlog", "events", "strikes",
+	"moderation", "status", "plugins", "polls", "queue", "counters",
+	"chatters", "chatlog", "sessions", "stats"}
+
+// tokenKey is where the request's token is kept in the gin context
+const tokenKey = "token"
+
+// ValidateScope returns an error if scope is not of the form '<resource>:<read|write>'. A resource of '*' covers
+// every resource.
+func ValidateScope(scope string) error {
+	split := strings.SplitN(scope, ":", 2)
+	if len(split) != 2 || (split[1] != "read" && split[1] != "write") {
+		return fmt.Errorf("'%s' is not a valid scope, scopes look like 'commands:write' or 'quotes:read'", scope)
+	}
+	if split[0] == "*" {
+		return nil
+	}
+	for _, resource := range Resources {
+		if split[0] == resource {
+			return nil
+		}
+	}
+	return fmt.Errorf("'%s' is not a resource, use one of %s or *", split[0], strings.Join(Resources, ", "))
+}
+
+// authorize checks that the request has a bearer token with a scope for resource. Requests that change data need a
+// write scope, and are recorded in the API audit log once they are done. Browsers can't set headers on EventSource and
+// WebSocket connections, so the token may also be given as '?access_token='.
+func (s *Server) authorize(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if secret == "" {
+			secret = c.Query("access_token")
+		}
+		if secret == "" {
+			fail(c, http.StatusUnauthorized, errors.New("an API token is needed, send one with 'Authorization: Bearer <token>'"))
+			return
+		}
+
+		token, err := s.Bot.FindAPIToken(secret)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, bot.ErrInvalidToken) && !errors.Is(err, bot.ErrExpiredToken) && !errors.Is(err, bot.ErrRevokedToken) {
+				status = http.StatusInternalServerError
+			}
+			fail(c, status, err)
+			return
+		}
+
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+		if !token.Allows(resource, write) {
+			access := "read"
+			if write {
+				access = "write"
+			}
+			fail(c, http.StatusForbidden, fmt.Errorf("the API token needs the '%s:%s' scope", resource, access))
+			return
+		}
+
+		c.Set(tokenKey, token)
+		c.Next()
+
+		if write {
+			err = s.Bot.LogAPIRequest(bot.APIRequest{TokenID: token.ID, Method: c.Request.Method, Path: c.Request.URL.Path,
+				Status: c.Writer.Status()})
+			if err != nil {
+				fmt.Printf("could not audit the API request %s %s: %v\n", c.Request.Method, c.Request.URL.Path, err)
+			}
+		}
+	}
+}
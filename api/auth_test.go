@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthorize(t *testing.T) {
+	s := newTestServer(t)
+	readOnly, _, _ := s.Bot.CreateAPIToken("reader", []string{"commands:read"}, 0)
+	writer, writerToken, _ := s.Bot.CreateAPIToken("writer", []string{"commands:write"}, 0)
+	expired, _, _ := s.Bot.CreateAPIToken("expired", []string{"commands:write"}, time.Nanosecond)
+	revoked, revokedToken, _ := s.Bot.CreateAPIToken("revoked", []string{"commands:write"}, 0)
+	s.Bot.RevokeAPIToken(revokedToken.ID)
+	time.Sleep(time.Second) // expiry is stored to the second
+
+	tests := []struct {
+		description string
+		token       string
+		method      string
+		path        string
+		body        interface{}
+		wantStatus  int
+	}{
+		{
+			description: "should reject a request without a token",
+			token:       "",
+			method:      http.MethodGet,
+			path:        "/api/commands",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			description: "should reject an unknown token",
+			token:       "pb_notarealtoken",
+			method:      http.MethodGet,
+			path:        "/api/commands",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			description: "should allow a read scope to read",
+			token:       readOnly,
+			method:      http.MethodGet,
+			path:        "/api/commands",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			description: "should not allow a read scope to write",
+			token:       readOnly,
+			method:      http.MethodPost,
+			path:        "/api/commands",
+			body:        commandRequest{Name: "hello", Response: "hi"},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			description: "should not allow a scope for a different resource",
+			token:       writer,
+			method:      http.MethodGet,
+			path:        "/api/quotes",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			description: "should allow a write scope to write",
+			token:       writer,
+			method:      http.MethodPost,
+			path:        "/api/commands",
+			body:        commandRequest{Name: "hello", Response: "hi"},
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			description: "should reject an expired token",
+			token:       expired,
+			method:      http.MethodGet,
+			path:        "/api/commands",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			description: "should reject a revoked token",
+			token:       revoked,
+			method:      http.MethodGet,
+			path:        "/api/commands",
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			code := doWithToken(t, s, test.token, test.method, test.path, test.body, nil)
+			if code != test.wantStatus {
+				t.Errorf("did not get the expected status\ngot - %d\nwant - %d", code, test.wantStatus)
+			}
+		})
+	}
+
+	// only the write that was allowed through should be audited
+	requests, err := s.Bot.APIAudit(0, 0)
+	if err != nil {
+		t.Fatalf("could not read the audit log: %v", err)
+	}
+	if len(requests) != 1 || requests[0].TokenID != writerToken.ID || requests[0].Path != "/api/commands" || requests[0].Status != http.StatusCreated {
+		t.Errorf("did not get the expected audit log: %+v", requests)
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	for _, scope := range []string{"commands:write", "quotes:read", "*:read"} {
+		if err := ValidateScope(scope); err != nil {
+			t.Errorf("%s should be a valid scope: %v", scope, err)
+		}
+	}
+	for _, scope := range []string{"commands", "commands:delete", "cookies:read"} {
+		if err := ValidateScope(scope); err == nil {
+			t.Errorf("%s should not be a valid scope", scope)
+		}
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.CORSOrigins = []string{"https://dashboard.example.com"}
+	s.Server = NewServer(s.Bot, s.lock)
+
+	for origin, wantAllowed := range map[string]bool{"https://dashboard.example.com": true, "https://evil.example.com": false} {
+		request, _ := http.NewRequest(http.MethodOptions, "/api/commands", nil)
+		request.Header.Set("Origin", origin)
+		request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		recorder := httptest.NewRecorder()
+		s.Router.ServeHTTP(recorder, request)
+
+		allowed := recorder.Header().Get("Access-Control-Allow-Origin") == origin
+		if allowed != wantAllowed {
+			t.Errorf("did not get the expected CORS result for %s\ngot - %v\nwant - %v", origin, allowed, wantAllowed)
+		}
+	}
+}
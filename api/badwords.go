@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// badWordUpdate changes a bad word's severity, which is required so that leaving it out doesn't reset it to purge
+type badWordUpdate struct {
+	Severity *bot.Severity `json:"severity" binding:"required"`
+}
+
+func (s *Server) listBadWords(c *gin.Context) {
+	badWords := s.Bot.BadWords
+	if badWords == nil {
+		badWords = []bot.BadWord{}
+	}
+	c.JSON(http.StatusOK, badWords)
+}
+
+func (s *Server) addBadWord(c *gin.Context) {
+	var request bot.BadWord
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	err := s.Bot.AddBadWord(request.Phrase, request.Severity)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, request)
+}
+
+func (s *Server) editBadWord(c *gin.Context) {
+	var request badWordUpdate
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	found, err := s.Bot.SetBadWordSeverity(c.Param("phrase"), *request.Severity)
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("'%s' is not a bad word", c.Param("phrase")))
+		return
+	} else if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, bot.BadWord{Phrase: c.Param("phrase"), Severity: *request.Severity})
+}
+
+func (s *Server) deleteBadWord(c *gin.Context) {
+	found, err := s.Bot.RemoveBadWord(c.Param("phrase"))
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("'%s' is not a bad word", c.Param("phrase")))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
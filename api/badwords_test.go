@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestBadWordRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	body := map[string]string{"phrase": "cookies", "severity": "timeout"}
+	if code := do(t, s, http.MethodPost, "/api/badwords", body, nil); code != http.StatusCreated {
+		t.Fatalf("did not get the expected status when adding, got - %d", code)
+	}
+	if found, badWord := s.Bot.ParseForBadWord("i like cookies"); !found || badWord.Severity != bot.SeverityTimeout {
+		t.Errorf("the bad word was not added to the bot: %+v", badWord)
+	}
+
+	body["severity"] = "explode"
+	if code := do(t, s, http.MethodPost, "/api/badwords", body, nil); code != http.StatusBadRequest {
+		t.Errorf("an invalid severity was not a bad request, got - %d", code)
+	}
+
+	numbered := map[string]interface{}{"phrase": "cupcakes", "severity": 99}
+	if code := do(t, s, http.MethodPost, "/api/badwords", numbered, nil); code != http.StatusBadRequest {
+		t.Errorf("an unknown severity number was not a bad request, got - %d", code)
+	}
+
+	var badWords []map[string]interface{}
+	do(t, s, http.MethodGet, "/api/badwords", nil, &badWords)
+	if len(badWords) != 1 || badWords[0]["severity"] != "timeout" {
+		t.Errorf("did not list the expected bad words: %+v", badWords)
+	}
+
+	if code := do(t, s, http.MethodPut, "/api/badwords/cookies", map[string]string{"severity": "ban"}, nil); code != http.StatusOK {
+		t.Errorf("did not get the expected status when editing, got - %d", code)
+	}
+	if _, badWord := s.Bot.ParseForBadWord("i like cookies"); badWord.Severity != bot.SeverityBan {
+		t.Errorf("the bad word's severity was not changed: %+v", badWord)
+	}
+	if code := do(t, s, http.MethodPut, "/api/badwords/cookies", map[string]interface{}{"severity": 99}, nil); code != http.StatusBadRequest {
+		t.Errorf("editing to an unknown severity was not a bad request, got - %d", code)
+	}
+	if code := do(t, s, http.MethodPut, "/api/badwords/cookies", map[string]string{}, nil); code != http.StatusBadRequest {
+		t.Errorf("editing without a severity was not a bad request, got - %d", code)
+	}
+	if code := do(t, s, http.MethodPut, "/api/badwords/cupcakes", map[string]string{"severity": "ban"}, nil); code != http.StatusNotFound {
+		t.Errorf("editing a missing bad word was not a not found, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/badwords/cookies", nil, nil); code != http.StatusNoContent {
+		t.Errorf("did not get the expected status when deleting, got - %d", code)
+	}
+	if code := do(t, s, http.MethodDelete, "/api/badwords/cookies", nil, nil); code != http.StatusNotFound {
+		t.Errorf("deleting a missing bad word was not a not found, got - %d", code)
+	}
+}
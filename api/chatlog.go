@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// searchChatLog returns the chat log, filtered by the optional user, q, since, until and limit query parameters. q
+// matches lines with every word in it. Dates can be given as 2006-01-02 or as a duration into the past such as 2d.
+func (s *Server) searchChatLog(c *gin.Context) {
+	var filter bot.ChatLogFilter
+	var err error
+
+	filter.User = c.Query("user")
+	filter.Term = c.Query("q")
+	if filter.Since, err = bot.ParseSince(c.Query("since")); err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if filter.Until, err = bot.ParseUntil(c.Query("until")); err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return
+	}
+	filter.Limit = 100
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			fail(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	lines, err := s.Bot.ChatLog(filter)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if lines == nil {
+		lines = []bot.ChatLine{}
+	}
+	c.JSON(http.StatusOK, lines)
+}
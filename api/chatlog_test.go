@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestChatLogRoute(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.LogChat(bot.ChatLine{Kind: bot.ChatLineMessage, User: "spammer", Message: "follow me for a free discord link"})
+	s.Bot.LogChat(bot.ChatLine{Kind: bot.ChatLineMessage, User: "viewer", Message: "nice play"})
+	s.Bot.LogChat(bot.ChatLine{Kind: bot.ChatLineMessage, User: "viewer", Message: "is there a discord?"})
+
+	var lines []bot.ChatLine
+	if code := do(t, s, http.MethodGet, "/api/chatlog?user=viewer&since=1d&q=discord", nil, &lines); code != http.StatusOK {
+		t.Fatalf("did not get the expected status, got - %d", code)
+	}
+	if len(lines) != 1 || lines[0].Message != "is there a discord?" {
+		t.Errorf("did not get the expected lines: %+v", lines)
+	}
+
+	if code := do(t, s, http.MethodGet, "/api/chatlog?until=whenever", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("an invalid date was not a bad request, got - %d", code)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type noteRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// getChatter returns the profile of whoever chats, or used to chat, as the given name
+func (s *Server) getChatter(c *gin.Context) {
+	profile, ok := s.findChatter(c)
+	if ok {
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// addNote adds a note to a chatter's profile, written by the token's name
+func (s *Server) addNote(c *gin.Context) {
+	var request noteRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	profile, ok := s.findChatter(c)
+	if !ok {
+		return
+	}
+	err := s.Bot.AddNote(profile.ID, actorName(c), request.Note)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if profile, err = s.Bot.GetChatter(profile.ID); err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+// findChatter looks up the chatter named in the path, responding with an error if they can't be found
+func (s *Server) findChatter(c *gin.Context) (bot.Profile, bool) {
+	profile, err := s.Bot.FindChatter(c.Param("username"))
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusNotFound, err)
+		return profile, false
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return profile, false
+	}
+	return profile, true
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestChatterRoutes(t *testing.T) {
+	s := newTestServer(t)
+	if _, err := s.Bot.TrackChatter(bot.Item{Sender: bot.User{ID: "1", Name: "viewer"}, Contents: "hi"}); err != nil {
+		t.Fatalf("could not track a chatter: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        interface{}
+		wantCode    int
+	}{
+		{description: "should get a chatter's profile", method: http.MethodGet, path: "/api/chatters/viewer", wantCode: http.StatusOK},
+		{description: "should not get the profile of someone who never chatted", method: http.MethodGet, path: "/api/chatters/nobody", wantCode: http.StatusNotFound},
+		{description: "should add a note", method: http.MethodPost, path: "/api/chatters/viewer/notes", body: noteRequest{Note: "regular from the discord"}, wantCode: http.StatusCreated},
+		{description: "should not add a note to someone who never chatted", method: http.MethodPost, path: "/api/chatters/nobody/notes", body: noteRequest{Note: "hi"}, wantCode: http.StatusNotFound},
+		{description: "should not add an empty note", method: http.MethodPost, path: "/api/chatters/viewer/notes", body: noteRequest{Note: "   "}, wantCode: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if code := do(t, s, test.method, test.path, test.body, nil); code != test.wantCode {
+			t.Errorf("%s\ndid not get the expected status\ngot - %d\nwant - %d", test.description, code, test.wantCode)
+		}
+	}
+
+	var profile bot.Profile
+	if code := do(t, s, http.MethodGet, "/api/chatters/@Viewer", nil, &profile); code != http.StatusOK ||
+		profile.Messages != 1 || len(profile.Notes) != 1 || profile.Notes[0].Note != "regular from the discord" {
+		t.Errorf("did not get the expected profile, got - %d %+v", code, profile)
+	}
+}
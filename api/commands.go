@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type commandRequest struct {
+	Name     string `json:"name"`
+	Response string `json:"response" binding:"required"`
+}
+
+type scriptRequest struct {
+	Script string `json:"script"` // an empty script removes the command's script
+}
+
+// commandKey turns a command name from a request into the key used in the bot's commands map, e.g. hello -> !hello
+func commandKey(name string) string {
+	if !strings.HasPrefix(name, "!") {
+		return fmt.Sprintf("!%s", name)
+	}
+	return name
+}
+
+func (s *Server) listCommands(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Bot.Commands)
+}
+
+func (s *Server) getCommand(c *gin.Context) {
+	found, command := s.Bot.FindCommand(commandKey(c.Param("name")))
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the command '%s' does not exist", c.Param("name")))
+		return
+	}
+	c.JSON(http.StatusOK, command)
+}
+
+func (s *Server) addCommand(c *gin.Context) {
+	var request commandRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if request.Name == "" {
+		fail(c, http.StatusBadRequest, fmt.Errorf("a name is needed for a new command"))
+		return
+	}
+
+	key := commandKey(request.Name)
+	if found, _ := s.Bot.FindCommand(key); found {
+		fail(c, http.StatusConflict, fmt.Errorf("the command '%s' already exists", key))
+		return
+	}
+
+	err := s.Bot.AddCommand(bot.Item{Key: key, Contents: request.Response})
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	_, command := s.Bot.FindCommand(key)
+	c.JSON(http.StatusCreated, command)
+}
+
+func (s *Server) editCommand(c *gin.Context) {
+	var request commandRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	key := commandKey(c.Param("name"))
+	if found, _ := s.Bot.FindCommand(key); !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the command '%s' does not exist", key))
+		return
+	}
+
+	err := s.Bot.EditCommand(bot.Item{Key: key, Contents: request.Response})
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	_, command := s.Bot.FindCommand(key)
+	c.JSON(http.StatusOK, command)
+}
+
+func (s *Server) deleteCommand(c *gin.Context) {
+	key := commandKey(c.Param("name"))
+	found, err := s.Bot.RemoveCommand(key)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the command '%s' does not exist", key))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) setCommandScript(c *gin.Context) {
+	var request scriptRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	key := commandKey(c.Param("name"))
+	if found, _ := s.Bot.FindCommand(key); !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the command '%s' does not exist", key))
+		return
+	}
+
+	err := s.Bot.SetCommandScript(key, request.Script)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	_, command := s.Bot.FindCommand(key)
+	c.JSON(http.StatusOK, command)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type counterRequest struct {
+	Name      string `json:"name" binding:"required"`
+	PerStream bool   `json:"per_stream"`
+}
+
+// counterUpdate changes a counter, fields that are left out stay as they are
+type counterUpdate struct {
+	Value     *int64 `json:"value"`
+	PerStream *bool  `json:"per_stream"`
+}
+
+func (s *Server) listCounters(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Bot.SortedCounters())
+}
+
+func (s *Server) getCounter(c *gin.Context) {
+	counter, found := s.Bot.Counters[c.Param("name")]
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the counter '%s' does not exist", c.Param("name")))
+		return
+	}
+	c.JSON(http.StatusOK, counter)
+}
+
+func (s *Server) addCounter(c *gin.Context) {
+	var request counterRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if _, found := s.Bot.Counters[request.Name]; found {
+		fail(c, http.StatusConflict, fmt.Errorf("the counter '%s' already exists", request.Name))
+		return
+	}
+
+	counter, err := s.Bot.AddCounter(request.Name, request.PerStream)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, counter)
+}
+
+func (s *Server) editCounter(c *gin.Context) {
+	name := c.Param("name")
+	if _, found := s.Bot.Counters[name]; !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the counter '%s' does not exist", name))
+		return
+	}
+	var request counterUpdate
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	var err error
+	if request.Value != nil {
+		err = s.Bot.SetCounter(name, *request.Value)
+	}
+	if request.PerStream != nil && err == nil {
+		err = s.Bot.SetCounterPerStream(name, *request.PerStream)
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.Bot.Counters[name])
+}
+
+func (s *Server) deleteCounter(c *gin.Context) {
+	found, err := s.Bot.RemoveCounter(c.Param("name"))
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the counter '%s' does not exist", c.Param("name")))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
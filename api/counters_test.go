@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestCounterRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.Counters = map[string]*bot.Counter{}
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        interface{}
+		wantCode    int
+	}{
+		{description: "should add a counter", method: http.MethodPost, path: "/api/counters", body: counterRequest{Name: "deaths", PerStream: true}, wantCode: http.StatusCreated},
+		{description: "should not add a counter twice", method: http.MethodPost, path: "/api/counters", body: counterRequest{Name: "deaths"}, wantCode: http.StatusConflict},
+		{description: "should not add a counter with an invalid name", method: http.MethodPost, path: "/api/counters", body: counterRequest{Name: "Big Deaths"}, wantCode: http.StatusBadRequest},
+		{description: "should set a counter", method: http.MethodPut, path: "/api/counters/deaths", body: map[string]int64{"value": 7}, wantCode: http.StatusOK},
+		{description: "should not set a counter that doesn't exist", method: http.MethodPut, path: "/api/counters/wins", body: map[string]int64{"value": 7}, wantCode: http.StatusNotFound},
+		{description: "should get a counter", method: http.MethodGet, path: "/api/counters/deaths", wantCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		if code := do(t, s, test.method, test.path, test.body, nil); code != test.wantCode {
+			t.Errorf("%s\ndid not get the expected status\ngot - %d\nwant - %d", test.description, code, test.wantCode)
+		}
+	}
+
+	var counters []bot.Counter
+	if code := do(t, s, http.MethodGet, "/api/counters", nil, &counters); code != http.StatusOK || len(counters) != 1 ||
+		counters[0].Value != 7 || !counters[0].PerStream {
+		t.Errorf("did not list the expected counters, got - %d %+v", code, counters)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/counters/deaths", nil, nil); code != http.StatusNoContent {
+		t.Errorf("could not delete the counter, got - %d", code)
+	}
+	if code := do(t, s, http.MethodDelete, "/api/counters/deaths", nil, nil); code != http.StatusNotFound {
+		t.Errorf("deleted a counter that doesn't exist, got - %d", code)
+	}
+}
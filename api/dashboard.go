@@ -0,0 +1,28 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dashboardFiles holds the dashboard, it is plain HTML, CSS and JavaScript with no outside assets so it works offline
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardRoutes serves the dashboard at /dashboard/. The pages themselves hold no data, everything is loaded through
+// the API with the token the user enters, so they are served without authorization.
+func (s *Server) dashboardRoutes() {
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // the directory is embedded at build time, so this can't happen
+	}
+
+	s.Router.StaticFS("/dashboard", http.FS(files))
+	s.Router.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/dashboard/")
+	})
+}
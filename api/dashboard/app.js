@@ -0,0 +1,370 @@
+// The dashboard talks to the bot only through its API, using the token saved in the browser.
+"use strict";
+
+const tokenStorageKey = "pleasantbot-token";
+const maxChatLines = 500;
+
+let token = localStorage.getItem(tokenStorageKey);
+let events = null;
+
+// api sends a request to the bot's API and returns the decoded response, throwing the API's error message on failure
+async function api(method, path, body) {
+	const options = { method, headers: { Authorization: `Bearer ${token}` } };
+	if (body !== undefined) {
+		options.headers["Content-Type"] = "application/json";
+		options.body = JSON.stringify(body);
+	}
+
+	const response = await fetch(`/api${path}`, options);
+	if (response.status === 204) {
+		return null;
+	}
+	const data = await response.json().catch(() => null);
+	if (!response.ok) {
+		const error = new Error(data && data.error ? data.error : `${method} ${path} failed with ${response.status}`);
+		error.status = response.status;
+		throw error;
+	}
+	return data;
+}
+
+// element creates an element with the given text, it never interprets text as HTML
+function element(tag, text, className) {
+	const el = document.createElement(tag);
+	if (text !== undefined && text !== null) {
+		el.textContent = text;
+	}
+	if (className) {
+		el.className = className;
+	}
+	return el;
+}
+
+function button(text, onClick, className) {
+	const el = element("button", text, className ? `small ${className}` : "small");
+	el.type = "button";
+	el.addEventListener("click", onClick);
+	return el;
+}
+
+function row(cells) {
+	const tr = document.createElement("tr");
+	for (const cell of cells) {
+		const td = document.createElement("td");
+		if (cell instanceof Node) {
+			td.appendChild(cell);
+		} else {
+			td.textContent = cell;
+		}
+		tr.appendChild(td);
+	}
+	return tr;
+}
+
+function showError(error) {
+	document.getElementById("error").textContent = error ? error.message : "";
+	if (error && error.status === 401) {
+		signOut();
+	}
+}
+
+// run calls f and shows any error it throws, then reloads the current page
+async function run(f) {
+	try {
+		await f();
+		showError(null);
+	} catch (error) {
+		showError(error);
+	}
+	await loadPage(currentPage());
+}
+
+// pages
+
+const loaders = {
+	async commands() {
+		const commands = await api("GET", "/commands");
+		const body = document.getElementById("commands");
+		body.replaceChildren(...Object.keys(commands).sort().map((name) => row([
+			name,
+			commands[name].response,
+			commands[name].count,
+			button("Delete", () => run(() => api("DELETE", `/commands/${encodeURIComponent(name.slice(1))}`)), "danger"),
+		])));
+	},
+
+	async quotes() {
+		const quotes = await api("GET", "/quotes");
+		quotes.sort((a, b) => a.id - b.id);
+		document.getElementById("quotes").replaceChildren(...quotes.map((quote) => row([
+			quote.id,
+			quote.quote,
+			quote.submitter,
+			quote.timestamp,
+			button("Delete", () => run(() => api("DELETE", `/quotes/${quote.id}`)), "danger"),
+		])));
+	},
+
+	async timers() {
+		const timers = await api("GET", "/timers");
+		document.getElementById("timers").replaceChildren(...Object.keys(timers).sort().map((name) => row([
+			name,
+			timers[name].message,
+			timers[name].minutes,
+			timers[name].enabled ? "yes" : "no",
+			button("Delete", () => run(() => api("DELETE", `/timers/${encodeURIComponent(name)}`)), "danger"),
+		])));
+	},
+
+	async badwords() {
+		const badWords = await api("GET", "/badwords");
+		document.getElementById("badwords").replaceChildren(...badWords.map((badWord) => row([
+			badWord.phrase,
+			badWord.severity,
+			button("Delete", () => run(() => api("DELETE", `/badwords/${encodeURIComponent(badWord.phrase)}`)), "danger"),
+		])));
+	},
+
+	async strikes() {
+		const users = await api("GET", "/strikes");
+		users.sort((a, b) => b.total - a.total);
+		document.getElementById("strikes").replaceChildren(...users.map((user) => row([
+			user.user,
+			user.total,
+			user.strikes.map((strike) => strike.reason).join(", "),
+			button("Pardon", () => run(() => api("DELETE", `/strikes/${encodeURIComponent(user.user)}`))),
+		])));
+	},
+
+	async queue() {
+		const queue = await api("GET", "/queue");
+		const size = queue.size ? ` (${queue.entries.length}/${queue.size})` : "";
+		document.getElementById("queue-state").textContent = `${queue.open ? "Open" : "Closed"}${size}`;
+		document.getElementById("queue-toggle").textContent = queue.open ? "Close" : "Open";
+		document.getElementById("queue-toggle").dataset.open = queue.open;
+		document.getElementById("queue").replaceChildren(...queue.entries.map((entry, i) => row([
+			i + 1,
+			entry.user,
+			entry.subscriber ? "yes" : "no",
+			new Date(entry.joined).toLocaleTimeString(),
+			button("Remove", () => run(() => api("DELETE", `/queue/${encodeURIComponent(entry.user)}`)), "danger"),
+		])));
+	},
+
+	async chat() {},
+};
+
+function currentPage() {
+	const page = location.hash.slice(1);
+	return loaders[page] ? page : "chat";
+}
+
+async function loadPage(page) {
+	for (const link of document.querySelectorAll("nav a")) {
+		link.classList.toggle("active", link.dataset.page === page);
+	}
+	for (const section of document.querySelectorAll(".page")) {
+		section.classList.toggle("active", section.id === `page-${page}`);
+	}
+
+	try {
+		await loaders[page]();
+	} catch (error) {
+		showError(error);
+	}
+}
+
+// forms
+
+function onSubmit(id, submit) {
+	const form = document.getElementById(id);
+	form.addEventListener("submit", (event) => {
+		event.preventDefault();
+		const values = Object.fromEntries(new FormData(form));
+		run(async () => {
+			await submit(values);
+			form.reset();
+		});
+	});
+}
+
+onSubmit("command-form", async (values) => {
+	const name = values.name.replace(/^!/, "");
+	try {
+		await api("POST", "/commands", { name, response: values.response });
+	} catch (error) {
+		if (error.status !== 409) {
+			throw error;
+		}
+		await api("PUT", `/commands/${encodeURIComponent(name)}`, { response: values.response });
+	}
+});
+
+onSubmit("quote-form", (values) => api("POST", "/quotes", values));
+
+onSubmit("timer-form", (values) => api("POST", "/timers", {
+	name: values.name,
+	message: values.message,
+	minutes: Number(values.minutes),
+}));
+
+onSubmit("badword-form", (values) => api("POST", "/badwords", values));
+
+document.getElementById("queue-toggle").addEventListener("click", (event) => {
+	run(() => api("PUT", "/queue", { open: event.target.dataset.open !== "true" }));
+});
+document.getElementById("queue-next").addEventListener("click", () => run(() => api("POST", "/queue/next")));
+document.getElementById("queue-clear").addEventListener("click", () => {
+	if (confirm("Remove everyone from the queue?")) {
+		run(() => api("DELETE", "/queue"));
+	}
+});
+
+// live chat
+
+const messageLines = new Map(); // message ID to its line in the chat
+
+function moderate(action, body) {
+	api("POST", `/moderation/${action}`, body).then(() => showError(null), showError);
+}
+
+function addChatLine(line) {
+	const chat = document.getElementById("chat");
+	const atBottom = chat.scrollTop + chat.clientHeight >= chat.scrollHeight - 10;
+	chat.appendChild(line);
+	while (chat.children.length > maxChatLines) {
+		const removed = chat.firstChild;
+		messageLines.delete(removed.dataset.id);
+		removed.remove();
+	}
+	if (atBottom) {
+		chat.scrollTop = chat.scrollHeight;
+	}
+}
+
+function chatMessage(event) {
+	const item = event.data;
+	const line = element("div", null, "line");
+	line.dataset.id = item.id;
+	line.dataset.user = item.sender.name;
+
+	line.appendChild(element("span", new Date(event.timestamp).toLocaleTimeString(), "time"));
+	line.appendChild(element("span", item.sender.name, "name"));
+	line.appendChild(element("span", [item.type, item.command, item.key, item.contents].filter(Boolean).join(" "), "text"));
+
+	const actions = element("span", null, "actions");
+	const username = item.sender.name;
+	if (item.id) {
+		actions.appendChild(button("Delete", () => moderate("delete", { message_id: item.id })));
+	}
+	actions.appendChild(button("Timeout", () => {
+		const seconds = Number(prompt(`Timeout ${username} for how many seconds?`, "600"));
+		if (seconds > 0) {
+			moderate("timeout", { username, seconds });
+		}
+	}));
+	actions.appendChild(button("Ban", () => {
+		if (confirm(`Ban ${username}?`)) {
+			moderate("ban", { username, reason: prompt("Reason (optional)") || "" });
+		}
+	}, "danger"));
+	line.appendChild(actions);
+
+	if (item.id) {
+		messageLines.set(item.id, line);
+	}
+	addChatLine(line);
+}
+
+function chatModeration(event) {
+	const entry = event.data;
+	const duration = entry.duration ? ` for ${entry.duration / 1e9}s` : "";
+	const reason = entry.reason ? `: ${entry.reason}` : "";
+	addChatLine(element("div", `${entry.actor} - ${entry.action} ${entry.target}${duration}${reason}`, "line notice"));
+
+	// a purge, timeout or ban removes every message from the user, a delete only removes one
+	if (["purge", "timeout", "ban"].includes(entry.action)) {
+		for (const line of document.querySelectorAll(".chat .line")) {
+			if (line.dataset.user === entry.target) {
+				line.classList.add("removed");
+			}
+		}
+	}
+}
+
+function showStatus(status) {
+	const el = document.getElementById("status");
+	el.className = `status ${status.connected ? "connected" : "disconnected"}`;
+	el.textContent = status.connected ? `connected to #${status.channel}` : "disconnected";
+	if (status.error) {
+		el.title = status.error;
+	}
+}
+
+function connectEvents() {
+	if (events) {
+		events.close();
+	}
+	events = new EventSource(`/api/events?types=message,moderation,connection,queue&access_token=${encodeURIComponent(token)}`);
+	events.addEventListener("message", (e) => chatMessage(JSON.parse(e.data)));
+	events.addEventListener("moderation", (e) => chatModeration(JSON.parse(e.data)));
+	events.addEventListener("connection", (e) => {
+		const data = JSON.parse(e.data).data;
+		showStatus({ connected: data.state === "connected", channel: data.channel, error: data.error });
+	});
+	events.addEventListener("queue", () => {
+		if (currentPage() === "queue") {
+			loadPage("queue");
+		}
+	});
+	events.onerror = () => showStatus({ connected: false, error: "lost the connection to the bot" });
+}
+
+// signing in
+
+async function signIn() {
+	try {
+		showStatus(await api("GET", "/status"));
+	} catch (error) {
+		if (error.status === 401) {
+			document.getElementById("login-error").textContent = error.message;
+			signOut();
+			return;
+		}
+		// a token without the status scope can still use the rest of the dashboard
+	}
+
+	document.getElementById("login").hidden = true;
+	document.getElementById("app").hidden = false;
+	document.getElementById("logout").hidden = false;
+	connectEvents();
+	loadPage(currentPage());
+}
+
+function signOut() {
+	localStorage.removeItem(tokenStorageKey);
+	token = null;
+	if (events) {
+		events.close();
+		events = null;
+	}
+	document.getElementById("login").hidden = false;
+	document.getElementById("app").hidden = true;
+	document.getElementById("logout").hidden = true;
+}
+
+document.getElementById("login-form").addEventListener("submit", (event) => {
+	event.preventDefault();
+	token = document.getElementById("token").value.trim();
+	localStorage.setItem(tokenStorageKey, token);
+	signIn();
+});
+
+document.getElementById("logout").addEventListener("click", signOut);
+window.addEventListener("hashchange", () => loadPage(currentPage()));
+
+if (token) {
+	signIn();
+} else {
+	signOut();
+}
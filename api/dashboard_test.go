@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestDashboard(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		description string
+		path        string
+		wantCode    int
+		wantBody    string
+	}{
+		{description: "should redirect the root to the dashboard", path: "/", wantCode: http.StatusFound},
+		{description: "should serve the dashboard without a token", path: "/dashboard/", wantCode: http.StatusOK, wantBody: "<title>PleasantBot</title>"},
+		{description: "should serve the dashboard's script", path: "/dashboard/app.js", wantCode: http.StatusOK, wantBody: "EventSource"},
+		{description: "should not serve files that don't exist", path: "/dashboard/nope.js", wantCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			s.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if recorder.Code != test.wantCode {
+				t.Fatalf("did not get the expected status\ngot - %d\nwant - %d", recorder.Code, test.wantCode)
+			}
+			if !strings.Contains(recorder.Body.String(), test.wantBody) {
+				t.Errorf("the response did not contain %q", test.wantBody)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.Name, s.Bot.ChannelName = "pleasantbot", "somechannel"
+
+	var status statusResponse
+	do(t, s, http.MethodGet, "/api/status", nil, &status)
+	if status.Connected || status.Channel != "somechannel" {
+		t.Errorf("did not get the expected status before connecting: %+v", status)
+	}
+
+	s.Bot.Events = bot.NewEventBus(0)
+	s.Bot.Publish(bot.EventConnection, bot.ConnectionEvent{State: bot.StateConnected, Channel: "somechannel"})
+	do(t, s, http.MethodGet, "/api/status", nil, &status)
+	if !status.Connected || status.Since.IsZero() {
+		t.Errorf("did not get the expected status after connecting: %+v", status)
+	}
+}
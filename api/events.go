@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// keepAliveInterval is how often an idle stream is pinged, so proxies don't close it
+const keepAliveInterval = 30 * time.Second
+
+var errNoEventStream = errors.New("the bot is not publishing events")
+
+// streamEvents sends events to a client using server-sent events. The event's ID and type are used as the SSE id and
+// event fields, so a browser's EventSource resumes from the last event it saw when it reconnects.
+func (s *Server) streamEvents(c *gin.Context) {
+	sub, ok := s.subscribe(c, "sse")
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	send := func(event bot.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		c.Writer.Flush()
+		return err
+	}
+
+	for _, event := range sub.Missed {
+		if send(event) != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-sub.Events():
+			if send(event) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// streamEventsWebSocket sends events to a client over a WebSocket, one JSON event per text message. Messages sent by
+// the client are ignored.
+func (s *Server) streamEventsWebSocket(c *gin.Context) {
+	sub, ok := s.subscribe(c, "websocket")
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has already responded
+	}
+	defer conn.Close()
+
+	// the connection has to be read from to notice the client closing it
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range sub.Missed {
+		if conn.WriteJSON(event) != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-sub.Events():
+			if conn.WriteJSON(event) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// subscribe reads the event types and the last seen event ID from the request and subscribes to the bot's events.
+// Types are given as '?types=message,moderation', and the last seen ID as the Last-Event-ID header or
+// '?last_event_id='. A client that falls behind loses its oldest events rather than holding up the bot. If ok is false
+// the request has already been failed.
+func (s *Server) subscribe(c *gin.Context, kind string) (sub *bot.Subscription, ok bool) {
+	if s.Bot.Events == nil {
+		fail(c, http.StatusServiceUnavailable, errNoEventStream)
+		return nil, false
+	}
+
+	types, err := parseEventTypes(c.Query("types"))
+	if err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	var after int64
+	if lastID != "" {
+		if after, err = strconv.ParseInt(lastID, 10, 64); err != nil {
+			fail(c, http.StatusBadRequest, fmt.Errorf("'%s' is not an event ID", lastID))
+			return nil, false
+		}
+	}
+
+	name := fmt.Sprintf("%s %s", kind, c.ClientIP())
+	return s.Bot.Events.Subscribe(name, bot.SubscribeOptions{Types: types, Backpressure: bot.DropOldest, After: after}), true
+}
+
+// parseEventTypes reads a comma separated list of event types, an empty list means every type
+func parseEventTypes(value string) ([]bot.EventType, error) {
+	var types []bot.EventType
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		valid := false
+		for _, eventType := range bot.EventTypes {
+			if bot.EventType(name) == eventType {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("'%s' is not an event type", name)
+		}
+		types = append(types, bot.EventType(name))
+	}
+	return types, nil
+}
+
+// checkOrigin allows WebSocket connections from the API's own host and from the configured CORS origins
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || strings.TrimPrefix(strings.TrimPrefix(origin, "http://"), "https://") == r.Host {
+		return true
+	}
+	for _, allowed := range s.Bot.CORSOrigins {
+		if origin == allowed || allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
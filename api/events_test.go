@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestStreamEvents(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.Events = bot.NewEventBus(0)
+	s.Bot.Publish(bot.EventMessage, bot.Item{Contents: "missed"})
+	s.Bot.Publish(bot.EventTimer, bot.TimerEvent{Name: "discord"})
+	s.Bot.Publish(bot.EventMessage, bot.Item{Contents: "also missed"})
+
+	server := httptest.NewServer(s.Router)
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events?types=message", nil)
+	request.Header.Set("Authorization", "Bearer "+s.token)
+	request.Header.Set("Last-Event-ID", "1")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("could not connect to the event stream: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("did not get the expected status\ngot - %d\nwant - %d", response.StatusCode, http.StatusOK)
+	}
+
+	events := make(chan bot.Event)
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+				var event bot.Event
+				json.Unmarshal([]byte(data), &event)
+				events <- event
+			}
+		}
+		close(events)
+	}()
+
+	// the resumed event, then a new one, skipping the timer event that wasn't asked for
+	if got := <-events; got.ID != 3 || got.Type != bot.EventMessage {
+		t.Errorf("did not get the missed event: %+v", got)
+	}
+	s.Bot.Publish(bot.EventTimer, bot.TimerEvent{Name: "discord"})
+	s.Bot.Publish(bot.EventMessage, bot.Item{Contents: "new"})
+	select {
+	case got := <-events:
+		if got.ID != 5 || got.Type != bot.EventMessage {
+			t.Errorf("did not get the new event: %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a new event")
+	}
+}
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.Events = bot.NewEventBus(0)
+	s.Bot.Publish(bot.EventCommand, bot.CommandEvent{Type: "!quote", User: "viewer"})
+
+	server := httptest.NewServer(s.Router)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/events/ws?last_event_id=0&access_token=" + s.token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("could not connect to the event stream: %v", err)
+	}
+	defer conn.Close()
+
+	// the server may not have subscribed yet when the dial returns, so keep publishing until something arrives
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				s.Bot.Publish(bot.EventModeration, bot.ModLogEntry{Action: bot.ModActionBan, Target: "spammer"})
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event struct {
+		Type bot.EventType   `json:"type"`
+		Data bot.ModLogEntry `json:"data"`
+	}
+	if err = conn.ReadJSON(&event); err != nil {
+		t.Fatalf("could not read an event: %v", err)
+	}
+	if event.Type != bot.EventModeration || event.Data.Target != "spammer" {
+		t.Errorf("did not get the expected event: %+v", event)
+	}
+}
+
+func TestStreamEventsErrors(t *testing.T) {
+	s := newTestServer(t)
+	if status := do(t, s, http.MethodGet, "/api/events", nil, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("did not get the expected status without an event stream\ngot - %d\nwant - %d", status, http.StatusServiceUnavailable)
+	}
+
+	s.Bot.Events = bot.NewEventBus(0)
+	tests := []struct {
+		description string
+		path        string
+	}{
+		{description: "should reject unknown event types", path: "/api/events?types=message,nope"},
+		{description: "should reject an invalid last event ID", path: "/api/events?last_event_id=abc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if status := do(t, s, http.MethodGet, test.path, nil, nil); status != http.StatusBadRequest {
+				t.Errorf("did not get the expected status\ngot - %d\nwant - %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+}
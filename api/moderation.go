@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// Moderator carries out moderation actions on the service the bot is connected to
+type Moderator interface {
+	DeleteMessage(id string) error
+	Timeout(username string, seconds int) error
+	Ban(username string) error
+	Unban(username string) error
+}
+
+var errNotConnected = errors.New("the bot is not connected to a channel")
+
+type moderationRequest struct {
+	Username  string `json:"username"`
+	MessageID string `json:"message_id"` // only used to delete a message
+	Seconds   int    `json:"seconds"`    // only used for timeouts
+	Reason    string `json:"reason"`
+}
+
+// moderate carries out a moderation action sent as 'POST /api/moderation/:action', where action is one of delete,
+// timeout, ban or unban. The action is recorded in the moderation log with the token's name as the actor.
+func (s *Server) moderate(c *gin.Context) {
+	if s.Moderator == nil {
+		fail(c, http.StatusServiceUnavailable, errNotConnected)
+		return
+	}
+
+	var request moderationRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	request.Username = strings.ToLower(strings.TrimPrefix(request.Username, "@"))
+
+	action := bot.ModAction(c.Param("action"))
+	switch {
+	case action != bot.ModActionDelete && action != bot.ModActionTimeout && action != bot.ModActionBan &&
+		action != bot.ModActionUnban:
+		fail(c, http.StatusNotFound, fmt.Errorf("'%s' is not a moderation action", action))
+		return
+	case action == bot.ModActionDelete && request.MessageID == "":
+		fail(c, http.StatusBadRequest, errors.New("a message_id is needed to delete a message"))
+		return
+	case action != bot.ModActionDelete && request.Username == "":
+		fail(c, http.StatusBadRequest, errors.New("a username is needed"))
+		return
+	case action == bot.ModActionTimeout && request.Seconds <= 0:
+		fail(c, http.StatusBadRequest, errors.New("a timeout needs a positive amount of seconds"))
+		return
+	}
+
+	entry := bot.ModLogEntry{Action: action, Actor: actorName(c), Target: request.Username, Reason: request.Reason}
+	var err error
+	switch action {
+	case bot.ModActionDelete:
+		err = s.Moderator.DeleteMessage(request.MessageID)
+	case bot.ModActionTimeout:
+		entry.Duration = time.Duration(request.Seconds) * time.Second
+		err = s.Moderator.Timeout(request.Username, request.Seconds)
+	case bot.ModActionBan:
+		err = s.Moderator.Ban(request.Username)
+	case bot.ModActionUnban:
+		err = s.Moderator.Unban(request.Username)
+	}
+	if err == nil {
+		err = s.Bot.LogModAction(entry)
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// actorName is how the request's token is named in the moderation log
+func actorName(c *gin.Context) string {
+	if token, ok := c.Get(tokenKey); ok && token.(bot.APIToken).Name != "" {
+		return fmt.Sprintf("api:%s", token.(bot.APIToken).Name)
+	}
+	return "api"
+}
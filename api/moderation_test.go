@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// recordModerator records the moderation actions it is asked to carry out
+type recordModerator struct {
+	actions []string
+}
+
+func (rm *recordModerator) DeleteMessage(id string) error {
+	rm.actions = append(rm.actions, "delete "+id)
+	return nil
+}
+
+func (rm *recordModerator) Timeout(username string, seconds int) error {
+	rm.actions = append(rm.actions, fmt.Sprintf("timeout %s %d", username, seconds))
+	return nil
+}
+
+func (rm *recordModerator) Ban(username string) error {
+	rm.actions = append(rm.actions, "ban "+username)
+	return nil
+}
+
+func (rm *recordModerator) Unban(username string) error {
+	rm.actions = append(rm.actions, "unban "+username)
+	return nil
+}
+
+func TestModerationRoutes(t *testing.T) {
+	s := newTestServer(t)
+	if code := do(t, s, http.MethodPost, "/api/moderation/ban", moderationRequest{Username: "spammer"}, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("moderating without a connection was not unavailable, got - %d", code)
+	}
+
+	moderator := &recordModerator{}
+	s.Moderator = moderator
+
+	tests := []struct {
+		description string
+		action      string
+		request     moderationRequest
+		wantCode    int
+		wantAction  string
+	}{
+		{
+			description: "should delete a message",
+			action:      "delete",
+			request:     moderationRequest{MessageID: "abc"},
+			wantCode:    http.StatusOK,
+			wantAction:  "delete abc",
+		},
+		{
+			description: "should time out a user",
+			action:      "timeout",
+			request:     moderationRequest{Username: "@Viewer", Seconds: 600},
+			wantCode:    http.StatusOK,
+			wantAction:  "timeout viewer 600",
+		},
+		{
+			description: "should ban a user",
+			action:      "ban",
+			request:     moderationRequest{Username: "spammer", Reason: "bot account"},
+			wantCode:    http.StatusOK,
+			wantAction:  "ban spammer",
+		},
+		{
+			description: "should unban a user",
+			action:      "unban",
+			request:     moderationRequest{Username: "spammer"},
+			wantCode:    http.StatusOK,
+			wantAction:  "unban spammer",
+		},
+		{
+			description: "should need a message ID to delete",
+			action:      "delete",
+			request:     moderationRequest{Username: "viewer"},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			description: "should need seconds for a timeout",
+			action:      "timeout",
+			request:     moderationRequest{Username: "viewer"},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			description: "should need a username to ban",
+			action:      "ban",
+			request:     moderationRequest{},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			description: "should reject unknown actions",
+			action:      "pardon",
+			request:     moderationRequest{Username: "viewer"},
+			wantCode:    http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			moderator.actions = nil
+			if code := do(t, s, http.MethodPost, "/api/moderation/"+test.action, test.request, nil); code != test.wantCode {
+				t.Fatalf("did not get the expected status\ngot - %d\nwant - %d", code, test.wantCode)
+			}
+
+			var got string
+			if len(moderator.actions) > 0 {
+				got = moderator.actions[0]
+			}
+			if got != test.wantAction {
+				t.Errorf("did not get the expected action\ngot - %q\nwant - %q", got, test.wantAction)
+			}
+		})
+	}
+
+	entries, _ := s.Bot.ModLog(bot.ModLogFilter{User: "spammer"})
+	if len(entries) != 2 || entries[1].Actor != "api:test" || entries[1].Reason != "bot account" {
+		t.Errorf("the actions were not logged as expected: %+v", entries)
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// listModLog returns the moderation log, filtered by the optional user, since, until and limit query parameters.
+// Dates can be given as 2006-01-02 or as a duration into the past such as 2d.
+func (s *Server) listModLog(c *gin.Context) {
+	var filter bot.ModLogFilter
+	var err error
+
+	filter.User = c.Query("user")
+	if filter.Since, err = bot.ParseSince(c.Query("since")); err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if filter.Until, err = bot.ParseUntil(c.Query("until")); err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			fail(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entries, err := s.Bot.ModLog(filter)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if entries == nil {
+		entries = []bot.ModLogEntry{}
+	}
+	c.JSON(http.StatusOK, entries)
+}
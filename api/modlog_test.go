@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestModLogRoute(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.LogModAction(bot.ModLogEntry{Action: bot.ModActionBan, Actor: "pleasantbot", Target: "spammer"})
+	s.Bot.LogModAction(bot.ModLogEntry{Action: bot.ModActionTimeout, Actor: "pleasantbot", Target: "viewer"})
+
+	var entries []bot.ModLogEntry
+	if code := do(t, s, http.MethodGet, "/api/modlog?user=spammer&since=1d", nil, &entries); code != http.StatusOK {
+		t.Fatalf("did not get the expected status, got - %d", code)
+	}
+	if len(entries) != 1 || entries[0].Action != bot.ModActionBan {
+		t.Errorf("did not get the expected entries: %+v", entries)
+	}
+
+	if code := do(t, s, http.MethodGet, "/api/modlog?since=whenever", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("an invalid date was not a bad request, got - %d", code)
+	}
+}
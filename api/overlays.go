@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// overlayFiles holds the default overlays, each can be replaced by a file of the same name in the overlay directory
+//
+//go:embed overlays
+var overlayFiles embed.FS
+
+// overlayNameRegex matches the names overlay files may have, which keeps requests inside the overlay directory
+var overlayNameRegex = regexp.MustCompile(`^[\w-]+(\.[a-z0-9]+)?$`)
+
+// overlayData is what an overlay template is rendered with
+type overlayData struct {
+	Name     string
+	Token    string            // the token the overlay was opened with, used to follow the event stream
+	Options  map[string]string // the page's other query parameters, e.g. ?limit=10
+	Counters []bot.Counter     // the counters when the overlay was opened
+}
+
+// overlayRoutes serves the overlays for OBS browser sources at /overlays/<name>, such as /overlays/chat. A name
+// without an extension is rendered as the template <name>.html, anything else is served as it is. Files in the
+// overlay directory are used in place of the defaults, so a theme can change a single file.
+func (s *Server) overlayRoutes() {
+	s.Router.GET("/overlays/:name", s.serveOverlay)
+}
+
+func (s *Server) serveOverlay(c *gin.Context) {
+	name := c.Param("name")
+	if !overlayNameRegex.MatchString(name) {
+		fail(c, http.StatusNotFound, fmt.Errorf("there is no overlay named '%s'", name))
+		return
+	}
+
+	if filepath.Ext(name) != "" {
+		content, err := s.overlayFile(name)
+		if err != nil {
+			fail(c, http.StatusNotFound, fmt.Errorf("there is no overlay file named '%s'", name))
+			return
+		}
+		c.Data(http.StatusOK, mime.TypeByExtension(filepath.Ext(name)), content)
+		return
+	}
+
+	content, err := s.overlayFile(name + ".html")
+	if err != nil {
+		fail(c, http.StatusNotFound, fmt.Errorf("there is no overlay named '%s'", name))
+		return
+	}
+
+	data := overlayData{Name: name, Token: c.Query("access_token"), Options: make(map[string]string)}
+	s.lock.Lock()
+	data.Counters = s.Bot.SortedCounters()
+	s.lock.Unlock()
+	for key, values := range c.Request.URL.Query() {
+		if key != "access_token" && len(values) > 0 {
+			data.Options[key] = values[0]
+		}
+	}
+
+	// option returns a query parameter or a default, e.g. {{option "limit" "10"}}
+	funcs := template.FuncMap{"option": func(key, fallback string) string {
+		if value, ok := data.Options[key]; ok {
+			return value
+		}
+		return fallback
+	}}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(string(content))
+	if err != nil {
+		fail(c, http.StatusInternalServerError, fmt.Errorf("the '%s' overlay template is invalid: %v", name, err))
+		return
+	}
+
+	var page bytes.Buffer
+	if err = tmpl.Execute(&page, data); err != nil {
+		fail(c, http.StatusInternalServerError, fmt.Errorf("could not render the '%s' overlay: %v", name, err))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// overlayFile reads a file from the overlay directory, falling back to the default overlays. Files are read on every
+// request so changes to a theme show up when the browser source is refreshed.
+func (s *Server) overlayFile(name string) ([]byte, error) {
+	if s.OverlayDir != "" {
+		content, err := os.ReadFile(filepath.Join(s.OverlayDir, name))
+		if err == nil {
+			return content, nil
+		}
+	}
+	return fs.ReadFile(overlayFiles, "overlays/"+name)
+}
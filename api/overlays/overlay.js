@@ -0,0 +1,68 @@
+// overlay.js connects an overlay to the bot's event stream. The browser reconnects on its own if the bot restarts,
+// picking up from the last event it saw.
+"use strict";
+
+// followEvents calls handlers[type](data, event) for each event of a type in handlers
+function followEvents(token, handlers) {
+	const types = Object.keys(handlers).join(",");
+	const events = new EventSource(`/api/events?types=${types}&access_token=${encodeURIComponent(token)}`);
+	for (const type of Object.keys(handlers)) {
+		events.addEventListener(type, (e) => {
+			const event = JSON.parse(e.data);
+			handlers[type](event.data, event);
+		});
+	}
+	return events;
+}
+
+// element creates an element with the given text, it never interprets text as HTML
+function element(tag, text, className) {
+	const el = document.createElement(tag);
+	if (text !== undefined && text !== null) {
+		el.textContent = text;
+	}
+	if (className) {
+		el.className = className;
+	}
+	return el;
+}
+
+// Popups shows one element at a time for a number of seconds each, queueing the rest
+class Popups {
+	constructor(container, seconds) {
+		this.container = container;
+		this.seconds = seconds;
+		this.queue = [];
+		this.showing = false;
+	}
+
+	show(el) {
+		this.queue.push(el);
+		if (!this.showing) {
+			this.next();
+		}
+	}
+
+	next() {
+		const el = this.queue.shift();
+		if (!el) {
+			this.showing = false;
+			return;
+		}
+
+		this.showing = true;
+		this.container.replaceChildren(el);
+		requestAnimationFrame(() => el.classList.add("visible"));
+		if (this.seconds > 0) {
+			setTimeout(() => {
+				el.classList.remove("visible");
+				setTimeout(() => this.next(), 500);
+			}, this.seconds * 1000);
+		} else {
+			this.showing = this.queue.length > 0;
+			if (this.showing) {
+				this.next();
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestOverlays(t *testing.T) {
+	s := newTestServer(t)
+	s.OverlayDir = t.TempDir()
+	os.WriteFile(filepath.Join(s.OverlayDir, "quote.html"), []byte(`<p>{{option "color" "red"}} {{.Name}}</p>`), 0644)
+	os.WriteFile(filepath.Join(s.OverlayDir, "overlay.css"), []byte("body { color: blue; }"), 0644)
+	os.WriteFile(filepath.Join(s.OverlayDir, "broken.html"), []byte("{{.Nope"), 0644)
+	s.Bot.Counters = map[string]*bot.Counter{"deaths": {Name: "deaths", Value: 3}}
+
+	tests := []struct {
+		description string
+		path        string
+		wantCode    int
+		wantBody    string
+		wantType    string
+	}{
+		{
+			description: "should render a default overlay with the token for the event stream",
+			path:        "/overlays/chat?access_token=pb_abc&limit=5",
+			wantCode:    http.StatusOK,
+			wantBody:    `followEvents("pb_abc"`,
+			wantType:    "text/html",
+		},
+		{
+			description: "should render the counters as they are when the overlay is opened",
+			path:        "/overlays/counters",
+			wantCode:    http.StatusOK,
+			wantBody:    `[{"name":"deaths","value":3,"per_stream":false}]`,
+		},
+		{
+			description: "should render options given in the query",
+			path:        "/overlays/chat?limit=5",
+			wantCode:    http.StatusOK,
+			wantBody:    `Number("5")`,
+		},
+		{
+			description: "should use a template from the overlay directory in place of the default",
+			path:        "/overlays/quote?color=green",
+			wantCode:    http.StatusOK,
+			wantBody:    "<p>green quote</p>",
+		},
+		{
+			description: "should use option defaults",
+			path:        "/overlays/quote",
+			wantCode:    http.StatusOK,
+			wantBody:    "<p>red quote</p>",
+		},
+		{
+			description: "should serve files from the overlay directory in place of the default",
+			path:        "/overlays/overlay.css",
+			wantCode:    http.StatusOK,
+			wantBody:    "color: blue",
+			wantType:    "text/css",
+		},
+		{
+			description: "should serve default files",
+			path:        "/overlays/overlay.js",
+			wantCode:    http.StatusOK,
+			wantBody:    "function followEvents",
+		},
+		{
+			description: "should fail on an invalid template",
+			path:        "/overlays/broken",
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			description: "should not find overlays that don't exist",
+			path:        "/overlays/nope",
+			wantCode:    http.StatusNotFound,
+		},
+		{
+			description: "should not serve files outside the overlay directory",
+			path:        "/overlays/..%2Fserver.go",
+			wantCode:    http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			s.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if recorder.Code != test.wantCode {
+				t.Fatalf("did not get the expected status\ngot - %d\nwant - %d", recorder.Code, test.wantCode)
+			}
+			if !strings.Contains(recorder.Body.String(), test.wantBody) {
+				t.Errorf("the response did not contain %q\ngot - %s", test.wantBody, recorder.Body.String())
+			}
+			if !strings.HasPrefix(recorder.Header().Get("Content-Type"), test.wantType) {
+				t.Errorf("did not get the expected content type\ngot - %s\nwant - %s", recorder.Header().Get("Content-Type"), test.wantType)
+			}
+		})
+	}
+}
+
+// every default overlay must render, html/template only finds some mistakes when a template is executed
+func TestDefaultOverlaysRender(t *testing.T) {
+	s := newTestServer(t)
+	for _, name := range []string{"chat", "quote", "timers", "alerts"} {
+		recorder := httptest.NewRecorder()
+		s.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/overlays/"+name+"?access_token=pb_abc", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("the %s overlay did not render: %s", name, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type permitRequest struct {
+	Username string `json:"username" binding:"required"`
+	Seconds  int    `json:"seconds" binding:"min=0"` // 0 gives a one-time permit
+}
+
+func (s *Server) listPermits(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Bot.PermittedUsers)
+}
+
+func (s *Server) addPermit(c *gin.Context) {
+	var request permitRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	username := strings.ToLower(strings.TrimPrefix(request.Username, "@"))
+	s.Bot.AddPermittedUser(username, time.Duration(request.Seconds)*time.Second)
+	c.JSON(http.StatusCreated, s.Bot.PermittedUsers[username])
+}
+
+func (s *Server) deletePermit(c *gin.Context) {
+	if !s.Bot.DeletePermittedUser(strings.ToLower(c.Param("username"))) {
+		fail(c, http.StatusNotFound, fmt.Errorf("'%s' does not have a permit", c.Param("username")))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
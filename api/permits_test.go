@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPermitRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	if code := do(t, s, http.MethodPost, "/api/permits", permitRequest{Username: "@Viewer", Seconds: 60}, nil); code != http.StatusCreated {
+		t.Fatalf("did not get the expected status when adding, got - %d", code)
+	}
+	permit, found := s.Bot.PermittedUsers["viewer"]
+	if !found || permit.Expires.IsZero() {
+		t.Errorf("a timed permit was not given to the user: %+v", permit)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/permits/viewer", nil, nil); code != http.StatusNoContent {
+		t.Errorf("did not get the expected status when deleting, got - %d", code)
+	}
+	if code := do(t, s, http.MethodDelete, "/api/permits/viewer", nil, nil); code != http.StatusNotFound {
+		t.Errorf("deleting a missing permit was not a not found, got - %d", code)
+	}
+}
@@ -0,0 +1,11 @@
+package api
+
+import "github.com/liamphmurphy/pleasantbot/plugin"
+
+// AddPluginRoutes serves a plugin's routes under /api/plugins/<name>
+func (s *Server) AddPluginRoutes(name string, routes []plugin.Route) {
+	group := s.Router.Group("/api/plugins/"+name, s.locked, s.authorize("plugins"))
+	for _, route := range routes {
+		group.Handle(route.Method, route.Path, route.Handler)
+	}
+}
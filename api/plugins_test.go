@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/plugin"
+)
+
+func TestPluginRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.AddPluginRoutes("hello", []plugin.Route{{Method: http.MethodGet, Path: "/greeting", Handler: func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]string{"greeting": "hi there"})
+	}}})
+
+	var got map[string]string
+	if status := do(t, s, http.MethodGet, "/api/plugins/hello/greeting", nil, &got); status != http.StatusOK {
+		t.Fatalf("did not get the expected status\ngot - %v\nwant - %v", status, http.StatusOK)
+	}
+	if got["greeting"] != "hi there" {
+		t.Errorf("did not get the expected greeting\ngot - %v\nwant - %v", got["greeting"], "hi there")
+	}
+
+	if status := doWithToken(t, s, "", http.MethodGet, "/api/plugins/hello/greeting", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("did not get the expected status without a token\ngot - %v\nwant - %v", status, http.StatusUnauthorized)
+	}
+}
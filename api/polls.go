@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+const defaultPollLimit = 20
+
+// listPolls returns past and running polls newest first, with an optional limit query parameter
+func (s *Server) listPolls(c *gin.Context) {
+	limit := defaultPollLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			fail(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	polls, err := s.Bot.Polls(limit)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	// the running poll's tally is kept in memory, which is more up to date than the database
+	for i := range polls {
+		if s.Bot.Poll != nil && polls[i].ID == s.Bot.Poll.ID {
+			polls[i] = *s.Bot.Poll
+		}
+	}
+	if polls == nil {
+		polls = []bot.Poll{}
+	}
+	c.JSON(http.StatusOK, polls)
+}
+
+// getCurrentPoll returns the running poll
+func (s *Server) getCurrentPoll(c *gin.Context) {
+	if s.Bot.Poll == nil {
+		fail(c, http.StatusNotFound, errors.New("there is no poll running"))
+		return
+	}
+	c.JSON(http.StatusOK, s.Bot.Poll)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestPollRoutes(t *testing.T) {
+	s := newTestServer(t)
+	if code := do(t, s, http.MethodGet, "/api/polls/current", nil, nil); code != http.StatusNotFound {
+		t.Errorf("did not get the expected status without a poll\ngot - %d\nwant - %d", code, http.StatusNotFound)
+	}
+
+	if _, err := s.Bot.StartPoll("pizza?", []string{"yes", "no"}, 0, "mod"); err != nil {
+		t.Fatalf("could not start the poll: %v", err)
+	}
+	s.Bot.Vote(bot.User{ID: "1", Name: "viewer"}, 1)
+
+	var current bot.Poll
+	if code := do(t, s, http.MethodGet, "/api/polls/current", nil, &current); code != http.StatusOK || current.Votes[0] != 1 {
+		t.Errorf("did not get the running poll, got - %d %+v", code, current)
+	}
+
+	var polls []bot.Poll
+	if code := do(t, s, http.MethodGet, "/api/polls", nil, &polls); code != http.StatusOK || len(polls) != 1 || polls[0].Votes[0] != 1 {
+		t.Errorf("did not list the expected polls, got - %d %+v", code, polls)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type queueRequest struct {
+	Open *bool `json:"open" binding:"required"`
+}
+
+type nextRequest struct {
+	Count int `json:"count"` // how many viewers to take, defaults to 1
+}
+
+// getQueue returns whether the queue is open and everyone in it, in order
+func (s *Server) getQueue(c *gin.Context) {
+	queue := s.Bot.Queue
+	if queue.Entries == nil {
+		queue.Entries = []bot.QueueEntry{}
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
+// setQueue opens or closes the queue
+func (s *Server) setQueue(c *gin.Context) {
+	var request queueRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if err := s.Bot.SetQueueOpen(*request.Open); err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	s.getQueue(c)
+}
+
+// nextInQueue takes viewers from the front of the queue and returns them
+func (s *Server) nextInQueue(c *gin.Context) {
+	request := nextRequest{Count: 1}
+	if c.Request.ContentLength > 0 && !bindJSON(c, &request) {
+		return
+	}
+	next, err := s.Bot.NextInQueue(request.Count)
+	if err != nil {
+		if errors.As(err, &bot.NonFatalError{}) {
+			fail(c, http.StatusBadRequest, err)
+		} else {
+			fail(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if next == nil {
+		next = []bot.QueueEntry{}
+	}
+	c.JSON(http.StatusOK, next)
+}
+
+func (s *Server) clearQueue(c *gin.Context) {
+	if err := s.Bot.ClearQueue(); err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// removeFromQueue takes a single viewer out of the queue
+func (s *Server) removeFromQueue(c *gin.Context) {
+	user := strings.ToLower(c.Param("username"))
+	removed, err := s.Bot.LeaveQueue(user)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !removed {
+		fail(c, http.StatusNotFound, errors.New(user+" is not in the queue"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestQueueRoutes(t *testing.T) {
+	s := newTestServer(t)
+	if code := do(t, s, http.MethodPut, "/api/queue", map[string]bool{"open": true}, nil); code != http.StatusOK {
+		t.Fatalf("could not open the queue, got - %d", code)
+	}
+	for _, name := range []string{"first", "second", "third"} {
+		if _, _, err := s.Bot.JoinQueue(bot.User{Name: name}); err != nil {
+			t.Fatalf("could not join the queue: %v", err)
+		}
+	}
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        interface{}
+		wantCode    int
+	}{
+		{description: "should take the first viewer", method: http.MethodPost, path: "/api/queue/next", wantCode: http.StatusOK},
+		{description: "should remove a viewer", method: http.MethodDelete, path: "/api/queue/Third", wantCode: http.StatusNoContent},
+		{description: "should not remove a viewer who isn't in the queue", method: http.MethodDelete, path: "/api/queue/first", wantCode: http.StatusNotFound},
+		{description: "should not take fewer than one viewer", method: http.MethodPost, path: "/api/queue/next", body: nextRequest{Count: -1}, wantCode: http.StatusBadRequest},
+		{description: "should need the queue's state", method: http.MethodPut, path: "/api/queue", body: map[string]string{}, wantCode: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if code := do(t, s, test.method, test.path, test.body, nil); code != test.wantCode {
+			t.Errorf("%s\ndid not get the expected status\ngot - %d\nwant - %d", test.description, code, test.wantCode)
+		}
+	}
+
+	var queue bot.Queue
+	if code := do(t, s, http.MethodGet, "/api/queue", nil, &queue); code != http.StatusOK || !queue.Open ||
+		len(queue.Entries) != 1 || queue.Entries[0].User != "second" {
+		t.Errorf("did not get the expected queue, got - %d %+v", code, queue)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/queue", nil, nil); code != http.StatusNoContent || len(s.Bot.Queue.Entries) != 0 {
+		t.Errorf("could not clear the queue, got - %d", code)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type quoteRequest struct {
+	Quote     string `json:"quote" binding:"required"`
+	Submitter string `json:"submitter"`
+}
+
+// quoteResponse is a quote along with its ID
+type quoteResponse struct {
+	ID        int    `json:"id"`
+	Quote     string `json:"quote"`
+	Timestamp string `json:"timestamp"`
+	Submitter string `json:"submitter"`
+}
+
+func (s *Server) quoteResponse(id int) quoteResponse {
+	quote := s.Bot.Quotes[id]
+	return quoteResponse{ID: id, Quote: quote.Quote, Timestamp: quote.Timestamp, Submitter: quote.Submitter}
+}
+
+// quoteID reads the :id parameter, responding with a not found if it isn't an existing quote
+func (s *Server) quoteID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if _, found := s.Bot.Quotes[id]; err != nil || !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("there is no quote with the ID '%s'", c.Param("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) listQuotes(c *gin.Context) {
+	quotes := []quoteResponse{}
+	for id := range s.Bot.Quotes {
+		quotes = append(quotes, s.quoteResponse(id))
+	}
+	c.JSON(http.StatusOK, quotes)
+}
+
+func (s *Server) getQuote(c *gin.Context) {
+	if id, ok := s.quoteID(c); ok {
+		c.JSON(http.StatusOK, s.quoteResponse(id))
+	}
+}
+
+func (s *Server) addQuote(c *gin.Context) {
+	var request quoteRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	err := s.Bot.AddQuote(request.Quote, request.Submitter)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the database picks the ID, which will be the highest one after the quotes are reloaded
+	var newest int
+	for id := range s.Bot.Quotes {
+		if id > newest {
+			newest = id
+		}
+	}
+	c.JSON(http.StatusCreated, s.quoteResponse(newest))
+}
+
+// editQuote changes a quote's text, and its submitter if one is given
+func (s *Server) editQuote(c *gin.Context) {
+	var request quoteRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	id, ok := s.quoteID(c)
+	if !ok {
+		return
+	}
+
+	submitter := request.Submitter
+	if submitter == "" {
+		submitter = s.Bot.Quotes[id].Submitter
+	}
+	err := s.Bot.EditQuote(id, request.Quote, submitter)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.quoteResponse(id))
+}
+
+func (s *Server) deleteQuote(c *gin.Context) {
+	if _, ok := s.quoteID(c); !ok {
+		return
+	}
+
+	err := s.Bot.DeleteQuote(c.Param("id"))
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
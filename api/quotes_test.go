@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestQuoteRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	var quote quoteResponse
+	if code := do(t, s, http.MethodPost, "/api/quotes", quoteRequest{Quote: "it's a quote", Submitter: "viewer"}, &quote); code != http.StatusCreated {
+		t.Fatalf("did not get the expected status when adding, got - %d", code)
+	}
+	if quote.ID != 1 || quote.Quote != "it's a quote" || quote.Submitter != "viewer" {
+		t.Errorf("did not get the expected quote back: %+v", quote)
+	}
+
+	var quotes []quoteResponse
+	do(t, s, http.MethodGet, "/api/quotes", nil, &quotes)
+	if len(quotes) != 1 {
+		t.Errorf("did not list the expected quotes: %+v", quotes)
+	}
+
+	if code := do(t, s, http.MethodGet, "/api/quotes/abc", nil, nil); code != http.StatusNotFound {
+		t.Errorf("an invalid ID was not a not found, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodPut, "/api/quotes/1", quoteRequest{Quote: "it's a better quote"}, &quote); code != http.StatusOK {
+		t.Errorf("did not get the expected status when editing, got - %d", code)
+	}
+	if quote.ID != 1 || quote.Quote != "it's a better quote" || quote.Submitter != "viewer" {
+		t.Errorf("did not get the expected quote back after editing: %+v", quote)
+	}
+	if s.Bot.Quotes[1].Quote != "it's a better quote" {
+		t.Errorf("the quote was not changed in the bot: %+v", s.Bot.Quotes[1])
+	}
+	if code := do(t, s, http.MethodPut, "/api/quotes/2", quoteRequest{Quote: "missing"}, nil); code != http.StatusNotFound {
+		t.Errorf("editing a missing quote was not a not found, got - %d", code)
+	}
+	if code := do(t, s, http.MethodPut, "/api/quotes/1", quoteRequest{Quote: " "}, nil); code != http.StatusBadRequest {
+		t.Errorf("editing to an empty quote was not a bad request, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/quotes/1", nil, nil); code != http.StatusNoContent {
+		t.Errorf("did not get the expected status when deleting, got - %d", code)
+	}
+	if len(s.Bot.Quotes) != 0 {
+		t.Errorf("the quote was not removed from the bot")
+	}
+}
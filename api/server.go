@@ -0,0 +1,162 @@
+// Package api serves the bot's HTTP management API. Every change made through the API goes through the same Bot
+// methods used by chat, so the bot's in-memory data and the database stay in sync.
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// Server holds what is needed to serve the API for a single bot
+type Server struct {
+	Bot        *bot.Bot
+	Router     *gin.Engine
+	Moderator  Moderator   // carries out moderation actions sent to the API, nil if the bot isn't connected
+	OverlayDir string      // holds overlay templates and files that replace the defaults, none are used if empty
+	lock       sync.Locker // shared with the service's chat loop so the two never change the bot's data at the same time
+}
+
+// errorResponse is the body sent back whenever a request fails
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer creates a Server for b with all of the API routes set up. lock must be the same lock the service holds
+// while it handles chat messages.
+func NewServer(b *bot.Bot, lock sync.Locker) *Server {
+	gin.SetMode(gin.ReleaseMode)
+	server := &Server{Bot: b, Router: gin.New(), lock: lock}
+	server.Router.Use(gin.Recovery())
+	if len(b.CORSOrigins) > 0 {
+		corsConfig := cors.DefaultConfig()
+		corsConfig.AllowOrigins = b.CORSOrigins
+		corsConfig.AddAllowHeaders("Authorization")
+		server.Router.Use(cors.New(corsConfig))
+	}
+	server.routes()
+	return server
+}
+
+// Run starts serving the API on addr, it blocks until the server stops
+func (s *Server) Run(addr string) error {
+	return s.Router.Run(addr)
+}
+
+func (s *Server) routes() {
+	// event streams stay open, so they must not hold the bot's lock
+	events := s.Router.Group("/api/events", s.authorize("events"))
+	events.GET("", s.streamEvents)
+	events.GET("/ws", s.streamEventsWebSocket)
+
+	api := s.Router.Group("/api", s.locked)
+
+	commands := api.Group("/commands", s.authorize("commands"))
+	commands.GET("", s.listCommands)
+	commands.GET("/:name", s.getCommand)
+	commands.POST("", s.addCommand)
+	commands.PUT("/:name", s.editCommand)
+	commands.PUT("/:name/script", s.setCommandScript)
+	commands.DELETE("/:name", s.deleteCommand)
+
+	quotes := api.Group("/quotes", s.authorize("quotes"))
+	quotes.GET("", s.listQuotes)
+	quotes.GET("/:id", s.getQuote)
+	quotes.POST("", s.addQuote)
+	quotes.PUT("/:id", s.editQuote)
+	quotes.DELETE("/:id", s.deleteQuote)
+
+	timers := api.Group("/timers", s.authorize("timers"))
+	timers.GET("", s.listTimers)
+	timers.GET("/:name", s.getTimer)
+	timers.POST("", s.addTimer)
+	timers.PUT("/:name", s.editTimer)
+	timers.DELETE("/:name", s.deleteTimer)
+
+	badWords := api.Group("/badwords", s.authorize("badwords"))
+	badWords.GET("", s.listBadWords)
+	badWords.POST("", s.addBadWord)
+	badWords.PUT("/:phrase", s.editBadWord)
+	badWords.DELETE("/:phrase", s.deleteBadWord)
+
+	permits := api.Group("/permits", s.authorize("permits"))
+	permits.GET("", s.listPermits)
+	permits.POST("", s.addPermit)
+	permits.DELETE("/:username", s.deletePermit)
+
+	modLog := api.Group("/modlog", s.authorize("modlog"))
+	modLog.GET("", s.listModLog)
+
+	chatLog := api.Group("/chatlog", s.authorize("chatlog"))
+	chatLog.GET("", s.searchChatLog)
+
+	strikes := api.Group("/strikes", s.authorize("strikes"))
+	strikes.GET("", s.listStrikes)
+	strikes.GET("/:username", s.getStrikes)
+	strikes.DELETE("/:username", s.pardonUser)
+
+	moderation := api.Group("/moderation", s.authorize("moderation"))
+	moderation.POST("/:action", s.moderate)
+
+	polls := api.Group("/polls", s.authorize("polls"))
+	polls.GET("", s.listPolls)
+	polls.GET("/current", s.getCurrentPoll)
+
+	queue := api.Group("/queue", s.authorize("queue"))
+	queue.GET("", s.getQueue)
+	queue.PUT("", s.setQueue)
+	queue.POST("/next", s.nextInQueue)
+	queue.DELETE("", s.clearQueue)
+	queue.DELETE("/:username", s.removeFromQueue)
+
+	counters := api.Group("/counters", s.authorize("counters"))
+	counters.GET("", s.listCounters)
+	counters.GET("/:name", s.getCounter)
+	counters.POST("", s.addCounter)
+	counters.PUT("/:name", s.editCounter)
+	counters.DELETE("/:name", s.deleteCounter)
+
+	chatters := api.Group("/chatters", s.authorize("chatters"))
+	chatters.GET("/:username", s.getChatter)
+	chatters.POST("/:username/notes", s.addNote)
+
+	sessions := api.Group("/sessions", s.authorize("sessions"))
+	sessions.GET("", s.listSessions)
+	sessions.GET("/:id", s.getSession)
+	sessions.PUT("", s.setSession)
+
+	stats := api.Group("/stats", s.authorize("stats"))
+	stats.GET("", s.getStats)
+	stats.GET("/chatters/:username", s.getUserStats)
+	stats.GET("/export/:kind", s.exportStats)
+
+	status := api.Group("/status", s.authorize("status"))
+	status.GET("", s.getStatus)
+
+	s.dashboardRoutes()
+	s.overlayRoutes()
+}
+
+// locked holds the bot's lock for the whole request
+func (s *Server) locked(c *gin.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c.Next()
+}
+
+// fail aborts the request with status and err as the body
+func fail(c *gin.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, errorResponse{Error: err.Error()})
+}
+
+// bindJSON reads the request body into value, responding with a bad request if it can't
+func bindJSON(c *gin.Context, value interface{}) bool {
+	if err := c.ShouldBindJSON(value); err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+// testServer is a Server along with a token that can access everything
+type testServer struct {
+	*Server
+	token string
+}
+
+// newTestServer creates a Server for a bot backed by a fresh database in a temporary directory
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	database := bot.Database{
+		CommandColumns: []string{"commandname", "commandresponse", "perm", "count"},
+		QuoteColumns:   []string{"quote", "timestamp", "submitter"},
+		TimerColumns:   []string{"timername", "message", "minutes", "enabled"},
+	}
+	err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase)
+	if err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+
+	b := &bot.Bot{Storage: &database, Commands: map[string]*bot.CommandValue{}, Quotes: map[int]*bot.QuoteValues{},
+		Timers: map[string]*bot.TimedValue{}, PermittedUsers: map[string]bot.LinkPermit{}}
+	token, _, err := b.CreateAPIToken("test", []string{"*:write"}, 0)
+	if err != nil {
+		t.Fatalf("could not create a test token: %v", err)
+	}
+	return &testServer{Server: NewServer(b, &sync.Mutex{}), token: token}
+}
+
+// do sends a request to the server with the server's token, encoding body as JSON if it isn't nil, and decodes the
+// response into out if out isn't nil. The response's status code is returned.
+func do(t *testing.T, s *testServer, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	return doWithToken(t, s, s.token, method, path, body, out)
+}
+
+// doWithToken is do with a specific token, no token is sent if it is empty
+func doWithToken(t *testing.T, s *testServer, token, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("could not encode the request body: %v", err)
+		}
+	}
+
+	request := httptest.NewRequest(method, path, &reader)
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	s.Router.ServeHTTP(recorder, request)
+
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("could not decode the response %q: %v", recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestCommandRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	var command bot.CommandValue
+	if code := do(t, s, http.MethodPost, "/api/commands", commandRequest{Name: "hello", Response: "hi there"}, &command); code != http.StatusCreated {
+		t.Fatalf("did not get the expected status when adding\ngot - %d\nwant - %d", code, http.StatusCreated)
+	}
+	if command.Response != "hi there" {
+		t.Errorf("did not get the expected command back: %+v", command)
+	}
+	if _, found := s.Bot.Commands["!hello"]; !found {
+		t.Errorf("the command was not added to the bot")
+	}
+
+	if code := do(t, s, http.MethodPost, "/api/commands", commandRequest{Name: "!hello", Response: "again"}, nil); code != http.StatusConflict {
+		t.Errorf("adding a duplicate command did not conflict, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodPut, "/api/commands/hello", commandRequest{Response: "hello again"}, nil); code != http.StatusOK {
+		t.Errorf("did not get the expected status when editing, got - %d", code)
+	}
+	if s.Bot.Commands["!hello"].Response != "hello again" {
+		t.Errorf("the command was not edited in the bot: %+v", s.Bot.Commands["!hello"])
+	}
+
+	var commands map[string]bot.CommandValue
+	do(t, s, http.MethodGet, "/api/commands", nil, &commands)
+	if len(commands) != 1 || commands["!hello"].Response != "hello again" {
+		t.Errorf("did not list the expected commands: %+v", commands)
+	}
+
+	// the database should agree with the bot after the changes
+	s.Bot.Commands = map[string]*bot.CommandValue{}
+	if err := s.Bot.LoadCommands(); err != nil || s.Bot.Commands["!hello"] == nil || s.Bot.Commands["!hello"].Response != "hello again" {
+		t.Errorf("the database does not have the edited command: %v", err)
+	}
+
+	if code := do(t, s, http.MethodPut, "/api/commands/hello/script", scriptRequest{Script: "return 'hi'"}, &command); code != http.StatusOK {
+		t.Errorf("did not get the expected status when setting a script, got - %d", code)
+	}
+	if command.Script != "return 'hi'" {
+		t.Errorf("did not get the command's script back: %+v", command)
+	}
+	if code := do(t, s, http.MethodPut, "/api/commands/hello/script", scriptRequest{Script: "return ("}, nil); code != http.StatusBadRequest {
+		t.Errorf("a script that doesn't compile was not rejected, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/commands/hello", nil, nil); code != http.StatusNoContent {
+		t.Errorf("did not get the expected status when deleting, got - %d", code)
+	}
+	var failure errorResponse
+	if code := do(t, s, http.MethodGet, "/api/commands/hello", nil, &failure); code != http.StatusNotFound || failure.Error == "" {
+		t.Errorf("a deleted command was still found, got - %d", code)
+	}
+}
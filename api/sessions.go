@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+const defaultSessionLimit = 20
+
+type sessionRequest struct {
+	Live *bool `json:"live" binding:"required"`
+}
+
+// listSessions returns past and live sessions newest first, with an optional limit query parameter
+func (s *Server) listSessions(c *gin.Context) {
+	limit := defaultSessionLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			fail(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	sessions, err := s.Bot.Sessions(limit)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []bot.Session{}
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// getSession returns a session, or the live one for an ID of "current". With a format query parameter of markdown
+// its summary is returned as a Markdown report instead.
+func (s *Server) getSession(c *gin.Context) {
+	var session bot.Session
+	if c.Param("id") == "current" {
+		if s.Bot.Session == nil {
+			fail(c, http.StatusNotFound, errors.New("the stream isn't live"))
+			return
+		}
+		session = *s.Bot.Session
+	} else {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			fail(c, http.StatusBadRequest, err)
+			return
+		}
+		session, err = s.Bot.GetSession(id)
+		if errors.As(err, &bot.NonFatalError{}) {
+			fail(c, http.StatusNotFound, err)
+			return
+		} else if err != nil {
+			fail(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, session)
+	case "markdown", "md":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(session.Markdown()))
+	default:
+		fail(c, http.StatusBadRequest, errors.New("format has to be json or markdown"))
+	}
+}
+
+// setSession starts or ends the live session, for tools that know when the stream goes live
+func (s *Server) setSession(c *gin.Context) {
+	var request sessionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	var session *bot.Session
+	var err error
+	if *request.Live {
+		session, err = s.Bot.StartSession(actorName(c))
+	} else {
+		session, err = s.Bot.EndSession()
+	}
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
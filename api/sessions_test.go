@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestSessionRoutes(t *testing.T) {
+	s := newTestServer(t)
+	live, offline := true, false
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        interface{}
+		wantCode    int
+	}{
+		{description: "should not get the live session while offline", method: http.MethodGet, path: "/api/sessions/current", wantCode: http.StatusNotFound},
+		{description: "should start a session", method: http.MethodPut, path: "/api/sessions", body: sessionRequest{Live: &live}, wantCode: http.StatusOK},
+		{description: "should not start a session twice", method: http.MethodPut, path: "/api/sessions", body: sessionRequest{Live: &live}, wantCode: http.StatusConflict},
+		{description: "should get the live session", method: http.MethodGet, path: "/api/sessions/current", wantCode: http.StatusOK},
+		{description: "should end the session", method: http.MethodPut, path: "/api/sessions", body: sessionRequest{Live: &offline}, wantCode: http.StatusOK},
+		{description: "should need to say whether the stream is live", method: http.MethodPut, path: "/api/sessions", body: map[string]string{}, wantCode: http.StatusBadRequest},
+		{description: "should not get a session that doesn't exist", method: http.MethodGet, path: "/api/sessions/99", wantCode: http.StatusNotFound},
+		{description: "should not export to an unknown format", method: http.MethodGet, path: "/api/sessions/1?format=pdf", wantCode: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if code := do(t, s, test.method, test.path, test.body, nil); code != test.wantCode {
+			t.Errorf("%s\ndid not get the expected status\ngot - %d\nwant - %d", test.description, code, test.wantCode)
+		}
+	}
+
+	var sessions []bot.Session
+	if code := do(t, s, http.MethodGet, "/api/sessions", nil, &sessions); code != http.StatusOK || len(sessions) != 1 ||
+		sessions[0].Summary == nil || !strings.HasPrefix(sessions[0].StartedBy, "api") {
+		t.Errorf("did not list the expected sessions, got - %d %+v", code, sessions)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/sessions/1?format=markdown", nil)
+	request.Header.Set("Authorization", "Bearer "+s.token)
+	recorder := httptest.NewRecorder()
+	s.Router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Body.String(), "# Stream session 1") {
+		t.Errorf("did not get the expected report, got - %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// statsSince reads the since query parameter, which defaults to the last 30 days. It is given as 2006-01-02 or as a
+// duration into the past such as 7d, and "all" covers everything.
+func statsSince(c *gin.Context) (time.Time, bool) {
+	since := c.DefaultQuery("since", "30d")
+	if since == "all" {
+		return time.Time{}, true
+	}
+	parsed, err := bot.ParseSince(since)
+	if err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return parsed, false
+	}
+	return parsed, true
+}
+
+// getStats returns the channel's chat stats and leaderboards
+func (s *Server) getStats(c *gin.Context) {
+	since, ok := statsSince(c)
+	if !ok {
+		return
+	}
+	stats, err := s.Bot.Stats(since)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// getUserStats returns the messages a user has sent on each day
+func (s *Server) getUserStats(c *gin.Context) {
+	since, ok := statsSince(c)
+	if !ok {
+		return
+	}
+	days, err := s.Bot.UserStats(c.Param("username"), since)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, days)
+}
+
+// exportStats returns one kind of stats as a CSV file
+func (s *Server) exportStats(c *gin.Context) {
+	since, ok := statsSince(c)
+	if !ok {
+		return
+	}
+	kind := c.Param("kind")
+	var export bytes.Buffer
+	err := s.Bot.WriteStatsCSV(&export, kind, since)
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", kind+".csv"))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", export.Bytes())
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestStatsRoutes(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	s.Bot.RecordStats(bot.Item{Sender: bot.User{Name: "viewer"}, Emotes: []string{"Kappa"}}, now)
+	s.Bot.RecordStats(bot.Item{Sender: bot.User{Name: "viewer"}}, now)
+	s.Bot.RecordStats(bot.Item{Sender: bot.User{Name: "lurker"}}, now.AddDate(0, 0, -60))
+
+	var stats bot.ChatStats
+	if code := do(t, s, http.MethodGet, "/api/stats", nil, &stats); code != http.StatusOK || stats.Messages != 2 ||
+		stats.Chatters != 1 || len(stats.TopEmotes) != 1 {
+		t.Errorf("did not get the expected stats, got - %d %+v", code, stats)
+	}
+	if code := do(t, s, http.MethodGet, "/api/stats?since=all", nil, &stats); code != http.StatusOK || stats.Messages != 3 {
+		t.Errorf("did not get every stat, got - %d %+v", code, stats)
+	}
+	if code := do(t, s, http.MethodGet, "/api/stats?since=whenever", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("an invalid date was not a bad request, got - %d", code)
+	}
+
+	var days []bot.DayStats
+	if code := do(t, s, http.MethodGet, "/api/stats/chatters/viewer?since=7d", nil, &days); code != http.StatusOK ||
+		len(days) != 1 || days[0].Messages != 2 {
+		t.Errorf("did not get the expected user stats, got - %d %+v", code, days)
+	}
+
+	tests := []struct {
+		description string
+		path        string
+		wantCode    int
+		wantBody    string
+	}{
+		{description: "should export the chatters as CSV", path: "/api/stats/export/chatters", wantCode: http.StatusOK, wantBody: "user,messages\nviewer,2\n"},
+		{description: "should export the emotes as CSV", path: "/api/stats/export/emotes?since=all", wantCode: http.StatusOK, wantBody: "emote,uses\nKappa,1\n"},
+		{description: "should not export an unknown kind of stats", path: "/api/stats/export/followers", wantCode: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.path, nil)
+		request.Header.Set("Authorization", "Bearer "+s.token)
+		recorder := httptest.NewRecorder()
+		s.Router.ServeHTTP(recorder, request)
+		if recorder.Code != test.wantCode || (test.wantBody != "" && recorder.Body.String() != test.wantBody) {
+			t.Errorf("%s\ndid not get the expected response\ngot - %d %q\nwant - %d %q", test.description, recorder.Code,
+				recorder.Body.String(), test.wantCode, test.wantBody)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// statusResponse describes the bot and its connection to its channel
+type statusResponse struct {
+	Bot       string    `json:"bot"`
+	Channel   string    `json:"channel"`
+	Connected bool      `json:"connected"`
+	Since     time.Time `json:"since,omitempty"` // when the connection last changed
+	Error     string    `json:"error,omitempty"` // why the bot disconnected
+}
+
+func (s *Server) getStatus(c *gin.Context) {
+	status := statusResponse{Bot: s.Bot.Name, Channel: s.Bot.ChannelName}
+	if s.Bot.Events != nil {
+		if event, ok := s.Bot.Events.Latest(bot.EventConnection); ok {
+			connection, _ := event.Data.(bot.ConnectionEvent)
+			status.Connected = connection.State == bot.StateConnected
+			status.Since = event.Timestamp
+			status.Error = connection.Error
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
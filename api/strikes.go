@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// strikesResponse is a user's active strikes along with their total
+type strikesResponse struct {
+	User    string       `json:"user"`
+	Total   int          `json:"total"`
+	Strikes []bot.Strike `json:"strikes"`
+}
+
+func (s *Server) listStrikes(c *gin.Context) {
+	users := []strikesResponse{}
+	for user := range s.Bot.Strikes {
+		if total := s.Bot.StrikeCount(user); total > 0 {
+			users = append(users, strikesResponse{User: user, Total: total, Strikes: s.Bot.ActiveStrikes(user)})
+		}
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+func (s *Server) getStrikes(c *gin.Context) {
+	user := strings.ToLower(c.Param("username"))
+	strikes := s.Bot.ActiveStrikes(user)
+	if strikes == nil {
+		strikes = []bot.Strike{}
+	}
+	c.JSON(http.StatusOK, strikesResponse{User: user, Total: s.Bot.StrikeCount(user), Strikes: strikes})
+}
+
+// pardonUser removes every strike from a user, the pardon is recorded in the moderation log
+func (s *Server) pardonUser(c *gin.Context) {
+	user := strings.ToLower(c.Param("username"))
+	total, err := s.Bot.PardonUser(user)
+	if err == nil {
+		err = s.Bot.LogModAction(bot.ModLogEntry{Action: bot.ModActionPardon, Actor: actorName(c), Target: user,
+			Reason: fmt.Sprintf("%d strike(s) removed", total)})
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestStrikeRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.Bot.AddStrike("viewer", bot.InfractionLink)
+	s.Bot.AddStrike("viewer", bot.InfractionBadWord)
+
+	var users []strikesResponse
+	do(t, s, http.MethodGet, "/api/strikes", nil, &users)
+	if len(users) != 1 || users[0].User != "viewer" || users[0].Total != 2 || len(users[0].Strikes) != 2 {
+		t.Errorf("did not list the expected strikes: %+v", users)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/strikes/Viewer", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("did not get the expected status when pardoning, got - %d", code)
+	}
+
+	var user strikesResponse
+	do(t, s, http.MethodGet, "/api/strikes/viewer", nil, &user)
+	if user.Total != 0 || len(user.Strikes) != 0 {
+		t.Errorf("the user still has strikes after a pardon: %+v", user)
+	}
+
+	entries, _ := s.Bot.ModLog(bot.ModLogFilter{User: "viewer"})
+	if len(entries) != 1 || entries[0].Action != bot.ModActionPardon {
+		t.Errorf("the pardon was not logged: %+v", entries)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type timerRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Message string `json:"message" binding:"required"`
+	Minutes int    `json:"minutes" binding:"required,min=1"`
+}
+
+// timerUpdate changes a timer, it stays enabled or disabled if enabled is left out
+type timerUpdate struct {
+	Message string `json:"message" binding:"required"`
+	Minutes int    `json:"minutes" binding:"required,min=1"`
+	Enabled *bool  `json:"enabled"`
+}
+
+func (s *Server) listTimers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Bot.Timers)
+}
+
+func (s *Server) getTimer(c *gin.Context) {
+	timer, found := s.Bot.Timers[c.Param("name")]
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the timer '%s' does not exist", c.Param("name")))
+		return
+	}
+	c.JSON(http.StatusOK, timer)
+}
+
+func (s *Server) addTimer(c *gin.Context) {
+	var request timerRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if _, found := s.Bot.Timers[request.Name]; found {
+		fail(c, http.StatusConflict, fmt.Errorf("the timer '%s' already exists", request.Name))
+		return
+	}
+
+	// timers are added from chat as '<minutes> <message>'
+	err := s.Bot.AddTimer(bot.Item{Key: request.Name, Contents: fmt.Sprintf("%d %s", request.Minutes, request.Message)})
+	if err != nil {
+		fail(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, s.Bot.Timers[request.Name])
+}
+
+func (s *Server) editTimer(c *gin.Context) {
+	var request timerUpdate
+	if !bindJSON(c, &request) {
+		return
+	}
+	name := c.Param("name")
+	timer, found := s.Bot.Timers[name]
+	if !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the timer '%s' does not exist", name))
+		return
+	}
+
+	enabled := timer.Enabled
+	if request.Enabled != nil {
+		enabled = *request.Enabled
+	}
+	err := s.Bot.EditTimer(name, bot.TimedValue{Message: request.Message, Minutes: request.Minutes, Enabled: enabled})
+	if errors.As(err, &bot.NonFatalError{}) {
+		fail(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.Bot.Timers[name])
+}
+
+func (s *Server) deleteTimer(c *gin.Context) {
+	if _, found := s.Bot.Timers[c.Param("name")]; !found {
+		fail(c, http.StatusNotFound, fmt.Errorf("the timer '%s' does not exist", c.Param("name")))
+		return
+	}
+
+	err := s.Bot.DeleteTimer(bot.Item{Key: c.Param("name")})
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestTimerRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	var timer bot.TimedValue
+	request := timerRequest{Name: "discord", Message: "join the discord", Minutes: 15}
+	if code := do(t, s, http.MethodPost, "/api/timers", request, &timer); code != http.StatusCreated {
+		t.Fatalf("did not get the expected status when adding, got - %d", code)
+	}
+	if timer != (bot.TimedValue{Message: "join the discord", Minutes: 15, Enabled: true}) {
+		t.Errorf("did not get the expected timer back: %+v", timer)
+	}
+
+	if code := do(t, s, http.MethodPost, "/api/timers", request, nil); code != http.StatusConflict {
+		t.Errorf("adding a duplicate timer did not conflict, got - %d", code)
+	}
+	if code := do(t, s, http.MethodPost, "/api/timers", timerRequest{Name: "bad", Message: "no minutes"}, nil); code != http.StatusBadRequest {
+		t.Errorf("a timer without minutes was not a bad request, got - %d", code)
+	}
+
+	disabled := false
+	update := timerUpdate{Message: "the discord is linked below", Minutes: 30, Enabled: &disabled}
+	if code := do(t, s, http.MethodPut, "/api/timers/discord", update, &timer); code != http.StatusOK {
+		t.Errorf("did not get the expected status when editing, got - %d", code)
+	}
+	if want := (bot.TimedValue{Message: "the discord is linked below", Minutes: 30}); timer != want || *s.Bot.Timers["discord"] != want {
+		t.Errorf("did not get the expected timer after editing\ngot - %+v\nwant - %+v", timer, want)
+	}
+	if code := do(t, s, http.MethodPut, "/api/timers/discord", timerUpdate{Message: "too often"}, nil); code != http.StatusBadRequest {
+		t.Errorf("editing a timer without minutes was not a bad request, got - %d", code)
+	}
+	if code := do(t, s, http.MethodPut, "/api/timers/missing", update, nil); code != http.StatusNotFound {
+		t.Errorf("editing a missing timer was not a not found, got - %d", code)
+	}
+
+	if code := do(t, s, http.MethodDelete, "/api/timers/discord", nil, nil); code != http.StatusNoContent {
+		t.Errorf("did not get the expected status when deleting, got - %d", code)
+	}
+	if code := do(t, s, http.MethodGet, "/api/timers/discord", nil, nil); code != http.StatusNotFound {
+		t.Errorf("a deleted timer was still found, got - %d", code)
+	}
+}
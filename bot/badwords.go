@@ -1,23 +1,88 @@
-// this file handles words that can cause purges and bans.
-// timeouts may be supported later, but for now I'm thinking that's best left for manual moderation.
+// this file handles words that can cause message deletions, purges, timeouts and bans.
 
 package bot
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 )
 
+// Severity determines what happens to a user who says a bad word
+type Severity int
+
+// the values for purge and ban are kept at 0 and 1 so that bad words stored before severities were graded still load
+// with their original meaning.
+const (
+	SeverityPurge   Severity = 0 // clear all of the user's messages with a 1 second timeout
+	SeverityBan     Severity = 1 // permanently ban the user
+	SeverityDelete  Severity = 2 // delete only the offending message
+	SeverityTimeout Severity = 3 // timeout the user for BadWordTimeout seconds
+	SeverityWarn    Severity = 4 // only send the public reason, used by strike ladders
+)
+
+var severityNames = map[Severity]string{
+	SeverityDelete:  "delete",
+	SeverityPurge:   "purge",
+	SeverityTimeout: "timeout",
+	SeverityBan:     "ban",
+	SeverityWarn:    "warn",
+}
+
+// severityRanks orders the severities from least to most harsh
+var severityRanks = map[Severity]int{
+	SeverityWarn:    0,
+	SeverityDelete:  1,
+	SeverityPurge:   2,
+	SeverityTimeout: 3,
+	SeverityBan:     4,
+}
+
 // BadWord contains info useful for bannable / purgeable phrases
 type BadWord struct {
-	Phrase   string
-	Severity int // 0 for purge, 1 for perma ban
+	Phrase   string   `json:"phrase"`
+	Severity Severity `json:"severity"`
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// MarshalText lets a Severity be written by name, e.g. in JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads a Severity by either its name or number
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// ParseSeverity takes in either the name of a severity (e.g. "timeout") or its number and returns the matching Severity
+func ParseSeverity(value string) (Severity, error) {
+	value = strings.ToLower(value)
+	for severity, name := range severityNames {
+		if value == name || value == strconv.Itoa(int(severity)) {
+			return severity, nil
+		}
+	}
+	return 0, NonFatalError{Err: fmt.Errorf("'%s' is not a valid severity, use one of warn, delete, purge, timeout or ban", value)}
 }
 
 // ParseForBadWord reads in a string and sees if a bad word was found and returns that bad word.
 // Callers should check if the bool is true, then use the returned BadWord if true.
 func (bot *Bot) ParseForBadWord(msg string) (bool, BadWord) {
+	msg = strings.ToLower(msg)
 	for i := range bot.BadWords { // search through all bad words
-		if strings.Contains(msg, bot.BadWords[i].Phrase) {
+		if strings.Contains(msg, strings.ToLower(bot.BadWords[i].Phrase)) {
 			return true, bot.BadWords[i]
 		}
 	}
@@ -25,6 +90,97 @@ func (bot *Bot) ParseForBadWord(msg string) (bool, BadWord) {
 	return false, BadWord{}
 }
 
+// AddBadWord adds a new bad word to the bot's slice and the database
+func (bot *Bot) AddBadWord(phrase string, severity Severity) error {
+	phrase = strings.TrimSpace(phrase)
+	if phrase == "" {
+		return NonFatalError{Err: fmt.Errorf("the phrase for a bad word cannot be empty")}
+	}
+
+	if _, found := bot.findBadWord(phrase); found {
+		return NonFatalError{Err: fmt.Errorf("'%s' is already a bad word", phrase)}
+	}
+	if err := checkSeverity(severity); err != nil {
+		return err
+	}
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Insert("badwords", []string{"phrase", "severity"}, []string{phrase, strconv.Itoa(int(severity))})
+		if err != nil {
+			return err
+		}
+	}
+
+	bot.BadWords = append(bot.BadWords, BadWord{Phrase: phrase, Severity: severity})
+	return nil
+}
+
+// SetBadWordSeverity changes what happens to a user who says a bad word. Returns false if it did not exist.
+func (bot *Bot) SetBadWordSeverity(phrase string, severity Severity) (bool, error) {
+	index, found := bot.findBadWord(strings.TrimSpace(phrase))
+	if !found {
+		return false, nil
+	}
+	if err := checkSeverity(severity); err != nil {
+		return true, err
+	}
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Update("badwords", "phrase", bot.BadWords[index].Phrase, []string{"severity"},
+			[]string{strconv.Itoa(int(severity))})
+		if err != nil {
+			return true, err
+		}
+	}
+
+	bot.BadWords[index].Severity = severity
+	return true, nil
+}
+
+// checkSeverity returns an error if severity is not one of the Severity constants, which a number in JSON can be
+func checkSeverity(severity Severity) error {
+	if _, ok := severityNames[severity]; !ok {
+		return NonFatalError{Err: fmt.Errorf("%d is not a valid severity, use one of warn, delete, purge, timeout or ban", int(severity))}
+	}
+	return nil
+}
+
+// RemoveBadWord removes a bad word from the bot's slice and the database. Returns false if it did not exist.
+func (bot *Bot) RemoveBadWord(phrase string) (bool, error) {
+	index, found := bot.findBadWord(strings.TrimSpace(phrase))
+	if !found {
+		return false, nil
+	}
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Delete("badwords", "phrase", bot.BadWords[index].Phrase)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	bot.BadWords = append(bot.BadWords[:index], bot.BadWords[index+1:]...)
+	return true, nil
+}
+
+// BadWordReason returns the public message that should be sent when a bad word of the given severity is found
+func (bot *Bot) BadWordReason(severity Severity) string {
+	if reason, ok := bot.BadWordReasons[severity]; ok {
+		return reason
+	}
+	return ""
+}
+
+// findBadWord returns the index of phrase in the bot's bad words
+func (bot *Bot) findBadWord(phrase string) (int, bool) {
+	for i := range bot.BadWords {
+		if strings.EqualFold(bot.BadWords[i].Phrase, phrase) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
 // LoadBadWords loads all badwords from the databases
 // TODO: generalize this for all bot data
 func (bot *Bot) LoadBadWords() error {
@@ -41,8 +197,16 @@ func (bot *Bot) LoadBadWords() error {
 		if err != nil {
 			return err
 		}
-		badWord := BadWord{Phrase: phrase, Severity: severity}
+		badWord := BadWord{Phrase: phrase, Severity: Severity(severity)}
 		bot.BadWords = append(bot.BadWords, badWord)
 	}
 	return nil
 }
+
+// loadBadWordReasons reads the public reason for each severity from the config
+func (bot *Bot) loadBadWordReasons() {
+	bot.BadWordReasons = make(map[Severity]string)
+	for severity, name := range severityNames {
+		bot.BadWordReasons[severity] = bot.Config.GetString(fmt.Sprintf("BadWordReasons.%s", name))
+	}
+}
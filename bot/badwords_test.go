@@ -48,3 +48,122 @@ func TestParseForBadWord(t *testing.T) {
 		}
 	}
 }
+
+func TestAddBadWord(t *testing.T) {
+	tests := []struct {
+		description  string
+		badWords     []BadWord
+		phrase       string
+		severity     Severity
+		wantBadWords []BadWord
+		wantErr      bool
+	}{
+		{
+			description:  "should add a new bad word",
+			badWords:     []BadWord{},
+			phrase:       "cookies",
+			severity:     SeverityTimeout,
+			wantBadWords: []BadWord{{Phrase: "cookies", Severity: SeverityTimeout}},
+			wantErr:      false,
+		},
+		{
+			description:  "should not add a duplicate bad word",
+			badWords:     []BadWord{{Phrase: "cookies", Severity: SeverityBan}},
+			phrase:       "Cookies",
+			severity:     SeverityDelete,
+			wantBadWords: []BadWord{{Phrase: "cookies", Severity: SeverityBan}},
+			wantErr:      true,
+		},
+		{
+			description:  "should not add an unknown severity",
+			badWords:     []BadWord{},
+			phrase:       "cookies",
+			severity:     Severity(99),
+			wantBadWords: []BadWord{},
+			wantErr:      true,
+		},
+		{
+			description:  "should not add an empty phrase",
+			badWords:     []BadWord{},
+			phrase:       " ",
+			severity:     SeverityDelete,
+			wantBadWords: []BadWord{},
+			wantErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			bot := &Bot{BadWords: test.badWords}
+			err := bot.AddBadWord(test.phrase, test.severity)
+			if (err != nil) != test.wantErr {
+				t.Errorf("did not get the expected error\ngot - %v\nwant error - %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(bot.BadWords, test.wantBadWords) {
+				t.Errorf("did not get the expected bad words\ngot - %v\nwant - %v", bot.BadWords, test.wantBadWords)
+			}
+		})
+	}
+}
+
+func TestRemoveBadWord(t *testing.T) {
+	bot := &Bot{BadWords: []BadWord{{Phrase: "cookies"}, {Phrase: "cupcakes", Severity: SeverityBan}}}
+
+	found, err := bot.RemoveBadWord("cookies")
+	if err != nil || !found {
+		t.Errorf("expected to remove the bad word\ngot found - %v, err - %v", found, err)
+	}
+
+	found, _ = bot.RemoveBadWord("cookies")
+	if found {
+		t.Errorf("a bad word was removed twice")
+	}
+
+	want := []BadWord{{Phrase: "cupcakes", Severity: SeverityBan}}
+	if !reflect.DeepEqual(bot.BadWords, want) {
+		t.Errorf("did not get the expected bad words\ngot - %v\nwant - %v", bot.BadWords, want)
+	}
+}
+
+func TestSetBadWordSeverity(t *testing.T) {
+	bot := &Bot{BadWords: []BadWord{{Phrase: "cookies"}}}
+
+	if found, err := bot.SetBadWordSeverity("cookies", SeverityTimeout); !found || err != nil {
+		t.Errorf("expected to change the severity\ngot found - %v, err - %v", found, err)
+	}
+	if found, err := bot.SetBadWordSeverity("cookies", Severity(99)); !found || err == nil {
+		t.Errorf("expected an error for an unknown severity\ngot found - %v, err - %v", found, err)
+	}
+	if found, _ := bot.SetBadWordSeverity("cupcakes", SeverityBan); found {
+		t.Errorf("changed a bad word that does not exist")
+	}
+
+	want := []BadWord{{Phrase: "cookies", Severity: SeverityTimeout}}
+	if !reflect.DeepEqual(bot.BadWords, want) {
+		t.Errorf("did not get the expected bad words\ngot - %v\nwant - %v", bot.BadWords, want)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantSeverity Severity
+		wantErr      bool
+	}{
+		{input: "timeout", wantSeverity: SeverityTimeout},
+		{input: "BAN", wantSeverity: SeverityBan},
+		{input: "0", wantSeverity: SeverityPurge},
+		{input: "explode", wantErr: true},
+	}
+
+	for _, test := range tests {
+		severity, err := ParseSeverity(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("did not get the expected error for %s: %v", test.input, err)
+		}
+		if err == nil && severity != test.wantSeverity {
+			t.Errorf("did not get the expected severity\ngot - %v\nwant - %v", severity, test.wantSeverity)
+		}
+	}
+}
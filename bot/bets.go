@@ -0,0 +1,358 @@
+// bets.go handles predictions, where viewers wager points on the outcome of something happening on stream. The
+// winners split the whole pool in proportion to what they wagered. Every wager and payout is stored, and points only
+// move inside a database transaction so a payout or refund happens for everyone or for no one.
+
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// states of a prediction
+const (
+	BetOpen      = "open"      // viewers can place wagers
+	BetClosed    = "closed"    // wagers are locked in, waiting on the result
+	BetResolved  = "resolved"  // the winners have been paid
+	BetCancelled = "cancelled" // every wager was refunded
+)
+
+var (
+	errNoBet          = errors.New("there is no prediction running")
+	errBetRunning     = errors.New("a prediction is already running, resolve it with !bet resolve <outcome> or !bet cancel")
+	errBetClosed      = errors.New("betting is closed")
+	errBetOutcomes    = errors.New("a prediction needs between 2 and 10 outcomes, each a single word")
+	errUnknownOutcome = errors.New("that isn't one of the outcomes")
+)
+
+// betCommands are the !bet subcommands, which can't be used as outcomes
+var betCommands = map[string]bool{"open": true, "close": true, "resolve": true, "cancel": true}
+
+// Bet is a single prediction
+type Bet struct {
+	ID        int64            `json:"id"`
+	Question  string           `json:"question"`
+	Outcomes  []string         `json:"outcomes"`
+	Pools     []int64          `json:"pools"`  // points wagered on each outcome
+	Wagers    map[string]Wager `json:"wagers"` // each user's wager, a user can only back one outcome
+	StartedBy string           `json:"started_by"`
+	Started   time.Time        `json:"started"`
+	State     string           `json:"state"`
+}
+
+// Wager is what a user has put on an outcome
+type Wager struct {
+	User    string `json:"user"`
+	Outcome int    `json:"outcome"` // index into the prediction's outcomes
+	Amount  int64  `json:"amount"`
+}
+
+// IsBetCommand returns whether word is a !bet subcommand rather than an outcome
+func IsBetCommand(word string) bool {
+	return betCommands[strings.ToLower(word)]
+}
+
+// OpenBet starts a prediction on question with the given outcomes
+func (bot *Bot) OpenBet(question string, outcomes []string, startedBy string) (*Bet, error) {
+	if bot.Bet != nil {
+		return nil, NonFatalError{Err: errBetRunning}
+	}
+	if len(outcomes) < 2 || len(outcomes) > 10 {
+		return nil, NonFatalError{Err: errBetOutcomes}
+	}
+	seen := make(map[string]bool)
+	names := make([]string, len(outcomes))
+	for i, outcome := range outcomes {
+		outcome = strings.ToLower(strings.TrimSpace(outcome))
+		if outcome == "" || strings.ContainsAny(outcome, " \t") || seen[outcome] || IsBetCommand(outcome) {
+			return nil, NonFatalError{Err: errBetOutcomes}
+		}
+		if _, err := strconv.Atoi(outcome); err == nil {
+			return nil, NonFatalError{Err: errBetOutcomes} // numbers are how outcomes are picked by position
+		}
+		seen[outcome] = true
+		names[i] = outcome
+	}
+
+	bet := &Bet{Question: question, Outcomes: names, Pools: make([]int64, len(names)),
+		Wagers: make(map[string]Wager), StartedBy: startedBy, Started: time.Now(), State: BetOpen}
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec("insert into bets (question, outcomes, started_by, started, state) values (?, ?, ?, ?, ?)",
+			question, strings.Join(names, "|"), startedBy, bet.Started.Format(time.RFC3339), bet.State)
+		if err != nil {
+			return err
+		}
+		bet.ID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	bot.Bet = bet
+	return bet, nil
+}
+
+// LoadBet picks up the prediction that was open or closed when the bot last stopped, along with its wagers, so it can
+// still be resolved or refunded
+func (bot *Bot) LoadBet() error {
+	bot.Bet = nil
+	rows, err := bot.Storage.DB.Query(`select id, question, outcomes, started_by, started, state from bets
+		where state in (?, ?) order by id desc limit 1`, BetOpen, BetClosed)
+	if err != nil {
+		return err
+	}
+	var bet *Bet
+	for rows.Next() {
+		var outcomes, started string
+		bet = &Bet{Wagers: make(map[string]Wager)}
+		if err = rows.Scan(&bet.ID, &bet.Question, &outcomes, &bet.StartedBy, &started, &bet.State); err != nil {
+			rows.Close()
+			return err
+		}
+		bet.Outcomes = strings.Split(outcomes, "|")
+		bet.Pools = make([]int64, len(bet.Outcomes))
+		bet.Started, _ = time.Parse(time.RFC3339, started)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || bet == nil {
+		return err
+	}
+
+	rows, err = bot.Storage.DB.Query("select user, outcome, amount from bet_wagers where bet_id = ? order by rowid", bet.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user, outcome string
+		var amount int64
+		if err = rows.Scan(&user, &outcome, &amount); err != nil {
+			return err
+		}
+		index := bet.outcome(outcome)
+		if index < 0 {
+			return fmt.Errorf("prediction %d has a wager on unknown outcome '%s'", bet.ID, outcome)
+		}
+		wager := bet.Wagers[user]
+		bet.Wagers[user] = Wager{User: user, Outcome: index, Amount: wager.Amount + amount}
+		bet.Pools[index] += amount
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	bot.Bet = bet
+	return nil
+}
+
+// PlaceBet wagers amount of a user's points on outcome, given by name or by its position starting at 1. Betting
+// again adds to the user's wager, but only on the outcome they already backed. The user's whole wager is returned.
+func (bot *Bot) PlaceBet(user string, outcome string, amount int64) (Wager, error) {
+	bet := bot.Bet
+	if bet == nil {
+		return Wager{}, NonFatalError{Err: errNoBet}
+	}
+	if bet.State != BetOpen {
+		return Wager{}, NonFatalError{Err: errBetClosed}
+	}
+	if amount <= 0 {
+		return Wager{}, NonFatalError{Err: fmt.Errorf("you have to bet at least 1")}
+	}
+	index := bet.outcome(outcome)
+	if index < 0 {
+		return Wager{}, NonFatalError{Err: errUnknownOutcome}
+	}
+	wager, ok := bet.Wagers[user]
+	if ok && wager.Outcome != index {
+		return Wager{}, NonFatalError{Err: fmt.Errorf("you already bet on %s", bet.Outcomes[wager.Outcome])}
+	}
+
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		if _, err := addPoints(tx, user, -amount, 0); err != nil {
+			return err
+		}
+		_, err := tx.Exec("insert into bet_wagers (bet_id, user, outcome, amount, timestamp) values (?, ?, ?, ?, ?)",
+			bet.ID, user, bet.Outcomes[index], amount, time.Now().Format(time.RFC3339))
+		return err
+	})
+	if err != nil {
+		return Wager{}, err
+	}
+
+	wager = Wager{User: user, Outcome: index, Amount: wager.Amount + amount}
+	bet.Wagers[user] = wager
+	bet.Pools[index] += amount
+	return wager, nil
+}
+
+// outcome returns the index of an outcome given by name or position, or -1 if there is no such outcome
+func (bet *Bet) outcome(outcome string) int {
+	outcome = strings.ToLower(outcome)
+	if n, err := strconv.Atoi(outcome); err == nil {
+		if n < 1 || n > len(bet.Outcomes) {
+			return -1
+		}
+		return n - 1
+	}
+	for i, name := range bet.Outcomes {
+		if name == outcome {
+			return i
+		}
+	}
+	return -1
+}
+
+// Total returns every point wagered on the prediction
+func (bet *Bet) Total() int64 {
+	var total int64
+	for _, pool := range bet.Pools {
+		total += pool
+	}
+	return total
+}
+
+// Summary describes the pool on each outcome, e.g. "yes: 500 (2 bets), no: 100 (1 bet)"
+func (bet *Bet) Summary() string {
+	counts := make([]int, len(bet.Outcomes))
+	for _, wager := range bet.Wagers {
+		counts[wager.Outcome]++
+	}
+	var summary []string
+	for i, outcome := range bet.Outcomes {
+		noun := "bets"
+		if counts[i] == 1 {
+			noun = "bet"
+		}
+		summary = append(summary, fmt.Sprintf("%s: %d (%d %s)", outcome, bet.Pools[i], counts[i], noun))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// CloseBet stops any more wagers being placed on the running prediction
+func (bot *Bot) CloseBet() (*Bet, error) {
+	bet := bot.Bet
+	if bet == nil {
+		return nil, NonFatalError{Err: errNoBet}
+	}
+	if bet.State != BetOpen {
+		return nil, NonFatalError{Err: errBetClosed}
+	}
+	if err := bot.Storage.DB.Update("bets", "id", fmt.Sprint(bet.ID), []string{"state"}, []string{BetClosed}); err != nil {
+		return nil, err
+	}
+	bet.State = BetClosed
+	return bet, nil
+}
+
+// ResolveBet ends the running prediction with outcome as the result and pays out the whole pool to those who backed
+// it, in proportion to their wagers. Points left over from rounding go to the biggest wagers first. If nobody backed
+// the outcome, everyone is refunded instead and refunded is true. The payouts are returned by user.
+func (bot *Bot) ResolveBet(outcome string) (payouts map[string]int64, refunded bool, err error) {
+	bet := bot.Bet
+	if bet == nil {
+		return nil, false, NonFatalError{Err: errNoBet}
+	}
+	index := bet.outcome(outcome)
+	if index < 0 {
+		return nil, false, NonFatalError{Err: errUnknownOutcome}
+	}
+	if bet.Pools[index] == 0 {
+		payouts, err = bot.CancelBet()
+		return payouts, true, err
+	}
+
+	payouts = bet.payouts(index)
+	err = bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		if err := payBets(tx, bet.ID, payouts, false); err != nil {
+			return err
+		}
+		_, err := tx.Exec("update bets set state = ?, result = ?, ended = ? where id = ?", BetResolved, bet.Outcomes[index],
+			time.Now().Format(time.RFC3339), bet.ID)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	bet.State = BetResolved
+	bot.Bet = nil
+	return payouts, false, nil
+}
+
+// payouts splits the whole pool between the users who backed the winning outcome
+func (bet *Bet) payouts(winner int) map[string]int64 {
+	var winners []Wager
+	for _, wager := range bet.Wagers {
+		if wager.Outcome == winner {
+			winners = append(winners, wager)
+		}
+	}
+	sort.Slice(winners, func(i, j int) bool {
+		if winners[i].Amount != winners[j].Amount {
+			return winners[i].Amount > winners[j].Amount
+		}
+		return winners[i].User < winners[j].User
+	})
+
+	// amount * total can overflow an int64 with big enough balances, so the share is worked out with big.Int
+	total, pool := big.NewInt(bet.Total()), big.NewInt(bet.Pools[winner])
+	payouts := make(map[string]int64)
+	remaining := bet.Total()
+	for _, wager := range winners {
+		share := new(big.Int).Mul(big.NewInt(wager.Amount), total)
+		payouts[wager.User] = share.Div(share, pool).Int64()
+		remaining -= payouts[wager.User]
+	}
+	for i := 0; remaining > 0; i = (i + 1) % len(winners) {
+		payouts[winners[i].User]++
+		remaining--
+	}
+	return payouts
+}
+
+// CancelBet ends the running prediction and gives everyone back what they wagered. The refunds are returned by user.
+func (bot *Bot) CancelBet() (map[string]int64, error) {
+	bet := bot.Bet
+	if bet == nil {
+		return nil, NonFatalError{Err: errNoBet}
+	}
+
+	refunds := make(map[string]int64)
+	for user, wager := range bet.Wagers {
+		refunds[user] = wager.Amount
+	}
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		if err := payBets(tx, bet.ID, refunds, true); err != nil {
+			return err
+		}
+		_, err := tx.Exec("update bets set state = ?, ended = ? where id = ?", BetCancelled,
+			time.Now().Format(time.RFC3339), bet.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	bet.State = BetCancelled
+	bot.Bet = nil
+	return refunds, nil
+}
+
+// payBets adds each user's payout to their balance and records it
+func payBets(tx *sql.Tx, id int64, payouts map[string]int64, refund bool) error {
+	now := time.Now().Format(time.RFC3339)
+	for user, amount := range payouts {
+		if _, err := addPoints(tx, user, amount, 0); err != nil {
+			return err
+		}
+		_, err := tx.Exec("insert into bet_payouts (bet_id, user, amount, refund, timestamp) values (?, ?, ?, ?, ?)",
+			id, user, amount, refund, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
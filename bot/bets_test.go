@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newBetBot(t *testing.T) *Bot {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database}
+	for _, user := range []string{"alice", "bob", "carol"} {
+		bot.SetPoints(user, 1000)
+	}
+	return bot
+}
+
+func balances(bot *Bot, users ...string) []int64 {
+	var points []int64
+	for _, user := range users {
+		balance, _ := bot.GetBalance(user)
+		points = append(points, balance.Points)
+	}
+	return points
+}
+
+func TestBet(t *testing.T) {
+	bot := newBetBot(t)
+	tests := []struct {
+		description string
+		outcomes    []string
+		wantErr     bool
+	}{
+		{description: "should need two outcomes", outcomes: []string{"yes"}, wantErr: true},
+		{description: "should not allow a subcommand as an outcome", outcomes: []string{"yes", "close"}, wantErr: true},
+		{description: "should not allow a number as an outcome", outcomes: []string{"yes", "2"}, wantErr: true},
+		{description: "should not allow the same outcome twice", outcomes: []string{"yes", "YES"}, wantErr: true},
+		{description: "should open a prediction", outcomes: []string{"Yes", "no"}},
+		{description: "should not open a second prediction", outcomes: []string{"yes", "no"}, wantErr: true},
+	}
+	for _, test := range tests {
+		if _, err := bot.OpenBet("will we win?", test.outcomes, "mod"); (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant error - %v", test.description, err, test.wantErr)
+		}
+	}
+
+	wagers := []struct {
+		description string
+		user        string
+		outcome     string
+		amount      int64
+		wantErr     bool
+	}{
+		{description: "should place a wager", user: "alice", outcome: "yes", amount: 300},
+		{description: "should add to a wager", user: "alice", outcome: "1", amount: 100},
+		{description: "should not back a second outcome", user: "alice", outcome: "no", amount: 100, wantErr: true},
+		{description: "should not bet an unknown outcome", user: "bob", outcome: "maybe", amount: 100, wantErr: true},
+		{description: "should not bet more than the balance", user: "bob", outcome: "yes", amount: 5000, wantErr: true},
+		{description: "should place a second wager", user: "bob", outcome: "yes", amount: 100},
+		{description: "should place a losing wager", user: "carol", outcome: "no", amount: 501},
+	}
+	for _, test := range wagers {
+		if _, err := bot.PlaceBet(test.user, test.outcome, test.amount); (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant error - %v", test.description, err, test.wantErr)
+		}
+	}
+	if _, err := bot.PlaceBet("dave", "yes", 10); !errors.Is(err, ErrNotEnoughPoints) {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, ErrNotEnoughPoints)
+	}
+	if want := []int64{500, 501}; !reflect.DeepEqual(bot.Bet.Pools, want) {
+		t.Errorf("did not get the expected pools\ngot - %v\nwant - %v", bot.Bet.Pools, want)
+	}
+
+	if _, err := bot.CloseBet(); err != nil {
+		t.Fatalf("could not close the prediction: %v", err)
+	}
+	if _, err := bot.PlaceBet("bob", "yes", 10); err == nil {
+		t.Errorf("placed a wager after betting closed")
+	}
+
+	// alice and bob split the 1001 point pool 4:1, the point left over from rounding goes to alice
+	payouts, refunded, err := bot.ResolveBet("yes")
+	if err != nil || refunded || bot.Bet != nil {
+		t.Fatalf("could not resolve the prediction: %v", err)
+	}
+	if want := map[string]int64{"alice": 801, "bob": 200}; !reflect.DeepEqual(payouts, want) {
+		t.Errorf("did not get the expected payouts\ngot - %v\nwant - %v", payouts, want)
+	}
+	if got, want := balances(bot, "alice", "bob", "carol"), []int64{1401, 1100, 499}; !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected balances\ngot - %v\nwant - %v", got, want)
+	}
+
+	rows, err := bot.Storage.DB.Query("select count(*) from bet_wagers")
+	if err != nil {
+		t.Fatalf("could not read the logged wagers: %v", err)
+	}
+	defer rows.Close()
+	var logged int
+	for rows.Next() {
+		rows.Scan(&logged)
+	}
+	if logged != 4 {
+		t.Errorf("did not log every wager\ngot - %v\nwant - %v", logged, 4)
+	}
+}
+
+func TestCancelBet(t *testing.T) {
+	bot := newBetBot(t)
+	bot.OpenBet("first blood?", []string{"us", "them"}, "mod")
+	bot.PlaceBet("alice", "us", 250)
+	bot.PlaceBet("bob", "them", 1000)
+
+	refunds, err := bot.CancelBet()
+	if err != nil || bot.Bet != nil {
+		t.Fatalf("could not cancel the prediction: %v", err)
+	}
+	if want := map[string]int64{"alice": 250, "bob": 1000}; !reflect.DeepEqual(refunds, want) {
+		t.Errorf("did not get the expected refunds\ngot - %v\nwant - %v", refunds, want)
+	}
+	if got, want := balances(bot, "alice", "bob"), []int64{1000, 1000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected balances\ngot - %v\nwant - %v", got, want)
+	}
+
+	// resolving an outcome nobody backed refunds everyone
+	bot.OpenBet("first blood?", []string{"us", "them"}, "mod")
+	bot.PlaceBet("alice", "us", 250)
+	if _, refunded, err := bot.ResolveBet("them"); err != nil || !refunded {
+		t.Errorf("did not refund a prediction nobody won: %v", err)
+	}
+	if got, want := balances(bot, "alice"), []int64{1000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected balances\ngot - %v\nwant - %v", got, want)
+	}
+}
+
+func TestLoadBet(t *testing.T) {
+	bot := newBetBot(t)
+	bot.OpenBet("first blood?", []string{"us", "them"}, "mod")
+	bot.PlaceBet("alice", "us", 200)
+	bot.PlaceBet("alice", "us", 100)
+	bot.PlaceBet("bob", "them", 100)
+	bot.CloseBet()
+
+	// a restart only has what was stored
+	want := bot.Bet
+	if err := bot.LoadBet(); err != nil {
+		t.Fatalf("could not load the prediction: %v", err)
+	}
+	if got := bot.Bet; got == nil || got.ID != want.ID || got.State != BetClosed ||
+		!reflect.DeepEqual(got.Outcomes, want.Outcomes) || !reflect.DeepEqual(got.Pools, want.Pools) ||
+		!reflect.DeepEqual(got.Wagers, want.Wagers) {
+		t.Fatalf("did not load the expected prediction\ngot - %+v\nwant - %+v", got, want)
+	}
+
+	if _, _, err := bot.ResolveBet("us"); err != nil {
+		t.Fatalf("could not resolve the loaded prediction: %v", err)
+	}
+	if got, want := balances(bot, "alice", "bob"), []int64{1100, 900}; !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected balances\ngot - %v\nwant - %v", got, want)
+	}
+
+	// a resolved prediction isn't loaded again
+	if err := bot.LoadBet(); err != nil || bot.Bet != nil {
+		t.Errorf("loaded a prediction that had ended\ngot - %+v %v", bot.Bet, err)
+	}
+}
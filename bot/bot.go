@@ -7,19 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/liamphmurphy/pleasantbot/storage"
 
 	"github.com/spf13/viper"
 )
 
-var (
-	// regex obtained from top answer here: https://stackoverflow.com/questions/3809401/what-is-a-good-regular-expression-to-match-a-url
-	urlRegex, _ = regexp.Compile(`[-a-zA-Z-1-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9()]{1,6}\b([-a-zA-Z0-9()@:%_\+.~#?&//=]*)`)
-)
-
 // Bot struct contains the necessary data to run an instance of a bot
 type Bot struct {
 	Name            string
@@ -34,15 +29,61 @@ type Bot struct {
 	PurgeForLongMsg bool
 	LongMsgAmount   int
 	EnableServer    bool
+	ServerAddress   string   // the address the API server listens on, e.g. localhost:8080
+	CORSOrigins     []string // origins allowed to make cross-origin requests to the API, none if empty
 	PostLinkPerm    uint8
 	Perms           []string                 `json:"-"` // holds a list of users that can post a link
 	DefaultCommands []DefaultCommand         `json:"-"`
 	Commands        map[string]*CommandValue `json:"-"`
 	BadWords        []BadWord                `json:"-"`
+	BadWordReasons  map[Severity]string      `json:"-"` // public message sent for each severity
+	BadWordTimeout  int                      // seconds a user is timed out for when saying a timeout severity bad word
 	Quotes          map[int]*QuoteValues     `json:"-"`
 	Timers          map[string]*TimedValue   `json:"-"`
-	PermittedUsers  map[string]struct{}      // list of users that can post links
-
+	Counters        map[string]*Counter      `json:"-"`
+	PermittedUsers  map[string]LinkPermit    // users that can post links without a high enough PostLinkPerm
+	AllowedDomains  []string                 `json:"-"` // domains anyone can post, including subdomains
+	DeniedDomains   []string                 `json:"-"` // domains that always get the poster banned
+	StrikesEnabled  bool
+	StrikeDecay     time.Duration       // how long a strike counts towards a user's total
+	StrikeLadder    []Punishment        `json:"-"` // punishment for a user with 1, 2, 3... strikes
+	StrikePoints    map[Infraction]int  `json:"-"` // strikes given for each kind of infraction, defaults to 1
+	Strikes         map[string][]Strike `json:"-"`
+	SpamFilters     []SpamFilter        `json:"-"`
+	Events          *EventBus           `json:"-"`
+	CommandCooldown time.Duration       // how long a user waits between uses of a command, moderators never wait
+	ScriptTimeout   time.Duration       // how long a command's script can run for
+	WasmEnabled     bool                // runs the WebAssembly modules in the config directory
+	WasmMemoryPages uint32              // 64KiB pages of memory a module can have
+	WasmTimeout     time.Duration       // how long a module can run for each chat message
+	WasmMessages    int                 // messages a module can send for each chat message
+	PointsEnabled   bool
+	PointsName      string        // what the channel calls its points, e.g. "cookies"
+	PointsPerMsg    int64         // points earned for chatting
+	PointsPerMinute int64         // points earned for each minute in the channel
+	PointsInterval  time.Duration // how often chatting can earn points
+	SubMultiplier   float64       // how many times more points subscribers earn
+	Giveaway        *Giveaway     `json:"-"` // the running giveaway, nil if there isn't one
+	GiveawayClaim   time.Duration // how long a giveaway winner has to speak in chat, no limit if 0
+	Poll            *Poll         `json:"-"` // the running poll, nil if there isn't one
+	PollDuration    time.Duration // how long a poll runs for when no duration is given
+	Bet             *Bet          `json:"-"` // the running prediction, nil if there isn't one
+	Queue           Queue         `json:"-"`
+	QueueSize       int           // how many viewers the queue can hold, no limit if 0
+	QueuePriority   bool          // moves subscribers ahead of everyone else in the queue
+	GreetEnabled    bool
+	GreetFirst      string        // greeting for first time chatters, {user} is replaced with their name
+	GreetReturning  string        // greeting for regulars chatting again after GreetAfter
+	GreetAfter      time.Duration // how long a regular is away before they're greeted again
+	RegularMessages int64         // messages a chatter has to have sent to be greeted as a regular
+	ChatLogEnabled  bool          // stores every chat message and moderation action in the chat log
+	ChatLogKeep     time.Duration // how long lines are kept in the chat log, forever if 0
+	Session         *Session      `json:"-"` // the live stream session, nil if the stream is offline
+	StatusFile      string        // a file holding "live" or "offline", followed to start and end sessions
+	StatusInterval  time.Duration // how often StatusFile is read
+	lastMessages    map[string]*repeatTracker
+	repeatsPruned   time.Time          // when users who stopped chatting were last forgotten from lastMessages
+	viewers         map[string]*viewer // users in the channel, who earn points for watching
 }
 
 type BotLoaderFunc func(bot *Bot) error
@@ -94,8 +135,45 @@ func LoadBot(bot *Bot) error {
 	bot.PurgeForLongMsg = bot.Config.GetBool("PurgeForLongMsg")
 	bot.LongMsgAmount = bot.Config.GetInt("LongMsgAmount")
 	bot.EnableServer = bot.Config.GetBool("EnableServer")
+	bot.ServerAddress = bot.Config.GetString("ServerAddress")
+	bot.CORSOrigins = bot.Config.GetStringSlice("CORSOrigins")
+	bot.PostLinkPerm = uint8(bot.Config.GetUint("PostLinkPerm"))
+	bot.AllowedDomains = bot.Config.GetStringSlice("Links.Allow")
+	bot.DeniedDomains = bot.Config.GetStringSlice("Links.Deny")
+	bot.PermittedUsers = make(map[string]LinkPermit)
+	bot.BadWordTimeout = bot.Config.GetInt("BadWordTimeout")
+	bot.Events = NewEventBus(bot.Config.GetInt("EventHistory"))
+	bot.CommandCooldown = bot.Config.GetDuration("CommandCooldown")
+	bot.ScriptTimeout = bot.Config.GetDuration("ScriptTimeout")
+	bot.WasmEnabled = bot.Config.GetBool("Wasm.Enabled")
+	bot.WasmMemoryPages = bot.Config.GetUint32("Wasm.MemoryPages")
+	bot.WasmTimeout = bot.Config.GetDuration("Wasm.Timeout")
+	bot.WasmMessages = bot.Config.GetInt("Wasm.Messages")
+	bot.loadBadWordReasons()
+	bot.loadPointsConfig()
+	bot.loadGreetingConfig()
+	bot.GiveawayClaim = bot.Config.GetDuration("Giveaways.ClaimTime")
+	bot.PollDuration = bot.Config.GetDuration("PollDuration")
+	bot.QueueSize = bot.Config.GetInt("Queue.Size")
+	bot.QueuePriority = bot.Config.GetBool("Queue.SubPriority")
+	bot.StatusFile = bot.Config.GetString("Sessions.StatusFile")
+	bot.StatusInterval = bot.Config.GetDuration("Sessions.StatusInterval")
+
+	err := bot.loadStrikeConfig()
+	if err != nil {
+		return err
+	}
+
+	err = bot.loadSpamFilters()
+	if err != nil {
+		return err
+	}
+
+	err = bot.loadChatLogConfig()
+	if err != nil {
+		return err
+	}
 
-	var err error
 	// load data
 	bot.Commands = make(map[string]*CommandValue)
 	err = bot.LoadCommands()
@@ -103,6 +181,11 @@ func LoadBot(bot *Bot) error {
 		return err
 	}
 
+	err = bot.LoadScripts()
+	if err != nil {
+		return err
+	}
+
 	bot.Quotes = make(map[int]*QuoteValues)
 	err = bot.LoadQuotes()
 	if err != nil {
@@ -120,6 +203,36 @@ func LoadBot(bot *Bot) error {
 		return err
 	}
 
+	err = bot.LoadStrikes()
+	if err != nil {
+		return err
+	}
+
+	err = bot.LoadQueue()
+	if err != nil {
+		return err
+	}
+
+	err = bot.LoadCounters()
+	if err != nil {
+		return err
+	}
+
+	err = bot.LoadGiveaway()
+	if err != nil {
+		return err
+	}
+
+	err = bot.LoadBet()
+	if err != nil {
+		return err
+	}
+
+	err = bot.LoadSession()
+	if err != nil {
+		return err
+	}
+
 	return err
 }
 
@@ -144,26 +257,6 @@ func Itob(i int) bool {
 	return i == 1
 }
 
-// DetectURl uses urlRegex to determine if the passed in message is a URL. The caller than perform whatever
-// action is desired with this information
-func (bot *Bot) DetectURL(message string) bool {
-	return urlRegex.MatchString(message)
-}
-
-// AddPermittedUser adds a user to the PermittedUsers slice, allowing them to post a link without being purged
-func (bot *Bot) AddPermittedUser(username string) {
-	bot.PermittedUsers[username] = struct{}{}
-}
-
-// DeletePermittedUser deletes a user from the permittedusers map if they exist in it
-func (bot *Bot) DeletePermittedUser(username string) bool {
-	var exists bool
-	if _, exists = bot.PermittedUsers[username]; exists {
-		delete(bot.PermittedUsers, username)
-	}
-	return exists
-}
-
 // GetOAuth returns the bot's oauth token
 func (bot *Bot) GetOAuth() string {
 	if !strings.Contains(bot.oauth, "oauth") {
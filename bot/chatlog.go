@@ -0,0 +1,155 @@
+// chatlog.go keeps a searchable log of every chat message and moderation action, so moderators can look back at what
+// a user said before acting on them. Lines older than the configured retention are pruned.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// kinds of ChatLine
+const (
+	ChatLineMessage    = "message"
+	ChatLineModeration = "moderation"
+)
+
+var chatLogColumns = []string{"kind", "username", "user_id", "message", "timestamp"}
+
+// ChatLine is a single line of the chat log
+type ChatLine struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"` // ChatLineMessage or ChatLineModeration
+	User      string    `json:"user"` // who sent the message, or who the moderation action was taken against
+	UserID    string    `json:"user_id"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ChatLogFilter narrows down a chat log search. Zero values are ignored.
+type ChatLogFilter struct {
+	User  string
+	Term  string // words that must all be in the line, in any order
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// loadChatLogConfig reads the chat log settings from the config
+func (bot *Bot) loadChatLogConfig() error {
+	bot.ChatLogEnabled = bot.Config.GetBool("ChatLog.Enabled")
+	retention := bot.Config.GetString("ChatLog.Retention")
+	if retention == "" || retention == "0" {
+		bot.ChatLogKeep = 0
+		return nil
+	}
+	keep, err := ParseDuration(retention)
+	if err != nil {
+		return fmt.Errorf("ChatLog.Retention '%s' is not a duration such as 30d or 12h", retention)
+	}
+	bot.ChatLogKeep = keep
+	return nil
+}
+
+// LogEvent adds a chat message or moderation action event to the chat log, other events are ignored
+func (bot *Bot) LogEvent(event Event) error {
+	line := ChatLine{Timestamp: event.Timestamp}
+	switch data := event.Data.(type) {
+	case Item:
+		line.Kind, line.User, line.UserID, line.Message = ChatLineMessage, data.Sender.Name, data.Sender.ID, data.Text()
+	case ModLogEntry:
+		line.Kind, line.User, line.UserID = ChatLineModeration, data.Target, data.TargetID
+		line.Message = fmt.Sprintf("%s %s by %s", data.Action, data.Target, data.Actor)
+		if data.Duration > 0 {
+			line.Message += fmt.Sprintf(" for %s", data.Duration)
+		}
+		if data.Reason != "" {
+			line.Message += ": " + data.Reason
+		}
+	default:
+		return nil
+	}
+	return bot.LogChat(line)
+}
+
+// LogChat adds a line to the chat log, the timestamp is set to now if it is empty
+func (bot *Bot) LogChat(line ChatLine) error {
+	if line.Timestamp.IsZero() {
+		line.Timestamp = time.Now()
+	}
+	return bot.Storage.DB.Insert("chatlog", chatLogColumns, []string{line.Kind, strings.ToLower(line.User), line.UserID,
+		line.Message, line.Timestamp.Format(modLogTimeFormat)})
+}
+
+// ChatLog returns the lines of the chat log matching filter, newest first
+func (bot *Bot) ChatLog(filter ChatLogFilter) ([]ChatLine, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.User != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, strings.ToLower(strings.TrimPrefix(filter.User, "@")))
+	}
+	if match := searchTerms(filter.Term); match != "" {
+		conditions = append(conditions, "id in (select docid from chatlog_search where chatlog_search match ?)")
+		args = append(args, match)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Since.Format(modLogTimeFormat))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, filter.Until.Format(modLogTimeFormat))
+	}
+
+	query := fmt.Sprintf("select id, %s from chatlog", strings.Join(chatLogColumns, ", "))
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by timestamp desc, id desc"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" limit %d", filter.Limit)
+	}
+
+	rows, err := bot.Storage.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var lines []ChatLine
+	for rows.Next() {
+		var line ChatLine
+		var timestamp string
+		if err = rows.Scan(&line.ID, &line.Kind, &line.User, &line.UserID, &line.Message, &timestamp); err != nil {
+			return nil, err
+		}
+		if line.Timestamp, err = time.ParseInLocation(modLogTimeFormat, timestamp, time.Local); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// searchTerms turns a search into a full text query where every word has to match. Each word is quoted so that
+// characters the query syntax treats specially are searched for like any other.
+func searchTerms(term string) string {
+	var words []string
+	for _, word := range strings.Fields(term) {
+		if word = strings.ReplaceAll(word, `"`, ""); word != "" {
+			words = append(words, `"`+word+`"`)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// PruneChatLog deletes the lines of the chat log older than ChatLogKeep, nothing is deleted if it is 0
+func (bot *Bot) PruneChatLog() error {
+	if bot.ChatLogKeep <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-bot.ChatLogKeep).Format(modLogTimeFormat)
+	return bot.Storage.DB.ArbitraryExec("delete from chatlog where timestamp < ?", cutoff)
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChatLog(t *testing.T) {
+	database := newTestDatabase(t)
+
+	bot := &Bot{Storage: database, ChatLogKeep: 7 * 24 * time.Hour}
+	now := time.Now()
+	events := []Event{
+		{Type: EventMessage, Timestamp: now.AddDate(0, 0, -30), Data: Item{Sender: User{ID: "1", Name: "spammer"}, Contents: "an old message"}},
+		{Type: EventMessage, Timestamp: now.AddDate(0, 0, -3), Data: Item{Sender: User{ID: "1", Name: "spammer"}, Contents: "join my Discord server"}},
+		{Type: EventMessage, Timestamp: now.Add(-time.Hour), Data: Item{Sender: User{ID: "2", Name: "viewer"}, Type: "!quote", Command: "add", Contents: "a discord quote"}},
+		{Type: EventModeration, Timestamp: now, Data: ModLogEntry{Action: ModActionBan, Actor: "somemod", Target: "spammer", TargetID: "1", Reason: "spam"}},
+		{Type: EventTimer, Timestamp: now, Data: TimerEvent{Name: "ignored", Message: "discord"}},
+	}
+	for _, event := range events {
+		if err := bot.LogEvent(event); err != nil {
+			t.Fatalf("could not log an event: %v", err)
+		}
+	}
+	if err := bot.PruneChatLog(); err != nil {
+		t.Fatalf("could not prune the chat log: %v", err)
+	}
+
+	tests := []struct {
+		description  string
+		filter       ChatLogFilter
+		wantMessages []string
+	}{
+		{
+			description:  "should return everything newer than the retention, newest first",
+			filter:       ChatLogFilter{},
+			wantMessages: []string{"ban spammer by somemod: spam", "!quote add a discord quote", "join my Discord server"},
+		},
+		{
+			description:  "should filter by user, including moderation actions against them",
+			filter:       ChatLogFilter{User: "@Spammer"},
+			wantMessages: []string{"ban spammer by somemod: spam", "join my Discord server"},
+		},
+		{
+			description:  "should search for every word in any order",
+			filter:       ChatLogFilter{Term: "discord JOIN"},
+			wantMessages: []string{"join my Discord server"},
+		},
+		{
+			description:  "should search for words with special characters",
+			filter:       ChatLogFilter{Term: `"discord" OR*`},
+			wantMessages: nil,
+		},
+		{
+			description:  "should filter by date and search together",
+			filter:       ChatLogFilter{Term: "discord", Since: now.AddDate(0, 0, -1)},
+			wantMessages: []string{"!quote add a discord quote"},
+		},
+		{
+			description:  "should limit the results",
+			filter:       ChatLogFilter{Limit: 1},
+			wantMessages: []string{"ban spammer by somemod: spam"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			lines, err := bot.ChatLog(test.filter)
+			if err != nil {
+				t.Fatalf("got an unexpected error: %v", err)
+			}
+
+			var messages []string
+			for _, line := range lines {
+				messages = append(messages, line.Message)
+			}
+			if !reflect.DeepEqual(messages, test.wantMessages) {
+				t.Errorf("did not get the expected lines\ngot - %v\nwant - %v", messages, test.wantMessages)
+			}
+		})
+	}
+}
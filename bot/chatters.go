@@ -0,0 +1,214 @@
+// chatters.go keeps a profile for everyone who chats, keyed by their Twitch user ID so it follows them through name
+// changes. Profiles hold when a user was first and last seen, how many messages they've sent, every name they've
+// used and notes left by moderators.
+
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxNoteLength = 500
+
+var errNoChatter = errors.New("that user hasn't chatted yet")
+
+// Profile is everything the bot knows about a chatter
+type Profile struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"` // the name they last chatted with
+	FirstSeen time.Time    `json:"first_seen"`
+	LastSeen  time.Time    `json:"last_seen"`
+	Messages  int64        `json:"messages"`
+	Names     []NameChange `json:"names"` // every name they've chatted with, oldest first
+	Notes     []Note       `json:"notes"`
+}
+
+// NameChange is a name a chatter has used, and when it was first seen
+type NameChange struct {
+	Name string    `json:"name"`
+	Seen time.Time `json:"seen"`
+}
+
+// Note is something a moderator has written about a chatter
+type Note struct {
+	Author    string    `json:"author"`
+	Note      string    `json:"note"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Visit is what TrackChatter found out about a message's sender
+type Visit struct {
+	User      User
+	FirstTime bool   // this is their first message in the channel
+	Returning bool   // they are a regular chatting again after being away
+	OldName   string // the name they last chatted with, if it has changed
+}
+
+// loadGreetingConfig reads the greeting settings from the config
+func (bot *Bot) loadGreetingConfig() {
+	bot.GreetEnabled = bot.Config.GetBool("Greetings.Enabled")
+	bot.GreetFirst = bot.Config.GetString("Greetings.FirstTime")
+	bot.GreetReturning = bot.Config.GetString("Greetings.Returning")
+	bot.GreetAfter = bot.Config.GetDuration("Greetings.ReturnAfter")
+	bot.RegularMessages = bot.Config.GetInt64("Greetings.RegularMessages")
+}
+
+// TrackChatter updates the profile of a chat message's sender. A user is chatting for the first time if Twitch says
+// so or if they have no profile yet, and returning if they've sent RegularMessages messages before and haven't
+// chatted for GreetAfter.
+func (bot *Bot) TrackChatter(item Item) (Visit, error) {
+	visit := Visit{User: item.Sender}
+	if item.Sender.ID == "" || item.IsServerInfo {
+		return visit, nil
+	}
+	now := time.Now()
+
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		var name, lastSeen string
+		var messages int64
+		err := tx.QueryRow("select username, last_seen, messages from chatters where user_id = ?", item.Sender.ID).
+			Scan(&name, &lastSeen, &messages)
+		if err == sql.ErrNoRows {
+			visit.FirstTime = true
+			_, err = tx.Exec(`insert into chatters (user_id, username, first_seen, last_seen, messages) values (?, ?, ?, ?, 1)`,
+				item.Sender.ID, item.Sender.Name, now.Format(time.RFC3339), now.Format(time.RFC3339))
+			if err == nil {
+				err = addName(tx, item.Sender.ID, item.Sender.Name, now)
+			}
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if last, err := time.Parse(time.RFC3339, lastSeen); err == nil {
+			visit.Returning = bot.GreetAfter > 0 && messages >= bot.RegularMessages && now.Sub(last) >= bot.GreetAfter
+		}
+		if name != item.Sender.Name {
+			visit.OldName = name
+			if err = addName(tx, item.Sender.ID, item.Sender.Name, now); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("update chatters set username = ?, last_seen = ?, messages = messages + 1 where user_id = ?",
+			item.Sender.Name, now.Format(time.RFC3339), item.Sender.ID)
+		return err
+	})
+	if item.FirstMessage {
+		visit.FirstTime = true
+	}
+	return visit, err
+}
+
+// addName records a name a chatter has used, a name they've used before keeps when it was first seen
+func addName(tx *sql.Tx, id, name string, seen time.Time) error {
+	_, err := tx.Exec("insert or ignore into chatter_names (user_id, username, seen) values (?, ?, ?)", id, name,
+		seen.Format(time.RFC3339))
+	return err
+}
+
+// Greeting returns the message to greet a chatter with, or an empty string if they shouldn't be greeted
+func (bot *Bot) Greeting(visit Visit) string {
+	if !bot.GreetEnabled {
+		return ""
+	}
+	var greeting string
+	if visit.FirstTime {
+		greeting = bot.GreetFirst
+	} else if visit.Returning {
+		greeting = bot.GreetReturning
+	}
+	return strings.ReplaceAll(greeting, "{user}", visit.User.Name)
+}
+
+// FindChatter returns the profile of the user who last chatted as name, or of someone who used to chat as name if
+// nobody is using it now
+func (bot *Bot) FindChatter(name string) (Profile, error) {
+	id, err := bot.ChatterID(name)
+	if err != nil {
+		return Profile{}, err
+	}
+	return bot.GetChatter(id)
+}
+
+// ChatterID returns the ID of the user who last chatted as name, or of someone who used to chat as name if nobody is
+// using it now
+func (bot *Bot) ChatterID(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(name, "@"))
+	var id string
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		err := tx.QueryRow("select user_id from chatters where username = ?", name).Scan(&id)
+		if err == sql.ErrNoRows {
+			err = tx.QueryRow("select user_id from chatter_names where username = ? order by seen desc limit 1", name).Scan(&id)
+		}
+		return err
+	})
+	if err == sql.ErrNoRows {
+		return "", NonFatalError{Err: errNoChatter}
+	}
+	return id, err
+}
+
+// GetChatter returns the profile of the user with the given ID
+func (bot *Bot) GetChatter(id string) (Profile, error) {
+	profile := Profile{ID: id, Names: []NameChange{}, Notes: []Note{}}
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		var firstSeen, lastSeen string
+		err := tx.QueryRow("select username, first_seen, last_seen, messages from chatters where user_id = ?", id).
+			Scan(&profile.Name, &firstSeen, &lastSeen, &profile.Messages)
+		if err != nil {
+			return err
+		}
+		profile.FirstSeen, _ = time.Parse(time.RFC3339, firstSeen)
+		profile.LastSeen, _ = time.Parse(time.RFC3339, lastSeen)
+
+		rows, err := tx.Query("select username, seen from chatter_names where user_id = ? order by seen, rowid", id)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var change NameChange
+			var seen string
+			if err = rows.Scan(&change.Name, &seen); err != nil {
+				rows.Close()
+				return err
+			}
+			change.Seen, _ = time.Parse(time.RFC3339, seen)
+			profile.Names = append(profile.Names, change)
+		}
+		rows.Close()
+
+		rows, err = tx.Query("select author, note, timestamp from chatter_notes where user_id = ? order by rowid", id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var note Note
+			var timestamp string
+			if err = rows.Scan(&note.Author, &note.Note, &timestamp); err != nil {
+				return err
+			}
+			note.Timestamp, _ = time.Parse(time.RFC3339, timestamp)
+			profile.Notes = append(profile.Notes, note)
+		}
+		return rows.Err()
+	})
+	if err == sql.ErrNoRows {
+		return Profile{}, NonFatalError{Err: errNoChatter}
+	}
+	return profile, err
+}
+
+// AddNote adds a moderator's note to the profile of the user with the given ID
+func (bot *Bot) AddNote(id, author, note string) error {
+	note = strings.TrimSpace(note)
+	if note == "" || len(note) > maxNoteLength {
+		return NonFatalError{Err: fmt.Errorf("a note has to be between 1 and %d characters", maxNoteLength)}
+	}
+	return bot.Storage.DB.ArbitraryExec("insert into chatter_notes (user_id, author, note, timestamp) values (?, ?, ?, ?)",
+		id, author, note, time.Now().Format(time.RFC3339))
+}
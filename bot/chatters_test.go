@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackChatter(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, GreetEnabled: true, GreetFirst: "welcome @{user}!", GreetReturning: "welcome back @{user}!",
+		GreetAfter: time.Hour, RegularMessages: 2}
+
+	// away makes it look like the user last chatted two hours ago
+	away := func() {
+		last := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+		database.DB.ArbitraryExec("update chatters set last_seen = ? where user_id = ?", last, "1")
+	}
+
+	tests := []struct {
+		description  string
+		inputItem    Item
+		before       func()
+		wantGreeting string
+		wantOldName  string
+	}{
+		{
+			description:  "should greet a first time chatter",
+			inputItem:    Item{Sender: User{ID: "1", Name: "newbie"}, Contents: "hi"},
+			wantGreeting: "welcome @newbie!",
+		},
+		{
+			description: "should not greet a chatter twice",
+			inputItem:   Item{Sender: User{ID: "1", Name: "newbie"}, Contents: "hello again"},
+		},
+		{
+			description: "should follow a name change",
+			inputItem:   Item{Sender: User{ID: "1", Name: "regular"}, Contents: "new name"},
+			wantOldName: "newbie",
+		},
+		{
+			description:  "should greet a regular that was away",
+			inputItem:    Item{Sender: User{ID: "1", Name: "regular"}, Contents: "i'm back"},
+			before:       away,
+			wantGreeting: "welcome back @regular!",
+		},
+		{
+			description: "should not greet someone who isn't a regular yet",
+			inputItem:   Item{Sender: User{ID: "2", Name: "lurker"}, Contents: "hi", FirstMessage: false},
+			before: func() {
+				database.DB.ArbitraryExec(`insert into chatters (user_id, username, first_seen, last_seen, messages)
+					values ('2', 'lurker', '2020-01-01T00:00:00Z', '2020-01-01T00:00:00Z', 1)`)
+			},
+		},
+		{
+			description:  "should greet someone Twitch says is chatting for the first time",
+			inputItem:    Item{Sender: User{ID: "2", Name: "lurker"}, Contents: "hi", FirstMessage: true},
+			wantGreeting: "welcome @lurker!",
+		},
+	}
+	for _, test := range tests {
+		if test.before != nil {
+			test.before()
+		}
+		visit, err := bot.TrackChatter(test.inputItem)
+		if err != nil {
+			t.Fatalf("%s\ncould not track the chatter: %v", test.description, err)
+		}
+		if greeting := bot.Greeting(visit); greeting != test.wantGreeting || visit.OldName != test.wantOldName {
+			t.Errorf("%s\ndid not get the expected visit\ngot - %q, %q\nwant - %q, %q", test.description, greeting,
+				visit.OldName, test.wantGreeting, test.wantOldName)
+		}
+	}
+
+	// the old name still finds the profile
+	profile, err := bot.FindChatter("@NewBie")
+	if err != nil {
+		t.Fatalf("could not find the chatter: %v", err)
+	}
+	if profile.ID != "1" || profile.Name != "regular" || profile.Messages != 4 || len(profile.Names) != 2 {
+		t.Errorf("did not get the expected profile: %+v", profile)
+	}
+	if _, err = bot.FindChatter("nobody"); err == nil {
+		t.Errorf("found a profile for someone who never chatted")
+	}
+
+	if err = bot.AddNote(profile.ID, "mod", "  was nice about it  "); err != nil {
+		t.Fatalf("could not add a note: %v", err)
+	}
+	if err = bot.AddNote(profile.ID, "mod", " "); err == nil {
+		t.Errorf("added an empty note")
+	}
+	if profile, _ = bot.GetChatter("1"); len(profile.Notes) != 1 || profile.Notes[0].Note != "was nice about it" {
+		t.Errorf("did not get the expected notes: %+v", profile.Notes)
+	}
+}
@@ -14,6 +14,7 @@ type CommandValue struct {
 	Response string `json:"response"`
 	Perm     string `json:"perm"`
 	Count    int    `json:"count"`
+	Script   string `json:"script,omitempty"` // Lua script run in place of sending Response, see scripts.go
 }
 
 // AddCommandString takes in a string of the form !addcom !comtitle <command response>
@@ -51,6 +52,14 @@ func (bot *Bot) RemoveCommand(key string) (bool, error) {
 		if err != nil {
 			return found, err
 		}
+		// the command's script and anything it stored go with it
+		err = bot.Storage.DB.Delete("command_scripts", "commandname", key)
+		if err == nil {
+			err = bot.Storage.DB.Delete("script_data", "commandname", key)
+		}
+		if err != nil {
+			return found, err
+		}
 	}
 	return found, nil
 }
@@ -71,10 +80,17 @@ func (bot *Bot) EditCommand(item Item) error {
 	return nil
 }
 
-// IncrementCommandCount takes in a command name (key) and increments the associated count value in the DB
+// IncrementCommandCount takes in a command name (key) and increments the associated count value in the DB. Older
+// commands may be stored without their leading '!', so either name is matched.
 func (bot *Bot) IncrementCommandCount(command string) error {
-	stmt := fmt.Sprintf("UPDATE commands SET count = count + 1 WHERE commandname = '%s'", command) // prepare statement tring
-	err := bot.Storage.DB.ArbitraryExec(stmt)
+	if com, ok := bot.Commands[command]; ok {
+		com.Count++
+	}
+	if bot.Storage == nil {
+		return nil
+	}
+	err := bot.Storage.DB.ArbitraryExec("UPDATE commands SET count = coalesce(count, 0) + 1 WHERE commandname = ? OR commandname = ?",
+		command, strings.TrimPrefix(command, "!"))
 	if err != nil {
 		return fmt.Errorf("Error updating the count for %s. Error: %s", command, err)
 	}
@@ -83,7 +99,7 @@ func (bot *Bot) IncrementCommandCount(command string) error {
 
 // LoadCommands queries the sqlite3 database for existing commands
 func (bot *Bot) LoadCommands() error {
-	rows, err := bot.Storage.DB.Query("select commandname, commandresponse, perm from commands")
+	rows, err := bot.Storage.DB.Query("select commandname, commandresponse, perm, coalesce(count, 0) from commands")
 	if err != nil {
 		return err
 	}
@@ -92,7 +108,8 @@ func (bot *Bot) LoadCommands() error {
 	for rows.Next() { // scan through results from query and assign to the Commands slice
 		var name, response string
 		var perm string
-		err = rows.Scan(&name, &response, &perm)
+		var count int
+		err = rows.Scan(&name, &response, &perm, &count)
 		if err != nil {
 			return err
 		}
@@ -100,7 +117,7 @@ func (bot *Bot) LoadCommands() error {
 			name = fmt.Sprintf("!%s", name)
 		}
 
-		bot.Commands[name] = &CommandValue{Response: response, Perm: perm}
+		bot.Commands[name] = &CommandValue{Response: response, Perm: perm, Count: count}
 	}
 	return nil
 }
@@ -112,16 +129,16 @@ func (bot *Bot) ConvertPermToInt(perm string) (uint8, error) {
 
 	switch perm { // determine permission, or error out if needed
 	case "all":
-		return 0, err
+		return PermAll, err
 
 	case "subscriber":
-		return 1, err
+		return PermSubscriber, err
 
 	case "moderator":
-		return 2, err
+		return PermModerator, err
 
 	case "broadcaster":
-		return 3, err
+		return PermBroadcaster, err
 
 	default:
 		return 255, fmt.Errorf("did not receive a valid permission: %s", perm)
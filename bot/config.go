@@ -21,13 +21,14 @@ func CreateViperConfig(path, name, configType, serverName string) (*viper.Viper,
 	v.SetConfigName(name)
 	v.SetConfigType(configType)
 	v.AddConfigPath(path)
+	setDefaults(serverName, v)
 
 	fullPath := fmt.Sprintf("%s/%s", path, name)
 	// attempt to read in the config
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// write a default config file at path/name.
-			writeConfig(fmt.Sprintf("%s/%s", path, name), serverName, v)
+			writeConfig(fmt.Sprintf("%s/%s", path, name), v)
 			return nil, NonFatalError{Err: fmt.Errorf("had to create a default config file, please go to %s and edit values as needed", fullPath)}
 		} else {
 			return nil, FatalError{Err: err}
@@ -46,18 +47,88 @@ func GetConfigDirectory() (string, error) {
 	return fmt.Sprintf("%s/.config/pleasantbot", home), nil
 }
 
-// writeConfig is run whenever the config.toml file doesn't exist, usually after a fresh download of the bot.
-func writeConfig(path, serverName string, configObject *viper.Viper) {
-	// prepare default values, will be used when viper writes the new config file
+// writeConfig is run whenever the config.toml file doesn't exist, usually after a fresh download of the bot. Besides
+// the settings from setDefaults it writes the values that need filling in and switches on the features a new bot uses.
+func writeConfig(path string, configObject *viper.Viper) {
 	configObject.SetDefault("ChannelName", "<enter channel name to moderate here>")
-	configObject.SetDefault("ServerName", serverName)
 	configObject.SetDefault("BotName", "<enter bot username here>")
 	configObject.SetDefault("BotOAuth", "<bot oauth>")
 	configObject.SetDefault("PurgeForLinks", true)
 	configObject.SetDefault("PurgeForLongMsg", true)
-	configObject.SetDefault("LongMsgAmount", 400)
 	configObject.SetDefault("EnableServer", true)
-	configObject.SetDefault("PostLinkPerm", uint(1)) // Minimum permission needed for non-purging links, in this case subscriber
+	configObject.SetDefault("Wasm.Enabled", false)                      // runs the .wasm modules in the config directory's wasm folder
+	configObject.SetDefault("Links.Allow", []string{"clips.twitch.tv"}) // domains anyone can post
+	configObject.SetDefault("Links.Deny", []string{})                   // domains that get the poster banned
+	configObject.SetDefault("Points.Enabled", true)
+	configObject.SetDefault("Greetings.Enabled", false)
+	configObject.SetDefault("ChatLog.Enabled", true)
+	configObject.SetDefault("Strikes.Enabled", true)
+
+	// spam filters, Threshold is a ratio for caps and symbols and a count for the rest
+	setFilterDefault(configObject, "caps", 0.7, 10, "delete", "please don't shout in chat")
+	setFilterDefault(configObject, "symbols", 0.6, 10, "delete", "please don't spam symbols")
+	setFilterDefault(configObject, "emotes", 10, 0, "delete", "please don't spam emotes")
+	setFilterDefault(configObject, "repeatedchars", 15, 0, "delete", "please don't spam characters")
+	setFilterDefault(configObject, "repeatedwords", 8, 0, "delete", "please don't repeat yourself")
+	setFilterDefault(configObject, "zalgo", 5, 0, "purge", "please don't post zalgo text")
+	setFilterDefault(configObject, "duplicate", 3, 0, "10s", "please don't post the same message over and over")
 
 	configObject.WriteConfigAs(path)
 }
+
+// setDefaults registers the default value of every setting, so a config written by an older version of the bot gets
+// sensible values for settings it doesn't have. Features are only switched on by writeConfig, so upgrading doesn't
+// turn on anything that wasn't asked for.
+func setDefaults(serverName string, configObject *viper.Viper) {
+	configObject.SetDefault("ServerName", serverName)
+	configObject.SetDefault("LongMsgAmount", 400)
+	configObject.SetDefault("ServerAddress", "localhost:8080")
+	configObject.SetDefault("CORSOrigins", []string{}) // e.g. ["https://dashboard.example.com"]
+	configObject.SetDefault("EventHistory", 500)       // events kept so event stream clients can resume
+	configObject.SetDefault("CommandCooldown", "5s")   // how long a user waits between uses of a command
+	configObject.SetDefault("ScriptTimeout", "250ms")  // how long a command's script can run for
+	configObject.SetDefault("Wasm.MemoryPages", 16)    // 64KiB pages of memory a module can have
+	configObject.SetDefault("Wasm.Timeout", "100ms")   // how long a module can run for each chat message
+	configObject.SetDefault("Wasm.Messages", 3)        // messages a module can send for each chat message
+	configObject.SetDefault("PostLinkPerm", uint(1))   // Minimum permission needed for non-purging links, in this case subscriber
+	configObject.SetDefault("BadWordTimeout", 600)
+	configObject.SetDefault("BadWordReasons", map[string]string{
+		"delete":  "please keep it friendly in chat",
+		"purge":   "that language isn't allowed here",
+		"timeout": "that language isn't allowed here, take a break",
+		"ban":     "that language will not be tolerated",
+	})
+	configObject.SetDefault("Points.Name", "points")
+	configObject.SetDefault("Points.PerMessage", 1)         // points earned for chatting
+	configObject.SetDefault("Points.PerMinute", 1)          // points earned for each minute in the channel
+	configObject.SetDefault("Points.MessageInterval", "1m") // how often chatting can earn points
+	configObject.SetDefault("Points.SubMultiplier", 2.0)    // subscribers earn this many times more points
+	configObject.SetDefault("Giveaways.ClaimTime", "0s")    // how long a winner has to speak in chat, e.g. "60s", no limit if 0
+	configObject.SetDefault("PollDuration", "2m")           // how long a poll runs for when no duration is given
+	configObject.SetDefault("Queue.Size", 50)               // how many viewers the queue can hold, no limit if 0
+	configObject.SetDefault("Queue.SubPriority", false)     // moves subscribers ahead of everyone else in the queue
+
+	configObject.SetDefault("Greetings.FirstTime", "welcome to the chat @{user}!") // empty to not greet first time chatters
+	configObject.SetDefault("Greetings.Returning", "welcome back @{user}!")        // empty to not greet returning regulars
+	configObject.SetDefault("Greetings.ReturnAfter", "12h")                        // how long a regular is away before being greeted again
+	configObject.SetDefault("Greetings.RegularMessages", 20)                       // messages a chatter needs to be a regular
+	configObject.SetDefault("ChatLog.Retention", "30d")                            // how long chat lines are kept, forever if 0
+	configObject.SetDefault("Sessions.StatusFile", "")                             // a file holding "live" or "offline" that starts and ends sessions
+	configObject.SetDefault("Sessions.StatusInterval", "30s")                      // how often the status file is read
+	configObject.SetDefault("Strikes.Decay", "24h")                                // how long until a strike no longer counts
+
+	configObject.SetDefault("Strikes.Ladder", []string{"warn", "10s", "10m", "1h", "ban"}) // punishment for 1, 2, 3... strikes
+	configObject.SetDefault("Strikes.Points", map[string]int{"link": 1, "deniedlink": 1, "longmsg": 1, "badword": 2})
+}
+
+// setFilterDefault prepares the default config values for a single spam filter
+func setFilterDefault(configObject *viper.Viper, name string, threshold float64, minLength int, action, reason string) {
+	configObject.SetDefault(fmt.Sprintf("Filters.%s", name), map[string]interface{}{
+		"Enabled":   true,
+		"Threshold": threshold,
+		"MinLength": minLength,
+		"Exempt":    "moderator",
+		"Action":    action,
+		"Reason":    reason,
+	})
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateViperConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// a new config is written with every feature a new bot uses
+	if _, err := CreateViperConfig(dir, "twitch", "toml", "irc.chat.twitch.tv:6697"); !errors.As(err, &NonFatalError{}) {
+		t.Fatalf("did not get the expected error for a new config\ngot - %v", err)
+	}
+	config, err := CreateViperConfig(dir, "twitch", "toml", "irc.chat.twitch.tv:6697")
+	if err != nil {
+		t.Fatalf("could not read the new config: %v", err)
+	}
+	if !config.GetBool("Points.Enabled") || !config.IsSet("Filters.caps") || config.GetString("ServerAddress") != "localhost:8080" {
+		t.Errorf("did not write the expected config\ngot - %v", config.AllSettings())
+	}
+
+	// a config from an older version gets the settings it doesn't have, but no new features are switched on
+	old := "ChannelName = \"channel\"\nEnableServer = true\n"
+	if err = os.WriteFile(filepath.Join(dir, "twitch.toml"), []byte(old), 0644); err != nil {
+		t.Fatalf("could not write the old config: %v", err)
+	}
+	config, err = CreateViperConfig(dir, "twitch", "toml", "irc.chat.twitch.tv:6697")
+	if err != nil {
+		t.Fatalf("could not read the old config: %v", err)
+	}
+	if got := config.GetString("ServerAddress"); got != "localhost:8080" {
+		t.Errorf("did not get the expected server address\ngot - %v\nwant - %v", got, "localhost:8080")
+	}
+	if got := config.GetString("Wasm.Timeout"); got != "100ms" {
+		t.Errorf("did not get the expected module timeout\ngot - %v\nwant - %v", got, "100ms")
+	}
+	if config.GetBool("Points.Enabled") || config.IsSet("Filters.caps") {
+		t.Errorf("switched on features the old config didn't have\ngot - %v", config.AllSettings())
+	}
+}
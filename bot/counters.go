@@ -0,0 +1,159 @@
+// counters.go handles named counters such as a death counter. Counters are changed from chat with e.g. '!deaths+',
+// and a command's response can show one with {counter:deaths}. Counters marked per stream are reset together when a
+// new stream starts.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	// counterNameRegex matches the names a counter can have, which are used as chat commands
+	counterNameRegex = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+	// counterVarRegex matches a counter in a command's response, e.g. {counter:deaths}
+	counterVarRegex = regexp.MustCompile(`\{counter:([a-z0-9_]+)\}`)
+
+	errNoCounter = errors.New("there is no counter with that name")
+)
+
+// Counter is a named count, it is also the data for an EventCounter
+type Counter struct {
+	Name      string `json:"name"`
+	Value     int64  `json:"value"`
+	PerStream bool   `json:"per_stream"` // reset to 0 when a new stream starts
+}
+
+// LoadCounters loads every counter from the database
+func (bot *Bot) LoadCounters() error {
+	bot.Counters = make(map[string]*Counter)
+	rows, err := bot.Storage.DB.Query("select name, value, per_stream from counters")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var counter Counter
+		if err = rows.Scan(&counter.Name, &counter.Value, &counter.PerStream); err != nil {
+			return err
+		}
+		bot.Counters[counter.Name] = &counter
+	}
+	return rows.Err()
+}
+
+// AddCounter creates a counter starting at 0. A counter can't share its name with a custom command, since both are
+// used from chat as !name.
+func (bot *Bot) AddCounter(name string, perStream bool) (*Counter, error) {
+	if !counterNameRegex.MatchString(name) {
+		return nil, NonFatalError{Err: fmt.Errorf("a counter's name can only have lowercase letters, numbers and underscores")}
+	}
+	if _, ok := bot.Counters[name]; ok {
+		return nil, NonFatalError{Err: fmt.Errorf("the counter '%s' already exists", name)}
+	}
+	if found, _ := bot.FindCommand("!" + name); found {
+		return nil, NonFatalError{Err: fmt.Errorf("there is already a command named !%s", name)}
+	}
+
+	err := bot.Storage.DB.ArbitraryExec("insert into counters (name, value, per_stream) values (?, 0, ?)", name, perStream)
+	if err != nil {
+		return nil, err
+	}
+	counter := &Counter{Name: name, PerStream: perStream}
+	bot.Counters[name] = counter
+	bot.Publish(EventCounter, *counter)
+	return counter, nil
+}
+
+// RemoveCounter deletes a counter, returning false if it didn't exist
+func (bot *Bot) RemoveCounter(name string) (bool, error) {
+	if _, ok := bot.Counters[name]; !ok {
+		return false, nil
+	}
+	if err := bot.Storage.DB.Delete("counters", "name", name); err != nil {
+		return false, err
+	}
+	delete(bot.Counters, name)
+	return true, nil
+}
+
+// AdjustCounter adds amount to a counter, which may be negative, and returns its new value
+func (bot *Bot) AdjustCounter(name string, amount int64) (int64, error) {
+	counter, ok := bot.Counters[name]
+	if !ok {
+		return 0, NonFatalError{Err: errNoCounter}
+	}
+	if err := bot.SetCounter(name, counter.Value+amount); err != nil {
+		return 0, err
+	}
+	return counter.Value, nil
+}
+
+// SetCounter sets a counter's value
+func (bot *Bot) SetCounter(name string, value int64) error {
+	counter, ok := bot.Counters[name]
+	if !ok {
+		return NonFatalError{Err: errNoCounter}
+	}
+	err := bot.Storage.DB.Update("counters", "name", name, []string{"value"}, []string{strconv.FormatInt(value, 10)})
+	if err != nil {
+		return err
+	}
+	counter.Value = value
+	bot.Publish(EventCounter, *counter)
+	return nil
+}
+
+// SetCounterPerStream changes whether a counter is reset when a new stream starts
+func (bot *Bot) SetCounterPerStream(name string, perStream bool) error {
+	counter, ok := bot.Counters[name]
+	if !ok {
+		return NonFatalError{Err: errNoCounter}
+	}
+	err := bot.Storage.DB.ArbitraryExec("update counters set per_stream = ? where name = ?", perStream, name)
+	if err != nil {
+		return err
+	}
+	counter.PerStream = perStream
+	return nil
+}
+
+// ResetStreamCounters sets every per stream counter back to 0, returning the names of those reset
+func (bot *Bot) ResetStreamCounters() ([]string, error) {
+	var reset []string
+	for _, counter := range bot.SortedCounters() {
+		if !counter.PerStream {
+			continue
+		}
+		if err := bot.SetCounter(counter.Name, 0); err != nil {
+			return reset, err
+		}
+		reset = append(reset, counter.Name)
+	}
+	return reset, nil
+}
+
+// SortedCounters returns a copy of every counter, sorted by name
+func (bot *Bot) SortedCounters() []Counter {
+	counters := make([]Counter, 0, len(bot.Counters))
+	for _, counter := range bot.Counters {
+		counters = append(counters, *counter)
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Name < counters[j].Name })
+	return counters
+}
+
+// ExpandCounters replaces each {counter:name} in response with the counter's value, unknown counters are left alone
+func (bot *Bot) ExpandCounters(response string) string {
+	return counterVarRegex.ReplaceAllStringFunc(response, func(match string) string {
+		name := counterVarRegex.FindStringSubmatch(match)[1]
+		if counter, ok := bot.Counters[name]; ok {
+			return strconv.FormatInt(counter.Value, 10)
+		}
+		return match
+	})
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, Commands: map[string]*CommandValue{"!discord": {Response: "join us"}}}
+	if err := bot.LoadCounters(); err != nil {
+		t.Fatalf("could not load the counters: %v", err)
+	}
+
+	additions := []struct {
+		description string
+		name        string
+		perStream   bool
+		wantErr     bool
+	}{
+		{description: "should add a counter", name: "deaths", perStream: true},
+		{description: "should add a counter that is kept between streams", name: "wins"},
+		{description: "should not add a counter twice", name: "deaths", wantErr: true},
+		{description: "should not add a counter named after a command", name: "discord", wantErr: true},
+		{description: "should not add a counter with an invalid name", name: "Deaths!", wantErr: true},
+	}
+	for _, test := range additions {
+		if _, err := bot.AddCounter(test.name, test.perStream); (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant error - %v", test.description, err, test.wantErr)
+		}
+	}
+
+	bot.AdjustCounter("deaths", 1)
+	bot.AdjustCounter("deaths", 1)
+	if value, err := bot.AdjustCounter("deaths", -1); value != 1 || err != nil {
+		t.Errorf("did not get the expected value\ngot - %v, %v\nwant - %v", value, err, 1)
+	}
+	if err := bot.SetCounter("wins", 10); err != nil {
+		t.Fatalf("could not set the counter: %v", err)
+	}
+	if _, err := bot.AdjustCounter("nope", 1); err == nil {
+		t.Errorf("changed a counter that doesn't exist")
+	}
+
+	responses := []struct {
+		description  string
+		response     string
+		wantResponse string
+	}{
+		{description: "should fill in counters", response: "died {counter:deaths} times, won {counter:wins}", wantResponse: "died 1 times, won 10"},
+		{description: "should leave unknown counters", response: "{counter:nope} and {deaths}", wantResponse: "{counter:nope} and {deaths}"},
+	}
+	for _, test := range responses {
+		if got := bot.ExpandCounters(test.response); got != test.wantResponse {
+			t.Errorf("%s\ndid not get the expected response\ngot - %v\nwant - %v", test.description, got, test.wantResponse)
+		}
+	}
+
+	// the values are kept after loading again, and only the per stream counter is reset
+	if err := bot.LoadCounters(); err != nil {
+		t.Fatalf("could not reload the counters: %v", err)
+	}
+	reset, err := bot.ResetStreamCounters()
+	if err != nil || !reflect.DeepEqual(reset, []string{"deaths"}) {
+		t.Errorf("did not reset the expected counters\ngot - %v, %v\nwant - %v", reset, err, []string{"deaths"})
+	}
+	want := []Counter{{Name: "deaths", Value: 0, PerStream: true}, {Name: "wins", Value: 10}}
+	if got := bot.SortedCounters(); !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected counters\ngot - %v\nwant - %v", got, want)
+	}
+}
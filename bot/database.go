@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"database/sql"
+
 	"github.com/liamphmurphy/pleasantbot/storage"
 )
 
@@ -12,3 +14,104 @@ type Database struct {
 	QuoteColumns   []string
 	TimerColumns   []string
 }
+
+// PrepareDatabase creates any of the bot's tables that are missing and upgrades ones from older versions, it is passed
+// to storage.Init
+func PrepareDatabase(db *sql.DB) error {
+	if err := dropOldChatters(db); err != nil {
+		return err
+	}
+
+	stmt := `
+	CREATE TABLE IF NOT EXISTS commands (id INTEGER PRIMARY KEY, commandname TEXT UNIQUE, commandresponse TEXT, perm TEXT, count INTEGER);
+	CREATE TABLE IF NOT EXISTS badwords (id INTEGER PRIMARY KEY, phrase TEXT, severity INTEGER);
+	CREATE TABLE IF NOT EXISTS quotes (id INTEGER PRIMARY KEY, quote TEXT, timestamp TEXT, submitter TEXT);
+	CREATE TABLE IF NOT EXISTS modlog (id INTEGER PRIMARY KEY, action TEXT, actor TEXT, target TEXT, target_id TEXT, reason TEXT, rule TEXT, excerpt TEXT, duration INTEGER, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS chatters (user_id TEXT PRIMARY KEY, username TEXT, first_seen TEXT, last_seen TEXT, messages INTEGER);
+	CREATE INDEX IF NOT EXISTS chatters_username ON chatters (username);
+	CREATE TABLE IF NOT EXISTS chatter_names (user_id TEXT, username TEXT, seen TEXT, UNIQUE(user_id, username));
+	CREATE TABLE IF NOT EXISTS chatter_notes (id INTEGER PRIMARY KEY, user_id TEXT, author TEXT, note TEXT, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS chatlog (id INTEGER PRIMARY KEY, kind TEXT, username TEXT, user_id TEXT, message TEXT, timestamp TEXT);
+	CREATE INDEX IF NOT EXISTS chatlog_username ON chatlog (username, timestamp);
+	CREATE INDEX IF NOT EXISTS chatlog_timestamp ON chatlog (timestamp);
+	CREATE VIRTUAL TABLE IF NOT EXISTS chatlog_search USING fts4(message);
+	CREATE TRIGGER IF NOT EXISTS chatlog_insert AFTER INSERT ON chatlog BEGIN INSERT INTO chatlog_search (docid, message) VALUES (new.id, new.message); END;
+	CREATE TRIGGER IF NOT EXISTS chatlog_delete AFTER DELETE ON chatlog BEGIN DELETE FROM chatlog_search WHERE docid = old.id; END;
+	CREATE TABLE IF NOT EXISTS sessions (id INTEGER PRIMARY KEY, started TEXT, ended TEXT, started_by TEXT, last_quote INTEGER, summary TEXT);
+	CREATE TABLE IF NOT EXISTS chat_stats (day TEXT, hour INTEGER, username TEXT, messages INTEGER, PRIMARY KEY (day, hour, username));
+	CREATE TABLE IF NOT EXISTS emote_stats (day TEXT, emote TEXT, uses INTEGER, PRIMARY KEY (day, emote));
+	CREATE TABLE IF NOT EXISTS timers (timername TEXT UNIQUE, message TEXT, minutes INTEGER, enabled INTEGER);
+	CREATE TABLE IF NOT EXISTS api_tokens (id INTEGER PRIMARY KEY, name TEXT, hash TEXT UNIQUE, scopes TEXT, created TEXT, expires TEXT, revoked INTEGER);
+	CREATE TABLE IF NOT EXISTS api_audit (id INTEGER PRIMARY KEY, token_id INTEGER, method TEXT, path TEXT, status INTEGER, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS command_scripts (commandname TEXT PRIMARY KEY, script TEXT);
+	CREATE TABLE IF NOT EXISTS script_data (commandname TEXT, key TEXT, value TEXT, UNIQUE(commandname, key));
+	CREATE TABLE IF NOT EXISTS wasm_data (module TEXT, key TEXT, value TEXT, UNIQUE(module, key));
+	CREATE TABLE IF NOT EXISTS points (username TEXT PRIMARY KEY, balance INTEGER, minutes INTEGER);
+	CREATE TABLE IF NOT EXISTS giveaways (id INTEGER PRIMARY KEY, keyword TEXT, cost INTEGER, sub_luck INTEGER, started_by TEXT, started TEXT, ended TEXT);
+	CREATE TABLE IF NOT EXISTS giveaway_entries (giveaway_id INTEGER, user TEXT, tickets INTEGER, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS giveaway_winners (giveaway_id INTEGER, user TEXT, status TEXT, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS polls (id INTEGER PRIMARY KEY, question TEXT, options TEXT, started_by TEXT, started TEXT, ended TEXT, state TEXT);
+	CREATE TABLE IF NOT EXISTS poll_votes (poll_id INTEGER, user_id TEXT, user TEXT, option INTEGER, timestamp TEXT, UNIQUE(poll_id, user_id));
+	CREATE TABLE IF NOT EXISTS bets (id INTEGER PRIMARY KEY, question TEXT, outcomes TEXT, started_by TEXT, started TEXT, ended TEXT, state TEXT, result TEXT);
+	CREATE TABLE IF NOT EXISTS bet_wagers (bet_id INTEGER, user TEXT, outcome TEXT, amount INTEGER, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS bet_payouts (bet_id INTEGER, user TEXT, amount INTEGER, refund BOOLEAN, timestamp TEXT);
+	CREATE TABLE IF NOT EXISTS queue (username TEXT PRIMARY KEY, user_id TEXT, subscriber BOOLEAN, joined TEXT);
+	CREATE TABLE IF NOT EXISTS queue_state (id INTEGER PRIMARY KEY, open BOOLEAN);
+	CREATE TABLE IF NOT EXISTS counters (name TEXT PRIMARY KEY, value INTEGER, per_stream BOOLEAN);
+	CREATE TABLE IF NOT EXISTS strikes (id INTEGER PRIMARY KEY, user TEXT, reason TEXT, amount INTEGER, timestamp TEXT);
+	`
+	if _, err := db.Exec(stmt); err != nil {
+		return err
+	}
+
+	return moveBanHistory(db)
+}
+
+// moveBanHistory moves the bans recorded in the ban_history table from before the moderation log was kept into modlog,
+// then drops the old table
+func moveBanHistory(db *sql.DB) error {
+	var tables int
+	err := db.QueryRow("select count(*) from sqlite_master where type = 'table' and name = 'ban_history'").Scan(&tables)
+	if err != nil || tables == 0 {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO modlog (action, actor, target, target_id, reason, rule, excerpt, duration, timestamp)
+		SELECT ?, '', user, '', reason, '', '', 0, timestamp FROM ban_history ORDER BY rowid`, string(ModActionBan))
+	if err == nil {
+		_, err = tx.Exec("DROP TABLE ban_history")
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// dropOldChatters drops the chatters table from before profiles were kept, which was keyed by name. Nothing ever wrote
+// to it, so no data is lost.
+func dropOldChatters(db *sql.DB) error {
+	rows, err := db.Query("select name from pragma_table_info('chatters')")
+	if err != nil {
+		return err
+	}
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			rows.Close()
+			return err
+		}
+		columns = append(columns, column)
+	}
+	rows.Close()
+
+	if len(columns) > 0 && columns[0] == "username" {
+		_, err = db.Exec("DROP TABLE chatters")
+	}
+	return err
+}
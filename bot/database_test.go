@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+// newTestDatabase creates a database with the bot's tables in a temporary directory, running any seed statements on it
+func newTestDatabase(t *testing.T, seed ...string) *Database {
+	t.Helper()
+	var database Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+	for _, statement := range seed {
+		if err := database.DB.ArbitraryExec(statement); err != nil {
+			t.Fatalf("could not seed the test database: %v", err)
+		}
+	}
+	return &database
+}
+
+func TestMoveBanHistory(t *testing.T) {
+	// a database from before the moderation log was kept
+	path := t.TempDir() + "/test.db"
+	var old storage.Sqlite
+	err := storage.Init(path, &old, func(db *sql.DB) error {
+		_, err := db.Exec(`CREATE TABLE ban_history (user TEXT, reason TEXT, timestamp TEXT);
+			INSERT INTO ban_history VALUES ('spammer', 'bad word', '2022-01-02 03:04:05');`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("could not prepare the old database: %v", err)
+	}
+	old.Close()
+
+	var database Database
+	if err = storage.Init(path, &database.DB, PrepareDatabase); err != nil {
+		t.Fatalf("could not upgrade the database: %v", err)
+	}
+	defer database.DB.Close()
+
+	bot := &Bot{Storage: &database}
+	entries, err := bot.ModLog(ModLogFilter{User: "spammer"})
+	if err != nil {
+		t.Fatalf("could not read the moderation log: %v", err)
+	}
+	want := ModLogEntry{Action: ModActionBan, Target: "spammer", Reason: "bad word",
+		Timestamp: time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)}
+	if len(entries) != 1 || entries[0].Action != want.Action || entries[0].Target != want.Target ||
+		entries[0].Reason != want.Reason || !entries[0].Timestamp.Equal(want.Timestamp) {
+		t.Errorf("did not move the ban into the moderation log\ngot - %+v\nwant - %+v", entries, want)
+	}
+
+	// preparing the database again finds nothing to move
+	var again Database
+	if err = storage.Init(path, &again.DB, PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the database again: %v", err)
+	}
+	defer again.DB.Close()
+	if entries, _ = bot.ModLog(ModLogFilter{}); len(entries) != 1 {
+		t.Errorf("did not get the expected entries\ngot - %v\nwant - %v", len(entries), 1)
+	}
+}
@@ -0,0 +1,303 @@
+// events.go handles the bot's event bus. Chat messages, moderation actions, commands, timers, users joining and
+// connection changes are published as events, and the bot's features subscribe to the ones they need. This keeps the
+// service's read loop from having to know about every feature.
+
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultEventHistory = 500 // how many past events are kept so a subscriber can resume where it left off
+	defaultEventBuffer  = 64  // how many events a subscriber can fall behind by before backpressure applies
+)
+
+// EventType is the kind of thing that happened
+type EventType string
+
+const (
+	EventMessage    EventType = "message"    // Data is an Item
+	EventChat       EventType = "chat"       // Data is an Item, published once the message has passed moderation
+	EventModeration EventType = "moderation" // Data is a ModLogEntry
+	EventCommand    EventType = "command"    // Data is a CommandEvent
+	EventTimer      EventType = "timer"      // Data is a TimerEvent
+	EventConnection EventType = "connection" // Data is a ConnectionEvent
+	EventQuote      EventType = "quote"      // Data is a QuoteEvent
+	EventAlert      EventType = "alert"      // Data is an AlertEvent
+	EventJoin       EventType = "join"       // Data is a UserEvent
+	EventPart       EventType = "part"       // Data is a UserEvent
+	EventPoll       EventType = "poll"       // Data is a Poll, published when it starts, gets a vote and ends
+	EventQueue      EventType = "queue"      // Data is a Queue, published whenever it changes
+	EventCounter    EventType = "counter"    // Data is a Counter, published when it is added or changed
+	EventSession    EventType = "session"    // Data is a Session, published when it starts and ends
+)
+
+// EventTypes lists every kind of event
+var EventTypes = []EventType{EventMessage, EventChat, EventModeration, EventCommand, EventTimer, EventConnection, EventQuote,
+	EventAlert, EventJoin, EventPart, EventPoll, EventQueue,
+	EventCounter, EventSession}
+
+// Event is a single thing that happened, IDs increase by one for each event
+type Event struct {
+	ID        int64       `json:"id"`
+	Type      EventType   `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// CommandEvent is published when a user runs a command
+type CommandEvent struct {
+	Type    string `json:"type"` // e.g. "!com" or "!quote"
+	Command string `json:"command"`
+	Key     string `json:"key"`
+	User    string `json:"user"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TimerEvent is published when a timer sends its message
+type TimerEvent struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// QuoteEvent is published when a quote is shown in chat
+type QuoteEvent struct {
+	ID int `json:"id"`
+	QuoteValues
+}
+
+// kinds of AlertEvent
+const (
+	AlertSub     = "sub"
+	AlertResub   = "resub"
+	AlertSubGift = "subgift"
+	AlertRaid    = "raid"
+)
+
+// AlertEvent is published when something happens in the channel that is worth celebrating, such as a sub or raid
+type AlertEvent struct {
+	Kind      string `json:"kind"` // one of AlertSub, AlertResub, AlertSubGift or AlertRaid
+	User      string `json:"user"`
+	Recipient string `json:"recipient,omitempty"` // who was gifted a sub
+	Months    int    `json:"months,omitempty"`    // cumulative months subscribed
+	Viewers   int    `json:"viewers,omitempty"`   // how many viewers came with a raid
+	Message   string `json:"message,omitempty"`   // the message the user shared with their sub
+}
+
+// UserEvent is published when a user joins or leaves the channel
+type UserEvent struct {
+	User string `json:"user"`
+}
+
+// connection states for a ConnectionEvent
+const (
+	StateConnected    = "connected"
+	StateDisconnected = "disconnected"
+)
+
+// ConnectionEvent is published when the bot connects to or disconnects from a service
+type ConnectionEvent struct {
+	State   string `json:"state"` // StateConnected or StateDisconnected
+	Channel string `json:"channel"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Backpressure decides what happens to an event published to a subscriber whose buffer is full
+type Backpressure int
+
+const (
+	DropNewest Backpressure = iota // the new event is dropped, for subscribers that only care about the latest state
+	DropOldest                     // the oldest buffered event is dropped to make room, for live views
+	Block                          // the publisher waits for room, for subscribers that can't miss an event
+)
+
+// SubscribeOptions describes what a subscriber wants from the bus
+type SubscribeOptions struct {
+	Types        []EventType // the event types wanted, every type if empty
+	Buffer       int         // how many events can be waiting, defaultEventBuffer if 0
+	Backpressure Backpressure
+	After        int64 // if above 0, remembered events with a greater ID are put in the subscription's Missed
+}
+
+// Subscription is a single subscriber to an EventBus
+type Subscription struct {
+	Name    string
+	Missed  []Event // remembered events the subscriber asked for with SubscribeOptions.After
+	types   map[EventType]bool
+	policy  Backpressure
+	events  chan Event
+	done    chan struct{}
+	once    sync.Once
+	dropped int64
+	bus     *EventBus
+}
+
+// EventBus hands published events to its subscribers and remembers the most recent ones
+type EventBus struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []Event
+	latest      map[EventType]Event
+	size        int
+	subscribers map[*Subscription]bool
+}
+
+// NewEventBus creates an EventBus that remembers the last size events
+func NewEventBus(size int) *EventBus {
+	if size <= 0 {
+		size = defaultEventHistory
+	}
+	return &EventBus{size: size, latest: make(map[EventType]Event), subscribers: make(map[*Subscription]bool)}
+}
+
+// Publish sends an event to every subscriber that wants its type, following each subscriber's backpressure policy.
+// Events published from a single goroutine reach each subscriber in order.
+func (bus *EventBus) Publish(eventType EventType, data interface{}) Event {
+	bus.mu.Lock()
+	bus.lastID++
+	event := Event{ID: bus.lastID, Type: eventType, Timestamp: time.Now(), Data: data}
+	bus.history = append(bus.history, event)
+	bus.latest[eventType] = event
+	if len(bus.history) > bus.size {
+		bus.history = bus.history[len(bus.history)-bus.size:]
+	}
+
+	var subscribers []*Subscription
+	for sub := range bus.subscribers {
+		if sub.wants(eventType) {
+			subscribers = append(subscribers, sub)
+		}
+	}
+	bus.mu.Unlock()
+
+	// the lock isn't held while delivering, so a blocked subscriber can still publish events of its own
+	for _, sub := range subscribers {
+		sub.deliver(event)
+	}
+	return event
+}
+
+// Subscribe adds a subscriber to the bus. The subscription must be closed once the subscriber is done with it.
+func (bus *EventBus) Subscribe(name string, options SubscribeOptions) *Subscription {
+	if options.Buffer <= 0 {
+		options.Buffer = defaultEventBuffer
+	}
+	sub := &Subscription{Name: name, types: make(map[EventType]bool), policy: options.Backpressure,
+		events: make(chan Event, options.Buffer), done: make(chan struct{}), bus: bus}
+	for _, eventType := range options.Types {
+		sub.types[eventType] = true
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if options.After > 0 {
+		for _, event := range bus.history {
+			if event.ID > options.After && sub.wants(event.Type) {
+				sub.Missed = append(sub.Missed, event)
+			}
+		}
+	}
+	bus.subscribers[sub] = true
+	return sub
+}
+
+// Handle subscribes handler to the bus, calling it with each event in its own goroutine until the subscription is
+// closed. A handler with the Block policy must not subscribe to events it publishes, or it may wait on itself.
+func (bus *EventBus) Handle(name string, options SubscribeOptions, handler func(Event)) *Subscription {
+	sub := bus.Subscribe(name, options)
+	go func() {
+		for _, event := range sub.Missed {
+			handler(event)
+		}
+		for {
+			select {
+			case event := <-sub.events:
+				handler(event)
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+	return sub
+}
+
+// Latest returns the most recent event of the given type, even if it is no longer in the history
+func (bus *EventBus) Latest(eventType EventType) (Event, bool) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	event, ok := bus.latest[eventType]
+	return event, ok
+}
+
+// Events returns the channel the subscription's events arrive on. It is never closed, so readers should also wait on
+// Done.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Done is closed once the subscription is closed
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Dropped returns how many events the subscriber has missed because its buffer was full
+func (sub *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&sub.dropped)
+}
+
+// Close removes the subscriber from the bus, it is safe to call more than once
+func (sub *Subscription) Close() {
+	sub.once.Do(func() {
+		sub.bus.mu.Lock()
+		delete(sub.bus.subscribers, sub)
+		sub.bus.mu.Unlock()
+		close(sub.done)
+	})
+}
+
+// wants returns true if the subscriber wants events of eventType
+func (sub *Subscription) wants(eventType EventType) bool {
+	return len(sub.types) == 0 || sub.types[eventType]
+}
+
+// deliver hands event to the subscriber following its backpressure policy
+func (sub *Subscription) deliver(event Event) {
+	select {
+	case sub.events <- event:
+		return
+	case <-sub.done:
+		return
+	default:
+	}
+
+	switch sub.policy {
+	case Block:
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+		}
+		return
+	case DropOldest:
+		select {
+		case <-sub.events:
+			atomic.AddInt64(&sub.dropped, 1)
+		default:
+		}
+		select {
+		case sub.events <- event:
+			return
+		default:
+		}
+	}
+	atomic.AddInt64(&sub.dropped, 1)
+}
+
+// Publish adds an event to the bot's event bus, if it has one
+func (bot *Bot) Publish(eventType EventType, data interface{}) {
+	if bot.Events != nil {
+		bot.Events.Publish(eventType, data)
+	}
+}
@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBusHistory(t *testing.T) {
+	bus := NewEventBus(3)
+	bus.Publish(EventMessage, "first")
+	bus.Publish(EventTimer, "second")
+	bus.Publish(EventMessage, "third")
+	bus.Publish(EventModeration, "fourth")
+
+	tests := []struct {
+		description string
+		types       []EventType
+		after       int64
+		wantIDs     []int64
+	}{
+		{
+			description: "should not return history without a last ID",
+			after:       0,
+		},
+		{
+			description: "should return the events after the last ID",
+			after:       2,
+			wantIDs:     []int64{3, 4},
+		},
+		{
+			description: "should only remember as many events as its size",
+			after:       1,
+			wantIDs:     []int64{2, 3, 4},
+		},
+		{
+			description: "should filter by type",
+			types:       []EventType{EventMessage},
+			after:       1,
+			wantIDs:     []int64{3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			sub := bus.Subscribe("test", SubscribeOptions{Types: test.types, After: test.after})
+			defer sub.Close()
+
+			var ids []int64
+			for _, event := range sub.Missed {
+				ids = append(ids, event.ID)
+			}
+			if len(ids) != len(test.wantIDs) {
+				t.Fatalf("did not get the expected events\ngot - %v\nwant - %v", ids, test.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != test.wantIDs[i] {
+					t.Errorf("did not get the expected events\ngot - %v\nwant - %v", ids, test.wantIDs)
+				}
+			}
+		})
+	}
+
+	if latest, ok := bus.Latest(EventTimer); !ok || latest.Data != "second" {
+		t.Errorf("did not get the latest timer event: %+v", latest)
+	}
+}
+
+func TestEventBusBackpressure(t *testing.T) {
+	tests := []struct {
+		description string
+		policy      Backpressure
+		wantData    []int
+		wantDropped int64
+	}{
+		{
+			description: "drop newest should keep the first events",
+			policy:      DropNewest,
+			wantData:    []int{1, 2},
+			wantDropped: 3,
+		},
+		{
+			description: "drop oldest should keep the last events",
+			policy:      DropOldest,
+			wantData:    []int{4, 5},
+			wantDropped: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			bus := NewEventBus(0)
+			sub := bus.Subscribe("test", SubscribeOptions{Buffer: 2, Backpressure: test.policy})
+			defer sub.Close()
+
+			for i := 1; i <= 5; i++ {
+				bus.Publish(EventMessage, i)
+			}
+
+			var got []int
+			for len(sub.Events()) > 0 {
+				got = append(got, (<-sub.Events()).Data.(int))
+			}
+			if len(got) != len(test.wantData) || got[0] != test.wantData[0] || got[1] != test.wantData[1] {
+				t.Errorf("did not get the expected events\ngot - %v\nwant - %v", got, test.wantData)
+			}
+			if sub.Dropped() != test.wantDropped {
+				t.Errorf("did not drop the expected amount of events\ngot - %d\nwant - %d", sub.Dropped(), test.wantDropped)
+			}
+		})
+	}
+}
+
+func TestEventBusBlock(t *testing.T) {
+	bus := NewEventBus(0)
+	sub := bus.Subscribe("test", SubscribeOptions{Types: []EventType{EventMessage}, Buffer: 1, Backpressure: Block})
+
+	bus.Publish(EventMessage, 1)
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(EventMessage, 2)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatalf("publishing to a full blocking subscriber did not wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// other types are not held up by the blocked subscriber
+	bus.Publish(EventTimer, "not wanted")
+
+	if got := (<-sub.Events()).Data; got != 1 {
+		t.Errorf("did not get the expected event\ngot - %v\nwant - 1", got)
+	}
+	<-published
+	if got := (<-sub.Events()).Data; got != 2 {
+		t.Errorf("did not get the expected event\ngot - %v\nwant - 2", got)
+	}
+
+	// closing a subscription lets any waiting publisher go
+	bus.Publish(EventMessage, 3)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		sub.Close()
+	}()
+	bus.Publish(EventMessage, 4)
+	sub.Close()
+	if sub.Dropped() != 0 {
+		t.Errorf("a blocking subscriber should never drop events, dropped %d", sub.Dropped())
+	}
+}
+
+func TestEventBusHandle(t *testing.T) {
+	bus := NewEventBus(0)
+	bus.Publish(EventMessage, "missed")
+
+	received := make(chan interface{}, 10)
+	sub := bus.Handle("test", SubscribeOptions{Types: []EventType{EventMessage}}, func(event Event) {
+		received <- event.Data
+	})
+	bus.Publish(EventTimer, "not wanted")
+	bus.Publish(EventMessage, "wanted")
+
+	select {
+	case got := <-received:
+		if got != "wanted" {
+			t.Errorf("did not get the expected event\ngot - %v\nwant - wanted", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the handler")
+	}
+
+	sub.Close()
+	bus.Publish(EventMessage, "after close")
+	select {
+	case got := <-received:
+		t.Errorf("the handler got an event after its subscription was closed: %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,214 @@
+// filters.go contains the spam filters that are run on every chat message, such as excessive caps or emotes.
+// Each filter reads its own thresholds, exemptions and punishment from the config.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// repeatWindow is how long a user's last message is remembered for the duplicate filter. A message sent again after
+// this long starts a new count, and users who haven't chatted for this long are forgotten.
+const repeatWindow = 10 * time.Minute
+
+const (
+	InfractionCaps          Infraction = "caps"
+	InfractionSymbols       Infraction = "symbols"
+	InfractionEmotes        Infraction = "emotes"
+	InfractionRepeatedChars Infraction = "repeatedchars"
+	InfractionRepeatedWords Infraction = "repeatedwords"
+	InfractionZalgo         Infraction = "zalgo"
+	InfractionDuplicate     Infraction = "duplicate"
+)
+
+// filterOrder is the order the spam filters are checked in
+var filterOrder = []Infraction{InfractionZalgo, InfractionCaps, InfractionSymbols, InfractionEmotes,
+	InfractionRepeatedChars, InfractionRepeatedWords, InfractionDuplicate}
+
+// spamCheck returns true if item breaks filter. Ratio based filters use Threshold as a ratio between 0 and 1, while
+// the rest treat it as a count.
+type spamCheck func(item Item, filter SpamFilter, bot *Bot) bool
+
+var spamChecks = map[Infraction]spamCheck{
+	InfractionCaps:          capsCheck,
+	InfractionSymbols:       symbolsCheck,
+	InfractionEmotes:        emotesCheck,
+	InfractionRepeatedChars: repeatedCharsCheck,
+	InfractionRepeatedWords: repeatedWordsCheck,
+	InfractionZalgo:         zalgoCheck,
+	InfractionDuplicate:     duplicateCheck,
+}
+
+// SpamFilter holds the settings for a single spam filter
+type SpamFilter struct {
+	Name       Infraction `json:"name"`
+	Enabled    bool       `json:"enabled"`
+	Threshold  float64    `json:"threshold"`
+	MinLength  int        `json:"min_length"`  // messages shorter than this are ignored by the ratio based filters
+	ExemptPerm uint8      `json:"exempt_perm"` // users with this permission or higher are not filtered, so all turns it off
+	Punishment Punishment `json:"punishment"`
+	Reason     string     `json:"reason"`
+}
+
+// repeatTracker remembers a user's last message and how many times in a row they have sent it
+type repeatTracker struct {
+	message string
+	count   int
+	sent    time.Time // when the message was last sent
+}
+
+// CheckSpamFilters runs item through every enabled spam filter and returns the first filter that it broke
+func (bot *Bot) CheckSpamFilters(item Item) (bool, SpamFilter) {
+	bot.trackRepeats(item, time.Now())
+
+	for _, filter := range bot.SpamFilters {
+		if !filter.Enabled || item.Sender.Perm >= filter.ExemptPerm {
+			continue
+		}
+		if check, ok := spamChecks[filter.Name]; ok && check(item, filter, bot) {
+			return true, filter
+		}
+	}
+
+	return false, SpamFilter{}
+}
+
+// trackRepeats updates how many times in a row the sender of item has sent the same message, forgetting the users who
+// haven't chatted within repeatWindow of now
+func (bot *Bot) trackRepeats(item Item, now time.Time) {
+	if bot.lastMessages == nil {
+		bot.lastMessages = make(map[string]*repeatTracker)
+	}
+	if now.Sub(bot.repeatsPruned) >= repeatWindow {
+		for name, tracker := range bot.lastMessages {
+			if now.Sub(tracker.sent) >= repeatWindow {
+				delete(bot.lastMessages, name)
+			}
+		}
+		bot.repeatsPruned = now
+	}
+
+	msg := strings.ToLower(strings.TrimSpace(item.Contents))
+	tracker, ok := bot.lastMessages[item.Sender.Name]
+	if ok && tracker.message == msg && now.Sub(tracker.sent) < repeatWindow {
+		tracker.count++
+		tracker.sent = now
+		return
+	}
+	bot.lastMessages[item.Sender.Name] = &repeatTracker{message: msg, count: 1, sent: now}
+}
+
+func capsCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	var letters, upper int
+	for _, r := range item.Contents {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= filter.MinLength && letters > 0 && float64(upper)/float64(letters) >= filter.Threshold
+}
+
+func symbolsCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	var total, symbols int
+	for _, r := range item.Contents {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			symbols++
+		}
+	}
+	return total >= filter.MinLength && total > 0 && float64(symbols)/float64(total) >= filter.Threshold
+}
+
+func emotesCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	return float64(len(item.Emotes)) >= filter.Threshold
+}
+
+func repeatedCharsCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	var run int
+	var last rune
+	for i, r := range []rune(item.Contents) {
+		if i > 0 && r == last && !unicode.IsSpace(r) {
+			run++
+		} else {
+			run = 1
+		}
+		if float64(run) >= filter.Threshold {
+			return true
+		}
+		last = r
+	}
+	return false
+}
+
+func repeatedWordsCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	counts := make(map[string]int)
+	for _, word := range strings.Fields(strings.ToLower(item.Contents)) {
+		counts[word]++
+		if float64(counts[word]) >= filter.Threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func zalgoCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	var marks int
+	for _, r := range item.Contents {
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+			marks++
+		}
+	}
+	return marks > 0 && float64(marks) >= filter.Threshold
+}
+
+func duplicateCheck(item Item, filter SpamFilter, bot *Bot) bool {
+	tracker, ok := bot.lastMessages[item.Sender.Name]
+	return ok && float64(tracker.count) >= filter.Threshold
+}
+
+// loadSpamFilters reads the settings for each spam filter from the config. A filter with no config is left disabled.
+func (bot *Bot) loadSpamFilters() error {
+	bot.SpamFilters = nil
+	for _, name := range filterOrder {
+		key := fmt.Sprintf("Filters.%s", name)
+		if !bot.Config.IsSet(key) {
+			continue
+		}
+
+		filter := SpamFilter{
+			Name:      name,
+			Enabled:   bot.Config.GetBool(key + ".Enabled"),
+			Threshold: bot.Config.GetFloat64(key + ".Threshold"),
+			MinLength: bot.Config.GetInt(key + ".MinLength"),
+			Reason:    bot.Config.GetString(key + ".Reason"),
+		}
+
+		// filters written without an exemption leave moderators alone, as the defaults do
+		exempt := bot.Config.GetString(key + ".Exempt")
+		if exempt == "" {
+			exempt = "moderator"
+		}
+		var err error
+		filter.ExemptPerm, err = bot.ConvertPermToInt(exempt)
+		if err != nil {
+			return FatalError{Err: fmt.Errorf("invalid exemption for the %s filter: %v", name, err)}
+		}
+
+		filter.Punishment, err = ParsePunishment(bot.Config.GetString(key + ".Action"))
+		if err != nil {
+			return FatalError{Err: fmt.Errorf("invalid action for the %s filter: %v", name, err)}
+		}
+
+		bot.SpamFilters = append(bot.SpamFilters, filter)
+	}
+	return nil
+}
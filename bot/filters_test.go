@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestCheckSpamFilters(t *testing.T) {
+	tests := []struct {
+		description string
+		filter      SpamFilter
+		inputItem   Item
+		wantFound   bool
+	}{
+		{
+			description: "should catch a message in all caps",
+			filter:      SpamFilter{Name: InfractionCaps, Enabled: true, Threshold: 0.7, MinLength: 5, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "WHY IS THIS SO LOUD"},
+			wantFound:   true,
+		},
+		{
+			description: "should ignore short messages for caps",
+			filter:      SpamFilter{Name: InfractionCaps, Enabled: true, Threshold: 0.7, MinLength: 5, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "GG"},
+			wantFound:   false,
+		},
+		{
+			description: "should not filter an exempt user",
+			filter:      SpamFilter{Name: InfractionCaps, Enabled: true, Threshold: 0.7, MinLength: 5, ExemptPerm: PermSubscriber},
+			inputItem:   Item{Contents: "WHY IS THIS SO LOUD", Sender: User{Perm: PermSubscriber}},
+			wantFound:   false,
+		},
+		{
+			description: "should not run a disabled filter",
+			filter:      SpamFilter{Name: InfractionCaps, Enabled: false, Threshold: 0.7, MinLength: 5, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "WHY IS THIS SO LOUD"},
+			wantFound:   false,
+		},
+		{
+			description: "should catch symbol spam",
+			filter:      SpamFilter{Name: InfractionSymbols, Enabled: true, Threshold: 0.5, MinLength: 5, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "hi ~~~~~~%%%%%%@@@@"},
+			wantFound:   true,
+		},
+		{
+			description: "should catch too many emotes",
+			filter:      SpamFilter{Name: InfractionEmotes, Enabled: true, Threshold: 3, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "Kappa Kappa Kappa", Emotes: []string{"Kappa", "Kappa", "Kappa"}},
+			wantFound:   true,
+		},
+		{
+			description: "should catch a long run of the same character",
+			filter:      SpamFilter{Name: InfractionRepeatedChars, Enabled: true, Threshold: 6, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "nooooooooo"},
+			wantFound:   true,
+		},
+		{
+			description: "should allow a short run of the same character",
+			filter:      SpamFilter{Name: InfractionRepeatedChars, Enabled: true, Threshold: 6, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "noooo"},
+			wantFound:   false,
+		},
+		{
+			description: "should catch a repeated word",
+			filter:      SpamFilter{Name: InfractionRepeatedWords, Enabled: true, Threshold: 4, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "spam Spam spam SPAM"},
+			wantFound:   true,
+		},
+		{
+			description: "should catch zalgo text",
+			filter:      SpamFilter{Name: InfractionZalgo, Enabled: true, Threshold: 3, ExemptPerm: PermModerator},
+			inputItem:   Item{Contents: "h́̂̃̄i"},
+			wantFound:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			bot := &Bot{SpamFilters: []SpamFilter{test.filter}}
+			found, filter := bot.CheckSpamFilters(test.inputItem)
+			if found != test.wantFound {
+				t.Errorf("did not get the expected result\ngot - %v\nwant - %v", found, test.wantFound)
+			}
+			if found && filter.Name != test.filter.Name {
+				t.Errorf("did not get the expected filter\ngot - %v\nwant - %v", filter.Name, test.filter.Name)
+			}
+		})
+	}
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	bot := &Bot{SpamFilters: []SpamFilter{{Name: InfractionDuplicate, Enabled: true, Threshold: 3, ExemptPerm: PermModerator}}}
+	item := Item{Sender: User{Name: "viewer"}, Contents: "buy followers"}
+
+	for i := 1; i <= 3; i++ {
+		found, _ := bot.CheckSpamFilters(item)
+		if found != (i == 3) {
+			t.Errorf("message %d: got found - %v", i, found)
+		}
+	}
+
+	// a different message should reset the count
+	bot.CheckSpamFilters(Item{Sender: User{Name: "viewer"}, Contents: "hello"})
+	if found, _ := bot.CheckSpamFilters(item); found {
+		t.Errorf("the duplicate count was not reset by a different message")
+	}
+}
+
+func TestRepeatWindow(t *testing.T) {
+	bot := &Bot{}
+	start := time.Now()
+	item := Item{Sender: User{Name: "viewer"}, Contents: "buy followers"}
+
+	bot.trackRepeats(item, start)
+	bot.trackRepeats(item, start.Add(time.Minute))
+	if count := bot.lastMessages["viewer"].count; count != 2 {
+		t.Errorf("did not count the repeat\ngot - %v\nwant - %v", count, 2)
+	}
+
+	// the same message sent after the window starts a new count
+	bot.trackRepeats(item, start.Add(time.Minute+repeatWindow))
+	if count := bot.lastMessages["viewer"].count; count != 1 {
+		t.Errorf("did not start a new count after the window\ngot - %v\nwant - %v", count, 1)
+	}
+
+	// users who stopped chatting are forgotten
+	bot.trackRepeats(Item{Sender: User{Name: "other"}, Contents: "hi"}, start.Add(time.Minute+3*repeatWindow))
+	if _, ok := bot.lastMessages["viewer"]; ok || len(bot.lastMessages) != 1 {
+		t.Errorf("did not forget the users who stopped chatting\ngot - %v", bot.lastMessages)
+	}
+}
+
+func TestLoadSpamFilters(t *testing.T) {
+	config := viper.New()
+	config.Set("Filters.caps", map[string]interface{}{"Enabled": true, "Threshold": 0.7, "Action": "delete"})
+	config.Set("Filters.zalgo", map[string]interface{}{"Enabled": true, "Threshold": 5, "Exempt": "all", "Action": "purge"})
+	bot := &Bot{Config: config}
+	if err := bot.loadSpamFilters(); err != nil {
+		t.Fatalf("could not load the spam filters: %v", err)
+	}
+
+	want := map[Infraction]uint8{InfractionCaps: PermModerator, InfractionZalgo: PermAll}
+	if len(bot.SpamFilters) != len(want) {
+		t.Fatalf("did not get the expected filters\ngot - %v\nwant - %v", bot.SpamFilters, want)
+	}
+	for _, filter := range bot.SpamFilters {
+		if filter.ExemptPerm != want[filter.Name] {
+			t.Errorf("did not get the expected exemption for the %s filter\ngot - %v\nwant - %v", filter.Name,
+				filter.ExemptPerm, want[filter.Name])
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// giveaways.go handles giveaways. Viewers enter by typing the giveaway's keyword in chat, optionally paying points to
+// do so, and winners are drawn with crypto/rand weighted by each entry's tickets. Every giveaway, entry and winner is
+// stored so a giveaway can be audited afterwards.
+
+package bot
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxGiveawayTickets is the most tickets a viewer can buy when entering a giveaway that costs points
+const MaxGiveawayTickets = 10
+
+// statuses of a giveaway winner
+const (
+	WinnerDrawn     = "drawn"     // the winner hasn't claimed the prize yet, or doesn't need to
+	WinnerClaimed   = "claimed"   // the winner spoke in chat in time
+	WinnerForfeited = "forfeited" // the winner didn't speak in chat in time
+	WinnerRerolled  = "rerolled"  // a moderator drew someone else in their place
+)
+
+var (
+	errNoGiveaway      = errors.New("there is no giveaway running")
+	errGiveawayRunning = errors.New("a giveaway is already running, end it with !giveaway end")
+	errNoEntrants      = errors.New("there is nobody left to draw")
+)
+
+// Giveaway is a single giveaway
+type Giveaway struct {
+	ID        int64          `json:"id"`
+	Keyword   string         `json:"keyword"`  // what viewers type to enter
+	Cost      int64          `json:"cost"`     // points it costs to enter
+	SubLuck   int            `json:"sub_luck"` // tickets a subscriber's entry gets, everyone else gets one
+	StartedBy string         `json:"started_by"`
+	Started   time.Time      `json:"started"`
+	Open      bool           `json:"open"`       // whether viewers can still enter, entries close once a winner is drawn
+	Entries   map[string]int `json:"entries"`    // each entrant's tickets
+	Winners   []Winner       `json:"winners"`    // everyone drawn, in order
+	ClaimTime time.Duration  `json:"claim_time"` // how long a winner has to speak in chat, no limit if 0
+}
+
+// Winner is someone drawn in a giveaway
+type Winner struct {
+	User   string    `json:"user"`
+	Status string    `json:"status"` // one of the Winner constants
+	Drawn  time.Time `json:"drawn"`
+}
+
+// StartGiveaway starts a giveaway entered by typing keyword. subLuck is how many tickets a subscriber's entry gets.
+func (bot *Bot) StartGiveaway(keyword string, cost int64, subLuck int, startedBy string) (*Giveaway, error) {
+	if bot.Giveaway != nil {
+		return nil, NonFatalError{Err: errGiveawayRunning}
+	}
+	if keyword == "" || cost < 0 {
+		return nil, NonFatalError{Err: fmt.Errorf("usage: !giveaway start <keyword> [cost] [sub luck]")}
+	}
+	if subLuck < 1 {
+		subLuck = 1
+	}
+
+	giveaway := &Giveaway{Keyword: strings.ToLower(keyword), Cost: cost, SubLuck: subLuck, StartedBy: startedBy,
+		Started: time.Now(), Open: true, Entries: make(map[string]int), ClaimTime: bot.GiveawayClaim}
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec("insert into giveaways (keyword, cost, sub_luck, started_by, started) values (?, ?, ?, ?, ?)",
+			giveaway.Keyword, cost, subLuck, startedBy, giveaway.Started.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		giveaway.ID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	bot.Giveaway = giveaway
+	return giveaway, nil
+}
+
+// LoadGiveaway picks up the giveaway that was running when the bot last stopped, along with its entries and winners,
+// so it can still be drawn and ended. Entries are closed if a winner had been drawn.
+func (bot *Bot) LoadGiveaway() error {
+	bot.Giveaway = nil
+	rows, err := bot.Storage.DB.Query(`select id, keyword, cost, sub_luck, started_by, started from giveaways
+		where ended is null or ended = '' order by id desc limit 1`)
+	if err != nil {
+		return err
+	}
+	var giveaway *Giveaway
+	for rows.Next() {
+		var started string
+		giveaway = &Giveaway{Open: true, Entries: make(map[string]int), ClaimTime: bot.GiveawayClaim}
+		err = rows.Scan(&giveaway.ID, &giveaway.Keyword, &giveaway.Cost, &giveaway.SubLuck, &giveaway.StartedBy, &started)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		giveaway.Started, _ = time.Parse(time.RFC3339, started)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || giveaway == nil {
+		return err
+	}
+
+	rows, err = bot.Storage.DB.Query("select user, tickets from giveaway_entries where giveaway_id = ?", giveaway.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var user string
+		var tickets int
+		if err = rows.Scan(&user, &tickets); err != nil {
+			rows.Close()
+			return err
+		}
+		giveaway.Entries[user] = tickets
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = bot.Storage.DB.Query("select user, status, timestamp from giveaway_winners where giveaway_id = ? order by rowid",
+		giveaway.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var winner Winner
+		var drawn string
+		if err = rows.Scan(&winner.User, &winner.Status, &drawn); err != nil {
+			return err
+		}
+		winner.Drawn, _ = time.Parse(time.RFC3339, drawn)
+		giveaway.Winners = append(giveaway.Winners, winner)
+		giveaway.Open = false
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	bot.Giveaway = giveaway
+	return nil
+}
+
+// EnterGiveaway enters the sender of item into the running giveaway if their message is its keyword. If the giveaway
+// costs points, viewers can buy up to MaxGiveawayTickets tickets by following the keyword with how many they want, e.g.
+// '!enter 3', and pay the cost for each. entered is false if they did not enter.
+func (bot *Bot) EnterGiveaway(item Item) (entered bool, err error) {
+	giveaway := bot.Giveaway
+	if giveaway == nil || !giveaway.Open || item.Sender.Name == "" {
+		return false, nil
+	}
+	words := strings.Fields(item.Text())
+	if len(words) == 0 || len(words) > 2 || !strings.EqualFold(words[0], giveaway.Keyword) {
+		return false, nil
+	}
+	if _, ok := giveaway.Entries[item.Sender.Name]; ok {
+		return false, nil
+	}
+
+	bought := 1
+	if len(words) == 2 {
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 1 {
+			return false, nil
+		}
+		if giveaway.Cost > 0 {
+			bought = n
+		}
+		if bought > MaxGiveawayTickets {
+			bought = MaxGiveawayTickets
+		}
+	}
+	tickets := bought
+	if item.Sender.Perm >= PermSubscriber {
+		tickets *= giveaway.SubLuck
+	}
+	err = bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		if giveaway.Cost > 0 {
+			if _, err := addPoints(tx, item.Sender.Name, -giveaway.Cost*int64(bought), 0); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("insert into giveaway_entries (giveaway_id, user, tickets, timestamp) values (?, ?, ?, ?)",
+			giveaway.ID, item.Sender.Name, tickets, time.Now().Format(time.RFC3339))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	giveaway.Entries[item.Sender.Name] = tickets
+	return true, nil
+}
+
+// DrawGiveaway closes entries to the running giveaway and draws a winner from those who haven't been drawn yet. The
+// last winner is marked as rerolled if they hadn't claimed the prize.
+func (bot *Bot) DrawGiveaway() (Winner, error) {
+	giveaway := bot.Giveaway
+	if giveaway == nil {
+		return Winner{}, NonFatalError{Err: errNoGiveaway}
+	}
+	giveaway.Open = false
+
+	if last := len(giveaway.Winners) - 1; last >= 0 && giveaway.Winners[last].Status == WinnerDrawn {
+		if err := bot.setWinnerStatus(last, WinnerRerolled); err != nil {
+			return Winner{}, err
+		}
+	}
+
+	user, err := drawWinner(giveaway)
+	if err != nil {
+		return Winner{}, err
+	}
+	winner := Winner{User: user, Status: WinnerDrawn, Drawn: time.Now()}
+
+	err = bot.Storage.DB.Insert("giveaway_winners", []string{"giveaway_id", "user", "status", "timestamp"},
+		[]string{fmt.Sprint(giveaway.ID), winner.User, winner.Status, winner.Drawn.Format(time.RFC3339)})
+	if err != nil {
+		return Winner{}, err
+	}
+	giveaway.Winners = append(giveaway.Winners, winner)
+	return winner, nil
+}
+
+// drawWinner picks an entrant that hasn't been drawn yet, weighted by their tickets
+func drawWinner(giveaway *Giveaway) (string, error) {
+	drawn := make(map[string]bool)
+	for _, winner := range giveaway.Winners {
+		drawn[winner.User] = true
+	}
+	var users []string
+	var total int64
+	for user, tickets := range giveaway.Entries {
+		if !drawn[user] {
+			users = append(users, user)
+			total += int64(tickets)
+		}
+	}
+	if len(users) == 0 {
+		return "", NonFatalError{Err: errNoEntrants}
+	}
+	sort.Strings(users) // map order is random, but the draw should only depend on crypto/rand
+
+	n, err := rand.Int(rand.Reader, big.NewInt(total))
+	if err != nil {
+		return "", err
+	}
+	pick := n.Int64()
+	for _, user := range users {
+		pick -= int64(giveaway.Entries[user])
+		if pick < 0 {
+			return user, nil
+		}
+	}
+	return "", errNoEntrants
+}
+
+// ClaimGiveaway marks the latest winner of the running giveaway as having claimed their prize if they are user and
+// the giveaway needs winners to claim it, returning true if they did
+func (bot *Bot) ClaimGiveaway(user string) (bool, error) {
+	giveaway := bot.Giveaway
+	if giveaway == nil || giveaway.ClaimTime <= 0 || len(giveaway.Winners) == 0 {
+		return false, nil
+	}
+	last := len(giveaway.Winners) - 1
+	winner := giveaway.Winners[last]
+	if winner.User != user || winner.Status != WinnerDrawn || time.Since(winner.Drawn) > giveaway.ClaimTime {
+		return false, nil
+	}
+	return true, bot.setWinnerStatus(last, WinnerClaimed)
+}
+
+// ForfeitGiveaway marks the latest winner of the running giveaway as having forfeited if they are user and haven't
+// claimed their prize, returning true if they had not
+func (bot *Bot) ForfeitGiveaway(user string) (bool, error) {
+	giveaway := bot.Giveaway
+	if giveaway == nil || len(giveaway.Winners) == 0 {
+		return false, nil
+	}
+	last := len(giveaway.Winners) - 1
+	if giveaway.Winners[last].User != user || giveaway.Winners[last].Status != WinnerDrawn {
+		return false, nil
+	}
+	return true, bot.setWinnerStatus(last, WinnerForfeited)
+}
+
+// EndGiveaway ends the running giveaway
+func (bot *Bot) EndGiveaway() (*Giveaway, error) {
+	giveaway := bot.Giveaway
+	if giveaway == nil {
+		return nil, NonFatalError{Err: errNoGiveaway}
+	}
+	err := bot.Storage.DB.Update("giveaways", "id", fmt.Sprint(giveaway.ID), []string{"ended"},
+		[]string{time.Now().Format(time.RFC3339)})
+	if err != nil {
+		return nil, err
+	}
+	bot.Giveaway = nil
+	return giveaway, nil
+}
+
+// setWinnerStatus changes the status of the running giveaway's i'th winner
+func (bot *Bot) setWinnerStatus(i int, status string) error {
+	giveaway := bot.Giveaway
+	winner := &giveaway.Winners[i]
+	err := bot.Storage.DB.ArbitraryExec("update giveaway_winners set status = ? where giveaway_id = ? and user = ?",
+		status, giveaway.ID, winner.User)
+	if err != nil {
+		return err
+	}
+	winner.Status = status
+	return nil
+}
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGiveaway(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, GiveawayClaim: time.Minute}
+	bot.SetPoints("viewer", 100)
+	bot.SetPoints("sub", 100)
+
+	if _, err := bot.StartGiveaway("!enter", 50, 3, "mod"); err != nil {
+		t.Fatalf("could not start the giveaway: %v", err)
+	}
+	if _, err := bot.StartGiveaway("!other", 0, 1, "mod"); err == nil {
+		t.Errorf("started a second giveaway while one was running")
+	}
+
+	tests := []struct {
+		description string
+		inputItem   Item
+		wantEntered bool
+		wantErr     error
+	}{
+		{description: "should enter a viewer with the keyword", inputItem: Item{Type: "!enter", Sender: User{Name: "viewer"}}, wantEntered: true},
+		{description: "should not enter a viewer twice", inputItem: Item{Type: "!enter", Sender: User{Name: "viewer"}}},
+		{description: "should ignore other messages", inputItem: Item{Contents: "hello", Sender: User{Name: "sub"}}},
+		{description: "should enter a subscriber", inputItem: Item{Type: "!enter", Sender: User{Name: "sub", Perm: PermSubscriber}}, wantEntered: true},
+		{description: "should not enter a viewer without enough points", inputItem: Item{Type: "!enter", Sender: User{Name: "broke"}},
+			wantErr: ErrNotEnoughPoints},
+	}
+	for _, test := range tests {
+		entered, err := bot.EnterGiveaway(test.inputItem)
+		if entered != test.wantEntered || !errors.Is(err, test.wantErr) {
+			t.Errorf("%s\ndid not get the expected result\ngot - %v, %v\nwant - %v, %v", test.description, entered, err,
+				test.wantEntered, test.wantErr)
+		}
+	}
+	if want := map[string]int{"viewer": 1, "sub": 3}; len(bot.Giveaway.Entries) != 2 || bot.Giveaway.Entries["sub"] != want["sub"] {
+		t.Errorf("did not get the expected entries\ngot - %v\nwant - %v", bot.Giveaway.Entries, want)
+	}
+	if balance, _ := bot.GetBalance("viewer"); balance.Points != 50 {
+		t.Errorf("did not take the cost of entering\ngot - %v\nwant - %v", balance.Points, 50)
+	}
+
+	first, err := bot.DrawGiveaway()
+	if err != nil {
+		t.Fatalf("could not draw a winner: %v", err)
+	}
+	if entered, _ := bot.EnterGiveaway(Item{Type: "!enter", Sender: User{Name: "late"}}); entered {
+		t.Errorf("entered the giveaway after a winner was drawn")
+	}
+
+	// a reroll draws the other entrant and marks the first winner as rerolled
+	second, err := bot.DrawGiveaway()
+	if err != nil || second.User == first.User {
+		t.Fatalf("did not draw a different winner: %v, %v", first, second)
+	}
+	if bot.Giveaway.Winners[0].Status != WinnerRerolled {
+		t.Errorf("did not get the expected status for the first winner\ngot - %v\nwant - %v", bot.Giveaway.Winners[0].Status, WinnerRerolled)
+	}
+	if claimed, _ := bot.ClaimGiveaway(first.User); claimed {
+		t.Errorf("a rerolled winner claimed the giveaway")
+	}
+	if claimed, err := bot.ClaimGiveaway(second.User); !claimed || err != nil {
+		t.Errorf("the winner could not claim the giveaway: %v", err)
+	}
+	if forfeited, _ := bot.ForfeitGiveaway(second.User); forfeited {
+		t.Errorf("a winner that claimed the giveaway forfeited it")
+	}
+	if _, err = bot.DrawGiveaway(); err == nil {
+		t.Errorf("drew a winner with nobody left to draw")
+	}
+
+	if _, err = bot.EndGiveaway(); err != nil || bot.Giveaway != nil {
+		t.Fatalf("could not end the giveaway: %v", err)
+	}
+	rows, err := database.DB.Query("select user, status from giveaway_winners order by rowid")
+	if err != nil {
+		t.Fatalf("could not read the stored winners: %v", err)
+	}
+	defer rows.Close()
+	var statuses []string
+	for rows.Next() {
+		var user, status string
+		rows.Scan(&user, &status)
+		statuses = append(statuses, status)
+	}
+	if len(statuses) != 2 || statuses[0] != WinnerRerolled || statuses[1] != WinnerClaimed {
+		t.Errorf("did not store the expected winners\ngot - %v\nwant - %v", statuses, []string{WinnerRerolled, WinnerClaimed})
+	}
+}
+
+func TestGiveawayTickets(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database}
+	for _, user := range []string{"viewer", "sub", "whale", "free"} {
+		bot.SetPoints(user, 1000)
+	}
+	if _, err := bot.StartGiveaway("win", 50, 2, "mod"); err != nil {
+		t.Fatalf("could not start the giveaway: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		inputItem   Item
+		wantEntered bool
+		wantTickets int
+		wantPoints  int64
+	}{
+		{description: "should buy several tickets", inputItem: Item{Contents: "win 3", Sender: User{Name: "viewer"}},
+			wantEntered: true, wantTickets: 3, wantPoints: 850},
+		{description: "should give a subscriber's tickets their luck", inputItem: Item{Contents: "WIN 2", Sender: User{Name: "sub", Perm: PermSubscriber}},
+			wantEntered: true, wantTickets: 4, wantPoints: 900},
+		{description: "should cap the tickets bought", inputItem: Item{Contents: "win 50", Sender: User{Name: "whale"}},
+			wantEntered: true, wantTickets: MaxGiveawayTickets, wantPoints: 1000 - 50*MaxGiveawayTickets},
+		{description: "should ignore anything but a number of tickets", inputItem: Item{Contents: "win everything", Sender: User{Name: "free"}},
+			wantPoints: 1000},
+		{description: "should ignore longer messages", inputItem: Item{Contents: "win 2 please", Sender: User{Name: "free"}},
+			wantPoints: 1000},
+	}
+	for _, test := range tests {
+		entered, err := bot.EnterGiveaway(test.inputItem)
+		if entered != test.wantEntered || err != nil {
+			t.Errorf("%s\ndid not get the expected result\ngot - %v, %v\nwant - %v", test.description, entered, err, test.wantEntered)
+		}
+		user := test.inputItem.Sender.Name
+		if tickets := bot.Giveaway.Entries[user]; tickets != test.wantTickets {
+			t.Errorf("%s\ndid not get the expected tickets\ngot - %v\nwant - %v", test.description, tickets, test.wantTickets)
+		}
+		if balance, _ := bot.GetBalance(user); balance.Points != test.wantPoints {
+			t.Errorf("%s\ndid not get the expected points\ngot - %v\nwant - %v", test.description, balance.Points, test.wantPoints)
+		}
+	}
+
+	// a giveaway that is free to enter gives one ticket however many are asked for
+	bot.Giveaway = nil
+	if _, err := bot.StartGiveaway("win", 0, 1, "mod"); err != nil {
+		t.Fatalf("could not start the giveaway: %v", err)
+	}
+	if entered, err := bot.EnterGiveaway(Item{Contents: "win 5", Sender: User{Name: "free"}}); !entered || err != nil {
+		t.Fatalf("could not enter the free giveaway: %v", err)
+	}
+	if tickets := bot.Giveaway.Entries["free"]; tickets != 1 {
+		t.Errorf("did not get the expected tickets in a free giveaway\ngot - %v\nwant - %v", tickets, 1)
+	}
+}
+
+func TestDrawIsWeighted(t *testing.T) {
+	giveaway := &Giveaway{Entries: map[string]int{"lucky": 99, "unlucky": 1}}
+	wins := 0
+	for i := 0; i < 200; i++ {
+		winner, err := drawWinner(giveaway)
+		if err != nil {
+			t.Fatalf("could not draw a winner: %v", err)
+		}
+		if winner == "lucky" {
+			wins++
+		}
+	}
+	if wins < 170 {
+		t.Errorf("the entry with more tickets did not win often enough\ngot - %v of 200", wins)
+	}
+}
+
+func TestLoadGiveaway(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, GiveawayClaim: time.Minute}
+	bot.SetPoints("viewer", 100)
+
+	bot.StartGiveaway("!enter", 10, 2, "mod")
+	bot.EnterGiveaway(Item{Type: "!enter", Command: "3", Sender: User{Name: "viewer"}})
+	bot.EnterGiveaway(Item{Type: "!enter", Sender: User{Name: "sub", Perm: PermSubscriber}})
+
+	// a restart only has what was stored
+	want := bot.Giveaway
+	if err := bot.LoadGiveaway(); err != nil {
+		t.Fatalf("could not load the giveaway: %v", err)
+	}
+	got := bot.Giveaway
+	if got == nil || got.ID != want.ID || got.Keyword != want.Keyword || got.Cost != want.Cost || got.SubLuck != want.SubLuck ||
+		!got.Open || got.ClaimTime != time.Minute || !reflect.DeepEqual(got.Entries, want.Entries) {
+		t.Fatalf("did not load the expected giveaway\ngot - %+v\nwant - %+v", got, want)
+	}
+
+	winner, err := bot.DrawGiveaway()
+	if err != nil {
+		t.Fatalf("could not draw the loaded giveaway: %v", err)
+	}
+	if err = bot.LoadGiveaway(); err != nil || bot.Giveaway == nil {
+		t.Fatalf("could not load the giveaway: %v", err)
+	}
+	if got := bot.Giveaway; got.Open || len(got.Winners) != 1 || got.Winners[0].User != winner.User || got.Winners[0].Status != WinnerDrawn {
+		t.Errorf("did not load the giveaway's winner\ngot - %+v\nwant - %+v", got, winner)
+	}
+
+	// an ended giveaway isn't loaded again
+	bot.EndGiveaway()
+	if err = bot.LoadGiveaway(); err != nil || bot.Giveaway != nil {
+		t.Errorf("loaded a giveaway that had ended\ngot - %+v %v", bot.Giveaway, err)
+	}
+}
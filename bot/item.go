@@ -3,19 +3,51 @@
 
 package bot
 
+import "strings"
+
+// permission levels a user can have, these line up with the values returned by ConvertPermToInt
+const (
+	PermAll uint8 = iota
+	PermSubscriber
+	PermModerator
+	PermBroadcaster
+)
+
 // Item represents a new key / value item for the bot such as a command.
 // e.g. a new item request would have a structure such as: !addcom !command <contents>
 // This struct will hold the !command and <contents> values respectively.
 // Each field will hold the value with any leading ! chars removed.
 type Item struct {
-	IsServerInfo bool // if true, consider item not from user and can be ignored
-	Sender       User
-	Type         string // ex: com
-	Command      string // ex: add
-	Key          string // ex: !somecommand
-	Contents     string // ex: this is the value of some command
+	IsServerInfo bool     `json:"is_server_info"` // if true, consider item not from user and can be ignored
+	ID           string   `json:"id"`             // the service's ID for this message, needed to delete a single message
+	Sender       User     `json:"sender"`
+	Type         string   `json:"type"`     // ex: com
+	Command      string   `json:"command"`  // ex: add
+	Key          string   `json:"key"`      // ex: !somecommand
+	Contents     string   `json:"contents"` // ex: this is the value of some command
+	Emotes       []string `json:"emotes"`   // name of every emote used in the message, once per use
+	// FirstMessage is set when the sender has never chatted in the channel before, from Twitch's first-msg tag
+	FirstMessage bool `json:"first_message"`
 }
 
 type User struct {
-	Name string
+	ID   string `json:"id"` // the service's ID for the user, which stays the same through name changes
+	Name string `json:"name"`
+	Perm uint8  `json:"perm"` // highest permission level the user has, e.g. PermModerator
+}
+
+// IsModerator returns true if the user is at least a moderator
+func (u User) IsModerator() bool {
+	return u.Perm >= PermModerator
+}
+
+// Text puts a command's parts back together to get the message as it was sent, split on single spaces
+func (item Item) Text() string {
+	var parts []string
+	for _, part := range []string{item.Type, item.Command, item.Key, item.Contents} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,163 @@
+// links.go handles detecting links in chat and deciding whether the sender is allowed to post them
+
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// matches a link with or without a scheme. The groups are the scheme, the domain, its top-level domain and anything
+	// after the domain.
+	urlRegex = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://)?((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+([a-z]{2,24}))\b([/?#:][^\s]*)?`)
+	// matches links that try to dodge the filter with a bracketed dot, such as 'example(.)com' or 'example [dot] me'
+	bracketedDotRegex = regexp.MustCompile(`(?i)\s*[\(\[\{]\s*(?:\.|dot)\s*[\)\]\}]\s*(com|net|org|tv|gg|io|co|me|ly|xyz|ru|info|biz|link|live)\b`)
+	// matches links written with the word dot, such as 'example dot com'. Top-level domains that are also English words
+	// are left out, so 'polka dot me' isn't a link.
+	spelledDotRegex = regexp.MustCompile(`(?i)\s+dot\s+(com|net|org|tv|gg|io|ly|xyz|ru|info|biz)\b`)
+
+	// linkTLDs are the top-level domains a link without a scheme, www. or path is recognised by. Ones that are common
+	// words, such as .it, .me or .so, are left out so a missing space after a full stop isn't taken for a link.
+	linkTLDs = makeSet(strings.Fields(`com net org edu gov mil int info biz xyz io gg tv co ly ru su ua cn jp kr tw uk eu
+		de fr nl es pl se fi dk ch cz gr hu ro pt br ar cl mx ca au nz za ws cc tk ml ga cf gq app dev link live site
+		online shop store club top pro gay lgbt stream tech cloud space website page`))
+)
+
+// LinkVerdict is the result of checking a message for links
+type LinkVerdict int
+
+const (
+	LinkNone         LinkVerdict = iota // the message has no links
+	LinkAllowed                         // the message has links the sender is allowed to post
+	LinkNotPermitted                    // the sender is not allowed to post a link in the message
+	LinkDenied                          // the message has a link to a denied domain
+)
+
+// LinkPermit allows a user to post links. A permit with no expiry is used up by the next link the user posts.
+type LinkPermit struct {
+	Expires time.Time `json:"expires"`
+}
+
+// Expired returns true if the permit was time limited and that time has passed
+func (lp LinkPermit) Expired() bool {
+	return !lp.Expires.IsZero() && time.Now().After(lp.Expires)
+}
+
+// DetectURl uses urlRegex to determine if the passed in message is a URL. The caller than perform whatever
+// action is desired with this information
+func (bot *Bot) DetectURL(message string) bool {
+	return len(bot.findDomains(message)) > 0
+}
+
+// FindDomains returns the domain of every link in message, including links written as 'example dot com'. A link needs a
+// scheme, www., a path or one of linkTLDs, and a top-level domain written like the start of a sentence, as in
+// 'sentence.Next', only counts with one of the first three.
+func FindDomains(message string) []string {
+	return findDomains(message, nil)
+}
+
+// findDomains returns the domains of the links in message as FindDomains does, but also counts any domain listed in the
+// bot's allowed or denied domains, whatever its top-level domain, so a denied domain can't be posted bare to dodge them
+func (bot *Bot) findDomains(message string) []string {
+	return findDomains(message, func(domain string) bool {
+		return matchesDomain(domain, bot.DeniedDomains) || matchesDomain(domain, bot.AllowedDomains)
+	})
+}
+
+// findDomains returns the domain of every link in message, a domain that listed returns true for is always a link
+func findDomains(message string, listed func(domain string) bool) []string {
+	// the '/' added after an obfuscated link marks it as one, whatever its top-level domain
+	message = bracketedDotRegex.ReplaceAllString(message, ".$1/")
+	message = spelledDotRegex.ReplaceAllString(message, ".$1/")
+
+	var domains []string
+	for _, match := range urlRegex.FindAllStringSubmatch(message, -1) {
+		scheme, domain, tld, rest := match[1], strings.ToLower(match[2]), match[3], match[4]
+		sentence := tld != strings.ToLower(tld) && tld != strings.ToUpper(tld)
+		if scheme == "" && !strings.HasPrefix(domain, "www.") && !strings.HasPrefix(rest, "/") &&
+			(sentence || !linkTLDs[strings.ToLower(tld)]) && (listed == nil || !listed(domain)) {
+			continue
+		}
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+// CheckLinks decides whether the sender of item may post the links in it. Denied domains are checked first and apply to
+// everyone, then allowed domains, the sender's permission level, and lastly any permit given with AddPermittedUser.
+// A one-time permit is used up when it lets a link through.
+func (bot *Bot) CheckLinks(item Item) LinkVerdict {
+	domains := bot.findDomains(item.Contents)
+	if len(domains) == 0 {
+		return LinkNone
+	}
+
+	allAllowed := true
+	for _, domain := range domains {
+		if matchesDomain(domain, bot.DeniedDomains) {
+			return LinkDenied
+		}
+		if !matchesDomain(domain, bot.AllowedDomains) {
+			allAllowed = false
+		}
+	}
+
+	if allAllowed || item.Sender.Perm >= bot.PostLinkPerm {
+		return LinkAllowed
+	}
+
+	permit, ok := bot.PermittedUsers[item.Sender.Name]
+	if !ok || permit.Expired() {
+		bot.DeletePermittedUser(item.Sender.Name)
+		return LinkNotPermitted
+	}
+	if permit.Expires.IsZero() {
+		bot.DeletePermittedUser(item.Sender.Name)
+	}
+	return LinkAllowed
+}
+
+// matchesDomain returns true if domain is one of domains, or a subdomain of one
+func matchesDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPermittedUser lets a user post links without being purged. A duration of 0 gives a one-time pass for their next
+// link, otherwise they can post links until the duration is up.
+func (bot *Bot) AddPermittedUser(username string, duration time.Duration) {
+	if bot.PermittedUsers == nil {
+		bot.PermittedUsers = make(map[string]LinkPermit)
+	}
+
+	var permit LinkPermit
+	if duration > 0 {
+		permit.Expires = time.Now().Add(duration)
+	}
+	bot.PermittedUsers[username] = permit
+}
+
+// DeletePermittedUser deletes a user from the permittedusers map if they exist in it
+func (bot *Bot) DeletePermittedUser(username string) bool {
+	var exists bool
+	if _, exists = bot.PermittedUsers[username]; exists {
+		delete(bot.PermittedUsers, username)
+	}
+	return exists
+}
+
+// makeSet returns a set of the values, for looking them up
+func makeSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
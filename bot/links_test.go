@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindDomains(t *testing.T) {
+	tests := []struct {
+		description string
+		message     string
+		wantDomains []string
+	}{
+		{
+			description: "should find a full link",
+			message:     "check out https://www.Example.com/page?id=1",
+			wantDomains: []string{"www.example.com"},
+		},
+		{
+			description: "should find a link without a scheme",
+			message:     "go to clips.twitch.tv/SomeClip now",
+			wantDomains: []string{"clips.twitch.tv"},
+		},
+		{
+			description: "should find a link written with dot",
+			message:     "visit example dot com for free stuff",
+			wantDomains: []string{"example.com"},
+		},
+		{
+			description: "should find a link written with a bracketed dot",
+			message:     "visit example(.)com or example [dot] net",
+			wantDomains: []string{"example.com", "example.net"},
+		},
+		{
+			description: "should not treat normal punctuation as a link",
+			message:     "e.g. this is fine... right? version 1.5",
+			wantDomains: nil,
+		},
+		{
+			description: "should find a link containing digits",
+			message:     "my site is site1.io",
+			wantDomains: []string{"site1.io"},
+		},
+		{
+			description: "should not treat a missing space after a full stop as a link",
+			message:     "that was the end of the sentence.Next one starts here",
+			wantDomains: nil,
+		},
+		{
+			description: "should not treat words joined by a dot as a link",
+			message:     "lol.ok sure",
+			wantDomains: nil,
+		},
+		{
+			description: "should not treat the word dot as a link when the domain is a word",
+			message:     "she wore a polka dot me thinks",
+			wantDomains: nil,
+		},
+		{
+			description: "should find a link with an unknown top-level domain when it has a path",
+			message:     "join t.me/somechannel",
+			wantDomains: []string{"t.me"},
+		},
+		{
+			description: "should find a link with an unknown top-level domain when it has www.",
+			message:     "see www.example.lol",
+			wantDomains: []string{"www.example.lol"},
+		},
+		{
+			description: "should find a bracketed link whatever its top-level domain",
+			message:     "example(.)me",
+			wantDomains: []string{"example.me"},
+		},
+		{
+			description: "should find a link written in capitals",
+			message:     "EXAMPLE.COM",
+			wantDomains: []string{"example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			domains := FindDomains(test.message)
+			if !reflect.DeepEqual(domains, test.wantDomains) {
+				t.Errorf("did not get the expected domains\ngot - %v\nwant - %v", domains, test.wantDomains)
+			}
+		})
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	tests := []struct {
+		description string
+		permitted   map[string]LinkPermit
+		inputItem   Item
+		wantVerdict LinkVerdict
+		wantPermit  bool // whether the sender should still have a permit afterwards
+	}{
+		{
+			description: "should ignore a message with no links",
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "hello"},
+			wantVerdict: LinkNone,
+		},
+		{
+			description: "should allow an allowed domain for anyone",
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "https://clips.twitch.tv/abc"},
+			wantVerdict: LinkAllowed,
+		},
+		{
+			description: "should deny a denied subdomain even for a subscriber",
+			inputItem:   Item{Sender: User{Name: "viewer", Perm: PermSubscriber}, Contents: "free.scam.ru"},
+			wantVerdict: LinkDenied,
+		},
+		{
+			description: "should allow a user with a high enough permission",
+			inputItem:   Item{Sender: User{Name: "viewer", Perm: PermSubscriber}, Contents: "example.com"},
+			wantVerdict: LinkAllowed,
+		},
+		{
+			description: "should not allow a viewer without a permit",
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "example.com"},
+			wantVerdict: LinkNotPermitted,
+		},
+		{
+			description: "should use up a one-time permit",
+			permitted:   map[string]LinkPermit{"viewer": {}},
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "example.com"},
+			wantVerdict: LinkAllowed,
+			wantPermit:  false,
+		},
+		{
+			description: "should deny a bare denied domain whose top-level domain is a word",
+			inputItem:   Item{Sender: User{Name: "viewer", Perm: PermSubscriber}, Contents: "check out evil.me"},
+			wantVerdict: LinkDenied,
+		},
+		{
+			description: "should deny a bare subdomain of a denied domain",
+			inputItem:   Item{Sender: User{Name: "viewer", Perm: PermSubscriber}, Contents: "free.evil.me for prizes"},
+			wantVerdict: LinkDenied,
+		},
+		{
+			description: "should keep a timed permit",
+			permitted:   map[string]LinkPermit{"viewer": {Expires: time.Now().Add(time.Minute)}},
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "example.com"},
+			wantVerdict: LinkAllowed,
+			wantPermit:  true,
+		},
+		{
+			description: "should not honor an expired permit",
+			permitted:   map[string]LinkPermit{"viewer": {Expires: time.Now().Add(-time.Minute)}},
+			inputItem:   Item{Sender: User{Name: "viewer"}, Contents: "example.com"},
+			wantVerdict: LinkNotPermitted,
+			wantPermit:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			bot := &Bot{PostLinkPerm: PermSubscriber, AllowedDomains: []string{"clips.twitch.tv"}, DeniedDomains: []string{"scam.ru", "evil.me"},
+				PermittedUsers: test.permitted}
+			verdict := bot.CheckLinks(test.inputItem)
+			if verdict != test.wantVerdict {
+				t.Errorf("did not get the expected verdict\ngot - %v\nwant - %v", verdict, test.wantVerdict)
+			}
+
+			if _, ok := bot.PermittedUsers["viewer"]; ok != test.wantPermit {
+				t.Errorf("did not get the expected permit state\ngot - %v\nwant - %v", ok, test.wantPermit)
+			}
+		})
+	}
+}
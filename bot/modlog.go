@@ -0,0 +1,182 @@
+// modlog.go handles the moderation audit log. Every action taken against a user, whether automatically by a
+// moderation rule or manually by a moderator through the bot, is recorded here.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	modLogTimeFormat = "2006-01-02 15:04:05"
+	maxExcerptLength = 200
+)
+
+// ModAction is the kind of action taken against a user
+type ModAction string
+
+const (
+	ModActionWarn    ModAction = "warn"
+	ModActionDelete  ModAction = "delete"
+	ModActionPurge   ModAction = "purge"
+	ModActionTimeout ModAction = "timeout"
+	ModActionBan     ModAction = "ban"
+	ModActionUnban   ModAction = "unban"
+	ModActionPardon  ModAction = "pardon"
+)
+
+var modLogColumns = []string{"action", "actor", "target", "target_id", "reason", "rule", "excerpt", "duration", "timestamp"}
+
+// ModLogEntry is a single moderation action
+type ModLogEntry struct {
+	ID        int           `json:"id"`
+	Action    ModAction     `json:"action"`
+	Actor     string        `json:"actor"` // the moderator who took the action, or the bot's name for automated actions
+	Target    string        `json:"target"`
+	TargetID  string        `json:"target_id"`
+	Reason    string        `json:"reason"`
+	Rule      string        `json:"rule"`    // the rule that was broken for automated actions, e.g. "link"
+	Excerpt   string        `json:"excerpt"` // the start of the message that caused the action
+	Duration  time.Duration `json:"duration"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// ModLogFilter narrows down a moderation log query. Zero values are ignored.
+type ModLogFilter struct {
+	User  string // matches either the target or the actor
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// ActionForSeverity returns the ModAction that matches a punishment severity
+func ActionForSeverity(severity Severity) ModAction {
+	switch severity {
+	case SeverityDelete:
+		return ModActionDelete
+	case SeverityPurge:
+		return ModActionPurge
+	case SeverityTimeout:
+		return ModActionTimeout
+	case SeverityBan:
+		return ModActionBan
+	}
+	return ModActionWarn
+}
+
+// LogModAction records entry in the moderation log and publishes it as an event. The timestamp is set to now if it is
+// empty, the target's ID is looked up from their chatter profile if it isn't given, and the excerpt is cut down to a
+// reasonable length.
+func (bot *Bot) LogModAction(entry ModLogEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.TargetID == "" && entry.Target != "" && bot.Storage != nil {
+		entry.TargetID, _ = bot.ChatterID(entry.Target) // a user who never chatted has no ID to log
+	}
+	if runes := []rune(entry.Excerpt); len(runes) > maxExcerptLength {
+		entry.Excerpt = string(runes[:maxExcerptLength]) + "..."
+	}
+	bot.Publish(EventModeration, entry)
+
+	if bot.Storage == nil {
+		return nil
+	}
+	return bot.Storage.DB.Insert("modlog", modLogColumns, []string{string(entry.Action), entry.Actor, entry.Target,
+		entry.TargetID, entry.Reason, entry.Rule, entry.Excerpt, strconv.Itoa(int(entry.Duration.Seconds())),
+		entry.Timestamp.Format(modLogTimeFormat)})
+}
+
+// ModLog returns the moderation log entries matching filter, newest first
+func (bot *Bot) ModLog(filter ModLogFilter) ([]ModLogEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.User != "" {
+		conditions = append(conditions, "(target = ? OR actor = ?)")
+		args = append(args, strings.ToLower(filter.User), strings.ToLower(filter.User))
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Since.Format(modLogTimeFormat))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, filter.Until.Format(modLogTimeFormat))
+	}
+
+	query := fmt.Sprintf("select id, %s from modlog", strings.Join(modLogColumns, ", "))
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by timestamp desc, id desc"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" limit %d", filter.Limit)
+	}
+
+	rows, err := bot.Storage.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var entries []ModLogEntry
+	for rows.Next() {
+		var entry ModLogEntry
+		var action, timestamp string
+		var seconds int
+		err = rows.Scan(&entry.ID, &action, &entry.Actor, &entry.Target, &entry.TargetID, &entry.Reason, &entry.Rule,
+			&entry.Excerpt, &seconds, &timestamp)
+		if err != nil {
+			return nil, err
+		}
+		entry.Action = ModAction(action)
+		entry.Duration = time.Duration(seconds) * time.Second
+		entry.Timestamp, err = time.ParseInLocation(modLogTimeFormat, timestamp, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ParseSince reads a point in time given either as a date such as "2022-01-31", or as a duration into the past such
+// as "2d" or "12h"
+func ParseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+
+	duration, err := ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a date (2006-01-02) or a duration (2d, 12h)", value)
+	}
+	return time.Now().Add(-duration), nil
+}
+
+// ParseUntil reads the end of a time range like ParseSince, except that a date such as "2022-01-31" means the end of
+// that day, so that everything on it is included
+func ParseUntil(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return ParseSince(value)
+}
+
+// ParseDuration is time.ParseDuration with support for a number of days, such as "2d"
+func ParseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
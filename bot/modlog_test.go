@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModLog(t *testing.T) {
+	database := newTestDatabase(t)
+
+	bot := &Bot{Storage: database}
+	now := time.Now()
+	entries := []ModLogEntry{
+		{Action: ModActionBan, Actor: "pleasantbot", Target: "spammer", TargetID: "1", Reason: "don't spam", Rule: "link", Timestamp: now.AddDate(0, 0, -3)},
+		{Action: ModActionTimeout, Actor: "pleasantbot", Target: "viewer", TargetID: "2", Rule: "caps", Duration: time.Minute, Timestamp: now.Add(-time.Hour)},
+		{Action: ModActionUnban, Actor: "somemod", Target: "spammer", Timestamp: now},
+	}
+	for _, entry := range entries {
+		if err := bot.LogModAction(entry); err != nil {
+			t.Fatalf("could not log an entry: %v", err)
+		}
+	}
+
+	today, err := ParseUntil(now.Format("2006-01-02"))
+	if err != nil {
+		t.Fatalf("could not parse today's date: %v", err)
+	}
+	tests := []struct {
+		description string
+		filter      ModLogFilter
+		wantActions []ModAction
+	}{
+		{
+			description: "should return everything newest first",
+			filter:      ModLogFilter{},
+			wantActions: []ModAction{ModActionUnban, ModActionTimeout, ModActionBan},
+		},
+		{
+			description: "should filter by target",
+			filter:      ModLogFilter{User: "Spammer"},
+			wantActions: []ModAction{ModActionUnban, ModActionBan},
+		},
+		{
+			description: "should filter by actor",
+			filter:      ModLogFilter{User: "somemod"},
+			wantActions: []ModAction{ModActionUnban},
+		},
+		{
+			description: "should filter by date",
+			filter:      ModLogFilter{Since: now.AddDate(0, 0, -1)},
+			wantActions: []ModAction{ModActionUnban, ModActionTimeout},
+		},
+		{
+			description: "should include the whole of the until date",
+			filter:      ModLogFilter{Until: today},
+			wantActions: []ModAction{ModActionUnban, ModActionTimeout, ModActionBan},
+		},
+		{
+			description: "should filter by a date range",
+			filter:      ModLogFilter{Until: now.AddDate(0, 0, -1)},
+			wantActions: []ModAction{ModActionBan},
+		},
+		{
+			description: "should limit the results",
+			filter:      ModLogFilter{Limit: 1},
+			wantActions: []ModAction{ModActionUnban},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, err := bot.ModLog(test.filter)
+			if err != nil {
+				t.Fatalf("got an unexpected error: %v", err)
+			}
+
+			var actions []ModAction
+			for _, entry := range got {
+				actions = append(actions, entry.Action)
+			}
+			if len(actions) != len(test.wantActions) {
+				t.Fatalf("did not get the expected actions\ngot - %v\nwant - %v", actions, test.wantActions)
+			}
+			for i := range actions {
+				if actions[i] != test.wantActions[i] {
+					t.Errorf("did not get the expected actions\ngot - %v\nwant - %v", actions, test.wantActions)
+				}
+			}
+		})
+	}
+
+	got, _ := bot.ModLog(ModLogFilter{User: "viewer"})
+	if len(got) != 1 || got[0].Duration != time.Minute || got[0].Reason != "" || got[0].TargetID != "2" {
+		t.Errorf("did not read back the expected entry: %+v", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	date, err := ParseSince("2022-01-31")
+	if err != nil || date.Format("2006-01-02") != "2022-01-31" {
+		t.Errorf("did not parse a date: %v %v", date, err)
+	}
+
+	since, err := ParseSince("2d")
+	if err != nil || time.Since(since).Round(time.Hour) != 48*time.Hour {
+		t.Errorf("did not parse a day duration: %v %v", since, err)
+	}
+
+	if _, err = ParseSince("yesterday"); err == nil {
+		t.Errorf("expected an error for an invalid value")
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	until, err := ParseUntil("2022-01-31")
+	if want := time.Date(2022, 1, 31, 23, 59, 59, 999999999, time.Local); err != nil || !until.Equal(want) {
+		t.Errorf("did not get the end of the day\ngot - %v %v\nwant - %v", until, err, want)
+	}
+
+	until, err = ParseUntil("2d")
+	if err != nil || time.Since(until).Round(time.Hour) != 48*time.Hour {
+		t.Errorf("did not parse a day duration: %v %v", until, err)
+	}
+
+	if until, err = ParseUntil(""); err != nil || !until.IsZero() {
+		t.Errorf("expected no time for an empty value: %v %v", until, err)
+	}
+	if _, err = ParseUntil("tomorrow"); err == nil {
+		t.Errorf("expected an error for an invalid value")
+	}
+}
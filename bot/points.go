@@ -0,0 +1,203 @@
+// points.go handles the bot's loyalty points. Viewers earn points for chatting and for every minute they're in the
+// channel, subscribers earning more, and can spend or give them away. Balances only change inside a database
+// transaction, so two changes to the same balance can't step on each other.
+
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNotEnoughPoints is returned when a change would leave a balance below zero
+var ErrNotEnoughPoints = errors.New("not enough points")
+
+// Balance is a viewer's points along with how long they've watched
+type Balance struct {
+	User    string `json:"user"`
+	Points  int64  `json:"points"`
+	Minutes int64  `json:"minutes"` // minutes spent in the channel
+}
+
+// viewer is someone currently in the channel
+type viewer struct {
+	perm       uint8
+	lastEarned time.Time // when the viewer last earned points for a message
+}
+
+// loadPointsConfig reads the points settings from the config
+func (bot *Bot) loadPointsConfig() {
+	bot.PointsEnabled = bot.Config.GetBool("Points.Enabled")
+	bot.PointsName = bot.Config.GetString("Points.Name")
+	bot.PointsPerMsg = bot.Config.GetInt64("Points.PerMessage")
+	bot.PointsPerMinute = bot.Config.GetInt64("Points.PerMinute")
+	bot.PointsInterval = bot.Config.GetDuration("Points.MessageInterval")
+	bot.SubMultiplier = bot.Config.GetFloat64("Points.SubMultiplier")
+}
+
+// GetBalance returns a user's balance, a user who has never earned points has an empty balance
+func (bot *Bot) GetBalance(user string) (Balance, error) {
+	balance := Balance{User: user}
+	rows, err := bot.Storage.DB.Query("select balance, minutes from points where username = ?", user)
+	if err != nil {
+		return balance, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&balance.Points, &balance.Minutes)
+	}
+	return balance, err
+}
+
+// AddPoints adds amount to a user's balance, which may be negative, and returns the new balance. ErrNotEnoughPoints
+// is returned if the balance would drop below zero.
+func (bot *Bot) AddPoints(user string, amount int64) (int64, error) {
+	var balance int64
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		var err error
+		balance, err = addPoints(tx, user, amount, 0)
+		return err
+	})
+	return balance, err
+}
+
+// SetPoints sets a user's balance
+func (bot *Bot) SetPoints(user string, amount int64) error {
+	if amount < 0 {
+		return ErrNotEnoughPoints
+	}
+	return bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`insert into points (username, balance, minutes) values (?, ?, 0)
+			on conflict (username) do update set balance = excluded.balance`, user, amount)
+		return err
+	})
+}
+
+// TransferPoints moves amount points from one user to another, either both balances change or neither does
+func (bot *Bot) TransferPoints(from, to string, amount int64) error {
+	if amount <= 0 {
+		return NonFatalError{Err: fmt.Errorf("the amount to give must be above zero")}
+	}
+	if from == to {
+		return NonFatalError{Err: fmt.Errorf("you can't give points to yourself")}
+	}
+	return bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		if _, err := addPoints(tx, from, -amount, 0); err != nil {
+			return err
+		}
+		_, err := addPoints(tx, to, amount, 0)
+		return err
+	})
+}
+
+// TopBalances returns the n users with the most points
+func (bot *Bot) TopBalances(n int) ([]Balance, error) {
+	rows, err := bot.Storage.DB.Query("select username, balance, minutes from points order by balance desc, username limit ?", n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []Balance
+	for rows.Next() {
+		var balance Balance
+		if err = rows.Scan(&balance.User, &balance.Points, &balance.Minutes); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// MarkPresent remembers that a user is in the channel, so they earn points for watching
+func (bot *Bot) MarkPresent(user string, perm uint8) {
+	if bot.viewers == nil {
+		bot.viewers = make(map[string]*viewer)
+	}
+	if v, ok := bot.viewers[user]; ok {
+		if perm > v.perm {
+			v.perm = perm
+		}
+		return
+	}
+	bot.viewers[user] = &viewer{perm: perm}
+}
+
+// MarkAbsent forgets a user that has left the channel
+func (bot *Bot) MarkAbsent(user string) {
+	delete(bot.viewers, user)
+}
+
+// EarnMessagePoints gives the sender of a chat message their points for chatting, at most once every
+// PointsInterval
+func (bot *Bot) EarnMessagePoints(item Item) error {
+	if !bot.PointsEnabled || item.Sender.Name == "" {
+		return nil
+	}
+	bot.MarkPresent(item.Sender.Name, item.Sender.Perm)
+	v := bot.viewers[item.Sender.Name]
+	v.perm = item.Sender.Perm
+	if time.Since(v.lastEarned) < bot.PointsInterval || bot.PointsPerMsg == 0 {
+		return nil
+	}
+	v.lastEarned = time.Now()
+	_, err := bot.AddPoints(item.Sender.Name, bot.earned(bot.PointsPerMsg, v.perm))
+	return err
+}
+
+// AwardWatchTime gives everyone in the channel a minute of watch time and the points that come with it
+func (bot *Bot) AwardWatchTime() error {
+	if !bot.PointsEnabled || len(bot.viewers) == 0 {
+		return nil
+	}
+	return bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		for user, v := range bot.viewers {
+			if _, err := addPoints(tx, user, bot.earned(bot.PointsPerMinute, v.perm), 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FormatPoints formats an amount with the name the channel gives its points, e.g. "100 points"
+func (bot *Bot) FormatPoints(amount int64) string {
+	name := bot.PointsName
+	if name == "" {
+		name = "points"
+	}
+	if amount == 1 {
+		name = strings.TrimSuffix(name, "s")
+	}
+	return fmt.Sprintf("%d %s", amount, name)
+}
+
+// earned returns the points a user with perm earns in place of amount
+func (bot *Bot) earned(amount int64, perm uint8) int64 {
+	if perm >= PermSubscriber && bot.SubMultiplier > 0 {
+		return int64(math.Round(float64(amount) * bot.SubMultiplier))
+	}
+	return amount
+}
+
+// addPoints changes a user's balance and watch time inside tx and returns the new balance
+func addPoints(tx *sql.Tx, user string, amount, minutes int64) (int64, error) {
+	_, err := tx.Exec("insert into points (username, balance, minutes) values (?, 0, 0) on conflict (username) do nothing", user)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance int64
+	if err = tx.QueryRow("select balance from points where username = ?", user).Scan(&balance); err != nil {
+		return 0, err
+	}
+	if balance+amount < 0 {
+		return balance, ErrNotEnoughPoints
+	}
+	_, err = tx.Exec("update points set balance = balance + ?, minutes = minutes + ? where username = ?", amount, minutes, user)
+	return balance + amount, err
+}
@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPoints(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, PointsEnabled: true, PointsPerMsg: 5, PointsPerMinute: 1, SubMultiplier: 2}
+
+	// chatting earns points once per interval, subscribers earn double
+	bot.PointsInterval = time.Hour
+	bot.EarnMessagePoints(Item{Sender: User{Name: "viewer"}})
+	bot.EarnMessagePoints(Item{Sender: User{Name: "viewer"}})
+	bot.EarnMessagePoints(Item{Sender: User{Name: "sub", Perm: PermSubscriber}})
+	bot.MarkPresent("lurker", PermAll)
+	bot.MarkPresent("leaver", PermAll)
+	bot.MarkAbsent("leaver")
+	if err := bot.AwardWatchTime(); err != nil {
+		t.Fatalf("could not award watch time: %v", err)
+	}
+
+	balances, err := bot.TopBalances(10)
+	if err != nil {
+		t.Fatalf("could not get the top balances: %v", err)
+	}
+	want := []Balance{{User: "sub", Points: 12, Minutes: 1}, {User: "viewer", Points: 6, Minutes: 1}, {User: "lurker", Points: 1, Minutes: 1}}
+	if !reflect.DeepEqual(balances, want) {
+		t.Errorf("did not get the expected balances\ngot - %+v\nwant - %+v", balances, want)
+	}
+
+	tests := []struct {
+		description string
+		from, to    string
+		amount      int64
+		wantErr     error
+		wantFrom    int64
+		wantTo      int64
+	}{
+		{description: "should move points between users", from: "sub", to: "lurker", amount: 2, wantFrom: 10, wantTo: 3},
+		{description: "should give points to a new user", from: "sub", to: "newcomer", amount: 10, wantFrom: 0, wantTo: 10},
+		{description: "should change neither balance without enough points", from: "lurker", to: "viewer", amount: 4,
+			wantErr: ErrNotEnoughPoints, wantFrom: 3, wantTo: 6},
+	}
+	for _, test := range tests {
+		err := bot.TransferPoints(test.from, test.to, test.amount)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant - %v", test.description, err, test.wantErr)
+		}
+		from, _ := bot.GetBalance(test.from)
+		to, _ := bot.GetBalance(test.to)
+		if from.Points != test.wantFrom || to.Points != test.wantTo {
+			t.Errorf("%s\ndid not get the expected balances\ngot - %d, %d\nwant - %d, %d", test.description, from.Points,
+				to.Points, test.wantFrom, test.wantTo)
+		}
+	}
+
+	if _, err = bot.AddPoints("lurker", -4); !errors.Is(err, ErrNotEnoughPoints) {
+		t.Errorf("did not get the expected error taking away too many points\ngot - %v\nwant - %v", err, ErrNotEnoughPoints)
+	}
+	if err = bot.SetPoints("lurker", 50); err != nil {
+		t.Fatalf("could not set points: %v", err)
+	}
+	if balance, _ := bot.GetBalance("lurker"); balance.Points != 50 || balance.Minutes != 1 {
+		t.Errorf("did not get the expected balance after setting points: %+v", balance)
+	}
+}
@@ -0,0 +1,177 @@
+// polls.go handles chat polls. Each user gets one vote, keyed by their Twitch user ID so a name change can't be used
+// to vote twice, and the tally is published as an event after every vote so overlays and the dashboard can follow it.
+
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// states a poll can be in
+const (
+	PollOpen      = "open"
+	PollClosed    = "closed"
+	PollCancelled = "cancelled"
+)
+
+const maxPollOptions = 10
+
+var errNoPoll = errors.New("there is no poll running")
+
+// Poll is a single poll, it is also the data for an EventPoll
+type Poll struct {
+	ID        int64     `json:"id"`
+	Question  string    `json:"question"`
+	Options   []string  `json:"options"`
+	Votes     []int     `json:"votes"` // votes for each option
+	StartedBy string    `json:"started_by"`
+	Started   time.Time `json:"started"`
+	Ends      time.Time `json:"ends,omitempty"` // when the poll closes by itself, zero if it is ended by hand
+	State     string    `json:"state"`          // one of PollOpen, PollClosed or PollCancelled
+	voters    map[string]bool
+}
+
+// StartPoll starts a poll that closes after duration, or when it is ended if duration is 0
+func (bot *Bot) StartPoll(question string, options []string, duration time.Duration, startedBy string) (*Poll, error) {
+	if bot.Poll != nil {
+		return nil, NonFatalError{Err: fmt.Errorf("a poll is already running, end it with !poll end")}
+	}
+	if question == "" || len(options) < 2 || len(options) > maxPollOptions {
+		return nil, NonFatalError{Err: fmt.Errorf("a poll needs a question and between 2 and %d options", maxPollOptions)}
+	}
+
+	poll := &Poll{Question: question, Options: options, Votes: make([]int, len(options)), StartedBy: startedBy,
+		Started: time.Now(), State: PollOpen, voters: make(map[string]bool)}
+	if duration > 0 {
+		poll.Ends = poll.Started.Add(duration)
+	}
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec("insert into polls (question, options, started_by, started, state) values (?, ?, ?, ?, ?)",
+			question, strings.Join(options, "|"), startedBy, poll.Started.Format(time.RFC3339), poll.State)
+		if err != nil {
+			return err
+		}
+		poll.ID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bot.Poll = poll
+	bot.publishPoll()
+	return poll, nil
+}
+
+// Vote counts a vote for the running poll's option'th option, numbered from 1. A user's first vote is the only one
+// that counts, voted is false for any other.
+func (bot *Bot) Vote(user User, option int) (voted bool, err error) {
+	poll := bot.Poll
+	if poll == nil {
+		return false, NonFatalError{Err: errNoPoll}
+	}
+	if option < 1 || option > len(poll.Options) {
+		return false, NonFatalError{Err: fmt.Errorf("pick an option from 1 to %d", len(poll.Options))}
+	}
+	voter := user.ID
+	if voter == "" {
+		voter = user.Name
+	}
+	if poll.voters[voter] {
+		return false, nil
+	}
+
+	err = bot.Storage.DB.Insert("poll_votes", []string{"poll_id", "user_id", "user", "option", "timestamp"},
+		[]string{fmt.Sprint(poll.ID), voter, user.Name, fmt.Sprint(option), time.Now().Format(time.RFC3339)})
+	if err != nil {
+		return false, err
+	}
+	poll.voters[voter] = true
+	poll.Votes[option-1]++
+	bot.publishPoll()
+	return true, nil
+}
+
+// EndPoll ends the running poll with state, either PollClosed or PollCancelled
+func (bot *Bot) EndPoll(state string) (*Poll, error) {
+	poll := bot.Poll
+	if poll == nil {
+		return nil, NonFatalError{Err: errNoPoll}
+	}
+	err := bot.Storage.DB.ArbitraryExec("update polls set state = ?, ended = ? where id = ?", state,
+		time.Now().Format(time.RFC3339), poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	poll.State = state
+	bot.publishPoll()
+	bot.Poll = nil
+	return poll, nil
+}
+
+// Polls returns the last limit polls, newest first, with their tallies
+func (bot *Bot) Polls(limit int) ([]Poll, error) {
+	rows, err := bot.Storage.DB.Query(`select id, question, options, started_by, started, state from polls
+		order by id desc limit ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var polls []Poll
+	for rows.Next() {
+		var poll Poll
+		var options, started string
+		if err = rows.Scan(&poll.ID, &poll.Question, &options, &poll.StartedBy, &started, &poll.State); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		poll.Options = strings.Split(options, "|")
+		poll.Votes = make([]int, len(poll.Options))
+		poll.Started, _ = time.Parse(time.RFC3339, started)
+		polls = append(polls, poll)
+	}
+	rows.Close()
+
+	for i := range polls {
+		rows, err = bot.Storage.DB.Query("select option, count(*) from poll_votes where poll_id = ? group by option", polls[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var option, votes int
+			if err = rows.Scan(&option, &votes); err == nil && option >= 1 && option <= len(polls[i].Votes) {
+				polls[i].Votes[option-1] = votes
+			}
+		}
+		rows.Close()
+	}
+	return polls, nil
+}
+
+// Results describes the tally of a poll, e.g. "yes: 3 (75%), no: 1 (25%)"
+func (poll *Poll) Results() string {
+	total := 0
+	for _, votes := range poll.Votes {
+		total += votes
+	}
+	var results []string
+	for i, option := range poll.Options {
+		percent := 0
+		if total > 0 {
+			percent = poll.Votes[i] * 100 / total
+		}
+		results = append(results, fmt.Sprintf("%s: %d (%d%%)", option, poll.Votes[i], percent))
+	}
+	return strings.Join(results, ", ")
+}
+
+// publishPoll publishes a copy of the running poll, so later votes don't change an event that was already sent
+func (bot *Bot) publishPoll() {
+	poll := *bot.Poll
+	poll.Votes = append([]int(nil), poll.Votes...)
+	bot.Publish(EventPoll, poll)
+}
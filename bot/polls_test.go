@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPoll(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, Events: NewEventBus(0)}
+	sub := bot.Events.Subscribe("test", SubscribeOptions{Types: []EventType{EventPoll}, Buffer: 16})
+	defer sub.Close()
+
+	if _, err := bot.StartPoll("best snack?", []string{"chips"}, 0, "mod"); err == nil {
+		t.Errorf("started a poll with only one option")
+	}
+	if _, err := bot.StartPoll("best snack?", []string{"chips", "cookies"}, 0, "mod"); err != nil {
+		t.Fatalf("could not start the poll: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		user        User
+		option      int
+		wantVoted   bool
+		wantErr     bool
+	}{
+		{description: "should count a vote", user: User{ID: "1", Name: "viewer"}, option: 2, wantVoted: true},
+		{description: "should not count a second vote", user: User{ID: "1", Name: "viewer"}, option: 1},
+		{description: "should not count a vote after a name change", user: User{ID: "1", Name: "renamed"}, option: 1},
+		{description: "should not count an option that doesn't exist", user: User{ID: "2", Name: "other"}, option: 3, wantErr: true},
+		{description: "should count another user's vote", user: User{ID: "2", Name: "other"}, option: 2, wantVoted: true},
+	}
+	for _, test := range tests {
+		voted, err := bot.Vote(test.user, test.option)
+		if voted != test.wantVoted || (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected result\ngot - %v, %v\nwant - %v, error %v", test.description, voted, err,
+				test.wantVoted, test.wantErr)
+		}
+	}
+
+	poll, err := bot.EndPoll(PollClosed)
+	if err != nil {
+		t.Fatalf("could not end the poll: %v", err)
+	}
+	if want := "chips: 0 (0%), cookies: 2 (100%)"; poll.Results() != want {
+		t.Errorf("did not get the expected results\ngot - %v\nwant - %v", poll.Results(), want)
+	}
+
+	// the poll is published when it starts, after each vote and when it ends
+	var tallies [][]int
+	for len(sub.Events()) > 0 {
+		event := <-sub.Events()
+		tallies = append(tallies, event.Data.(Poll).Votes)
+	}
+	if want := [][]int{{0, 0}, {0, 1}, {0, 2}, {0, 2}}; !reflect.DeepEqual(tallies, want) {
+		t.Errorf("did not publish the expected tallies\ngot - %v\nwant - %v", tallies, want)
+	}
+
+	polls, err := bot.Polls(10)
+	if err != nil || len(polls) != 1 {
+		t.Fatalf("did not get the stored poll: %v, %v", polls, err)
+	}
+	if polls[0].State != PollClosed || !reflect.DeepEqual(polls[0].Votes, []int{0, 2}) {
+		t.Errorf("did not get the expected stored poll: %+v", polls[0])
+	}
+}
@@ -0,0 +1,176 @@
+// queue.go handles the viewer queue used when playing with viewers. Entries are kept in the database in the order
+// they joined, so the queue survives a restart, and subscribers can optionally be moved ahead of everyone else.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	errQueueClosed = errors.New("the queue is closed")
+	errQueueFull   = errors.New("the queue is full")
+)
+
+// Queue is the viewer queue, it is also the data for an EventQueue
+type Queue struct {
+	Open    bool         `json:"open"`
+	Size    int          `json:"size"` // how many viewers the queue can hold, no limit if 0
+	Entries []QueueEntry `json:"entries"`
+}
+
+// QueueEntry is a viewer waiting in the queue
+type QueueEntry struct {
+	User       string    `json:"user"`
+	UserID     string    `json:"user_id"`
+	Subscriber bool      `json:"subscriber"`
+	Joined     time.Time `json:"joined"`
+}
+
+// LoadQueue loads whether the queue is open and who is in it from the database
+func (bot *Bot) LoadQueue() error {
+	bot.Queue = Queue{Size: bot.QueueSize}
+	rows, err := bot.Storage.DB.Query("select open from queue_state")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err = rows.Scan(&bot.Queue.Open); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+
+	rows, err = bot.Storage.DB.Query("select username, user_id, subscriber, joined from queue order by rowid")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry QueueEntry
+		var joined string
+		if err = rows.Scan(&entry.User, &entry.UserID, &entry.Subscriber, &joined); err != nil {
+			return err
+		}
+		if entry.Joined, err = time.Parse(time.RFC3339, joined); err != nil {
+			return err
+		}
+		bot.Queue.Entries = append(bot.Queue.Entries, entry)
+	}
+	if bot.QueuePriority {
+		sort.SliceStable(bot.Queue.Entries, func(i, j int) bool {
+			return bot.Queue.Entries[i].Subscriber && !bot.Queue.Entries[j].Subscriber
+		})
+	}
+	return rows.Err()
+}
+
+// SetQueueOpen opens or closes the queue to new viewers, those already in it keep their place
+func (bot *Bot) SetQueueOpen(open bool) error {
+	err := bot.Storage.DB.ArbitraryExec(`insert into queue_state (id, open) values (1, ?)
+		on conflict (id) do update set open = excluded.open`, open)
+	if err != nil {
+		return err
+	}
+	bot.Queue.Open = open
+	bot.publishQueue()
+	return nil
+}
+
+// QueuePosition returns where a user is in the queue starting from 1, or 0 if they aren't in it
+func (bot *Bot) QueuePosition(user string) int {
+	for i, entry := range bot.Queue.Entries {
+		if entry.User == user {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// JoinQueue adds a user to the end of the queue, or behind the last subscriber if they are a subscriber and
+// subscribers get priority. Their position is returned, along with whether they joined or were already in the queue.
+func (bot *Bot) JoinQueue(user User) (position int, joined bool, err error) {
+	if position = bot.QueuePosition(user.Name); position > 0 {
+		return position, false, nil
+	}
+	if !bot.Queue.Open {
+		return 0, false, NonFatalError{Err: errQueueClosed}
+	}
+	if bot.QueueSize > 0 && len(bot.Queue.Entries) >= bot.QueueSize {
+		return 0, false, NonFatalError{Err: errQueueFull}
+	}
+
+	entry := QueueEntry{User: user.Name, UserID: user.ID, Subscriber: user.Perm >= PermSubscriber, Joined: time.Now()}
+	err = bot.Storage.DB.ArbitraryExec("insert into queue (username, user_id, subscriber, joined) values (?, ?, ?, ?)",
+		entry.User, entry.UserID, entry.Subscriber, entry.Joined.Format(time.RFC3339))
+	if err != nil {
+		return 0, false, err
+	}
+
+	position = len(bot.Queue.Entries)
+	if bot.QueuePriority && entry.Subscriber {
+		position = 0
+		for position < len(bot.Queue.Entries) && bot.Queue.Entries[position].Subscriber {
+			position++
+		}
+	}
+	bot.Queue.Entries = append(bot.Queue.Entries, QueueEntry{})
+	copy(bot.Queue.Entries[position+1:], bot.Queue.Entries[position:])
+	bot.Queue.Entries[position] = entry
+	bot.publishQueue()
+	return position + 1, true, nil
+}
+
+// LeaveQueue removes a user from the queue, returning false if they weren't in it
+func (bot *Bot) LeaveQueue(user string) (bool, error) {
+	position := bot.QueuePosition(user)
+	if position == 0 {
+		return false, nil
+	}
+	if err := bot.Storage.DB.Delete("queue", "username", user); err != nil {
+		return false, err
+	}
+	bot.Queue.Entries = append(bot.Queue.Entries[:position-1], bot.Queue.Entries[position:]...)
+	bot.publishQueue()
+	return true, nil
+}
+
+// NextInQueue takes the first n viewers off the front of the queue and returns them
+func (bot *Bot) NextInQueue(n int) ([]QueueEntry, error) {
+	if n < 1 {
+		return nil, NonFatalError{Err: fmt.Errorf("you have to take at least 1 viewer from the queue")}
+	}
+	if n > len(bot.Queue.Entries) {
+		n = len(bot.Queue.Entries)
+	}
+	next := append([]QueueEntry(nil), bot.Queue.Entries[:n]...)
+	for _, entry := range next {
+		if err := bot.Storage.DB.Delete("queue", "username", entry.User); err != nil {
+			return nil, err
+		}
+		bot.Queue.Entries = bot.Queue.Entries[1:]
+	}
+	bot.publishQueue()
+	return next, nil
+}
+
+// ClearQueue removes everyone from the queue
+func (bot *Bot) ClearQueue() error {
+	if err := bot.Storage.DB.ArbitraryExec("delete from queue"); err != nil {
+		return err
+	}
+	bot.Queue.Entries = nil
+	bot.publishQueue()
+	return nil
+}
+
+// publishQueue publishes a copy of the queue, so subscribers don't share its entries with the bot
+func (bot *Bot) publishQueue() {
+	queue := bot.Queue
+	queue.Entries = append([]QueueEntry(nil), queue.Entries...)
+	bot.Publish(EventQueue, queue)
+}
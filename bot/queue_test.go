@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+// queueUsers returns the names of everyone in the queue, in order
+func queueUsers(queue Queue) []string {
+	var users []string
+	for _, entry := range queue.Entries {
+		users = append(users, entry.User)
+	}
+	return users
+}
+
+func TestQueue(t *testing.T) {
+	database := newTestDatabase(t)
+	bot := &Bot{Storage: database, QueueSize: 4, QueuePriority: true}
+	if err := bot.LoadQueue(); err != nil {
+		t.Fatalf("could not load the queue: %v", err)
+	}
+
+	if _, _, err := bot.JoinQueue(User{Name: "early"}); err == nil {
+		t.Errorf("joined the queue before it was open")
+	}
+	if err := bot.SetQueueOpen(true); err != nil {
+		t.Fatalf("could not open the queue: %v", err)
+	}
+
+	tests := []struct {
+		description  string
+		user         User
+		wantPosition int
+		wantJoined   bool
+		wantErr      bool
+	}{
+		{description: "should join the queue", user: User{Name: "first"}, wantPosition: 1, wantJoined: true},
+		{description: "should join behind the first viewer", user: User{Name: "second"}, wantPosition: 2, wantJoined: true},
+		{description: "should not join twice", user: User{Name: "first"}, wantPosition: 1},
+		{description: "should move a subscriber to the front", user: User{Name: "sub", Perm: PermSubscriber}, wantPosition: 1, wantJoined: true},
+		{description: "should put a subscriber behind other subscribers", user: User{Name: "mod", Perm: PermModerator}, wantPosition: 2, wantJoined: true},
+		{description: "should not join a full queue", user: User{Name: "late"}, wantErr: true},
+	}
+	for _, test := range tests {
+		position, joined, err := bot.JoinQueue(test.user)
+		if position != test.wantPosition || joined != test.wantJoined || (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected result\ngot - %v, %v, %v\nwant - %v, %v, error %v", test.description,
+				position, joined, err, test.wantPosition, test.wantJoined, test.wantErr)
+		}
+	}
+	if got, want := queueUsers(bot.Queue), []string{"sub", "mod", "first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected queue\ngot - %v\nwant - %v", got, want)
+	}
+
+	if left, err := bot.LeaveQueue("mod"); !left || err != nil {
+		t.Errorf("could not leave the queue: %v", err)
+	}
+	next, err := bot.NextInQueue(2)
+	if err != nil || len(next) != 2 || next[0].User != "sub" || next[1].User != "first" {
+		t.Errorf("did not take the expected viewers from the queue\ngot - %v, %v", next, err)
+	}
+
+	// the queue is the same after loading it again, as it would be after a restart
+	bot.JoinQueue(User{Name: "third"})
+	want := bot.Queue
+	if err = bot.LoadQueue(); err != nil {
+		t.Fatalf("could not reload the queue: %v", err)
+	}
+	if !bot.Queue.Open || !reflect.DeepEqual(queueUsers(bot.Queue), queueUsers(want)) {
+		t.Errorf("did not get the same queue after reloading it\ngot - %v\nwant - %v", bot.Queue, want)
+	}
+
+	if err = bot.ClearQueue(); err != nil || len(bot.Queue.Entries) != 0 {
+		t.Errorf("could not clear the queue: %v", err)
+	}
+}
@@ -6,15 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // QuoteValues represents the values associated with a quote. The ID in the DB will be the map key
 type QuoteValues struct {
-	Quote     string
-	Timestamp string
-	Submitter string
+	Quote     string `json:"quote"`
+	Timestamp string `json:"timestamp"`
+	Submitter string `json:"submitter"`
 }
 
 // AddQuote adds a quote to the bot's internal slice and the database
@@ -32,14 +34,28 @@ func (bot *Bot) generateQuoteString(id int) string {
 	return fmt.Sprintf("%s -- %s [submitted by %s]", values.Quote, values.Timestamp, values.Submitter)
 }
 
-// RandomQuote returns a random string, it does not print. It's up to the caller on what to do with it.
+// RandomQuote returns a random string, it does not print. It's up to the caller on what to do with it. The quote is
+// published as an event so overlays can show it.
 func (bot *Bot) RandomQuote() (string, error) {
 	if len(bot.Quotes) == 0 {
 		return "", errors.New("no quotes were found")
 	}
-	randomIndex := rand.Intn(len(bot.Quotes))
 
-	return bot.generateQuoteString(randomIndex + 1), nil // return quote string
+	// IDs can have gaps once quotes are deleted, so pick from the IDs that exist
+	ids := make([]int, 0, len(bot.Quotes))
+	for id := range bot.Quotes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	id := ids[rand.Intn(len(ids))]
+
+	bot.publishQuote(id)
+	return bot.generateQuoteString(id), nil // return quote string
+}
+
+// publishQuote publishes the quote with the given ID as the quote being shown
+func (bot *Bot) publishQuote(id int) {
+	bot.Publish(EventQuote, QuoteEvent{ID: id, QuoteValues: *bot.Quotes[id]})
 }
 
 // LoadQuotes loads all quotes from the DB.
@@ -63,6 +79,24 @@ func (bot *Bot) LoadQuotes() error {
 	return nil
 }
 
+// EditQuote changes the text and submitter of a quote, keeping the date it was added
+func (bot *Bot) EditQuote(id int, quote, submitter string) error {
+	values, found := bot.Quotes[id]
+	if !found {
+		return NonFatalError{Err: fmt.Errorf("there is no quote with the ID %d", id)}
+	}
+	if strings.TrimSpace(quote) == "" {
+		return NonFatalError{Err: fmt.Errorf("a quote cannot be empty")}
+	}
+
+	err := bot.Storage.DB.Update("quotes", "id", strconv.Itoa(id), []string{"quote", "submitter"}, []string{quote, submitter})
+	if err != nil {
+		return err
+	}
+	values.Quote, values.Submitter = quote, submitter
+	return nil
+}
+
 func (bot *Bot) DeleteQuote(quoteID string) error {
 	id, err := strconv.Atoi(quoteID)
 	if err != nil {
@@ -76,12 +110,15 @@ func (bot *Bot) DeleteQuote(quoteID string) error {
 	return bot.Storage.DB.Delete("quotes", "id", quoteID)
 }
 
-// GetQuote returns a quote of a specified index / id. Correlates to the automatically generated ID in sqlite.
+// GetQuote returns a quote of a specified index / id. Correlates to the automatically generated ID in sqlite. The
+// quote is published as an event so overlays can show it.
 func (bot *Bot) GetQuote(index int) (string, error) {
 	if index <= 0 {
 		return "", errors.New("the ID must be a valid integer greater than 0")
-	} else if index > len(bot.Quotes) {
-		return "", fmt.Errorf("the requested ID %d is greater than the total number of quotes, which is: %d", index, len(bot.Quotes))
+	} else if _, found := bot.Quotes[index]; !found {
+		return "", fmt.Errorf("there is no quote with the ID %d, there are %d quotes", index, len(bot.Quotes))
 	}
+
+	bot.publishQuote(index)
 	return bot.generateQuoteString(index), nil // return quote string
 }
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"testing"
+)
+
+func TestShowQuote(t *testing.T) {
+	// quote 2 was deleted, so IDs have a gap
+	bot := &Bot{Events: NewEventBus(0), Quotes: map[int]*QuoteValues{
+		1: {Quote: "first", Timestamp: "2022-01-01", Submitter: "viewer"},
+		3: {Quote: "third", Timestamp: "2022-01-03", Submitter: "viewer"},
+	}}
+	sub := bot.Events.Subscribe("test", SubscribeOptions{Types: []EventType{EventQuote}})
+	defer sub.Close()
+	quotes := sub.Events()
+
+	for i := 0; i < 20; i++ {
+		if _, err := bot.RandomQuote(); err != nil {
+			t.Fatalf("got an unexpected error: %v", err)
+		}
+		event := (<-quotes).Data.(QuoteEvent)
+		if event.ID != 1 && event.ID != 3 {
+			t.Fatalf("picked a quote that doesn't exist: %+v", event)
+		}
+	}
+
+	got, err := bot.GetQuote(3)
+	if want := "third -- 2022-01-03 [submitted by viewer]"; err != nil || got != want {
+		t.Errorf("did not get the expected quote\ngot - %v\nwant - %v", got, want)
+	}
+	if event := (<-quotes).Data.(QuoteEvent); event.ID != 3 || event.Quote != "third" {
+		t.Errorf("did not publish the expected quote: %+v", event)
+	}
+
+	if _, err = bot.GetQuote(2); err == nil {
+		t.Errorf("expected an error for a deleted quote")
+	}
+}
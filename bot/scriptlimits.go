@@ -0,0 +1,243 @@
+// scriptlimits.go keeps Lua scripts from using up the bot's memory. Besides the limits on the call stack and registry
+// every string a script builds is counted against a budget for the run: the '..' operator is compiled into a call to
+// a checked concatenation, and the string and table library functions that build strings are wrapped. No single string
+// can be longer than scriptMaxStringSize, and a run can't build more than scriptMaxStringBytes in total. Anything else
+// a script allocates, such as tables, is bounded by the script's timeout.
+
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/ast"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	scriptMaxStringSize  = 4096    // length of a single string a script can build
+	scriptMaxStringBytes = 1 << 20 // bytes of strings a single run of a script can build
+
+	// scriptConcat names the local every '..' is compiled into a call of. It isn't a valid Lua name, so a script can't
+	// change it.
+	scriptConcat = "(concat)"
+)
+
+// formatWidthRegex finds a width or precision in a format string longer than Lua itself allows
+var formatWidthRegex = regexp.MustCompile(`%[-+ #0]*(\d{3,}|\d*\.\d{3,})`)
+
+// scriptBudget counts the bytes of strings a single run of a script has built
+type scriptBudget struct {
+	built int
+}
+
+// build counts a string of size bytes against the budget, raising an error in L once it is used up
+func (budget *scriptBudget) build(L *lua.LState, size int) {
+	if size > scriptMaxStringSize {
+		L.RaiseError("a script can't build strings longer than %d", scriptMaxStringSize)
+	}
+	budget.built += size
+	if budget.built > scriptMaxStringBytes {
+		L.RaiseError("a script can only build %d bytes of strings", scriptMaxStringBytes)
+	}
+}
+
+// compileScript compiles a script with every '..' replaced by a call to the checked concatenation
+func compileScript(name, source string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	checkConcat(chunk)
+	// the global is copied into a local before the script runs, so the script can't swap it for another function
+	declare := &ast.LocalAssignStmt{Names: []string{scriptConcat}, Exprs: []ast.Expr{&ast.IdentExpr{Value: scriptConcat}}}
+	return lua.Compile(append([]ast.Stmt{declare}, chunk...), name)
+}
+
+// limitStrings gives a script the checked concatenation and wraps the library functions that build strings, so that
+// everything they build counts against budget. It must be called after the libraries are opened.
+func limitStrings(L *lua.LState, budget *scriptBudget) {
+	// an operand that isn't a string or number is concatenated by Lua itself, to keep __concat and its error messages
+	if err := L.DoString("return function(a, b) return a .. b end"); err != nil {
+		panic(fmt.Sprintf("could not make the script concatenation: %v", err))
+	}
+	concat := L.Get(-1)
+	L.Pop(1)
+	L.SetGlobal(scriptConcat, L.NewFunction(func(L *lua.LState) int {
+		a, b := L.Get(1), L.Get(2)
+		if isConcatenable(a) && isConcatenable(b) {
+			lhs, rhs := lua.LVAsString(a), lua.LVAsString(b)
+			budget.build(L, len(lhs)+len(rhs))
+			L.Push(lua.LString(lhs + rhs))
+			return 1
+		}
+		L.Push(concat)
+		L.Push(a)
+		L.Push(b)
+		L.Call(2, 1)
+		return 1
+	}))
+
+	strs := L.GetGlobal("string").(*lua.LTable)
+	for _, name := range []string{"char", "format", "gsub", "lower", "upper", "reverse", "rep"} {
+		wrapStringResults(L, budget, strs, name)
+	}
+	wrapStringResults(L, budget, L.GetGlobal("table").(*lua.LTable), "concat")
+}
+
+// wrapStringResults replaces a library function with one that counts the strings it returns against budget. The
+// functions whose result can be much larger than their arguments are checked before they run.
+func wrapStringResults(L *lua.LState, budget *scriptBudget, lib *lua.LTable, name string) {
+	original := L.GetField(lib, name)
+	L.SetField(lib, name, L.NewFunction(func(L *lua.LState) int {
+		switch name {
+		case "rep":
+			str, n := L.CheckString(1), L.CheckInt(2)
+			if n > 0 && len(str) > 0 && n > scriptMaxStringSize/len(str) {
+				L.RaiseError("string.rep can't build strings longer than %d", scriptMaxStringSize)
+			}
+		case "format":
+			if formatWidthRegex.MatchString(L.CheckString(1)) {
+				L.RaiseError("invalid format (width or precision too long)")
+			}
+		case "concat":
+			checkTableConcat(L)
+		}
+
+		top := L.GetTop()
+		L.Push(original)
+		for i := 1; i <= top; i++ {
+			L.Push(L.Get(i))
+		}
+		L.Call(top, lua.MultRet)
+		for i := top + 1; i <= L.GetTop(); i++ {
+			if str, ok := L.Get(i).(lua.LString); ok {
+				budget.build(L, len(str))
+			}
+		}
+		return L.GetTop() - top
+	}))
+}
+
+// checkTableConcat raises an error if table.concat would build a string longer than scriptMaxStringSize. Each value
+// counts as at least one byte, so a huge range of empty strings is refused without walking all of it.
+func checkTableConcat(L *lua.LState) {
+	list := L.CheckTable(1)
+	sep := L.OptString(2, "")
+	first, last := L.OptInt(3, 1), L.OptInt(4, list.Len())
+	size := 0
+	for i := first; i <= last; i++ {
+		n := len(lua.LVAsString(list.RawGetInt(i)))
+		if n == 0 {
+			n = 1
+		}
+		if i > first {
+			n += len(sep)
+		}
+		if size += n; size > scriptMaxStringSize {
+			L.RaiseError("table.concat can't build strings longer than %d", scriptMaxStringSize)
+		}
+	}
+}
+
+func isConcatenable(value lua.LValue) bool {
+	switch value.(type) {
+	case lua.LString, lua.LNumber:
+		return true
+	}
+	return false
+}
+
+// checkConcat replaces every '..' in the statements with a call to the checked concatenation
+func checkConcat(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			checkConcatExprs(s.Lhs)
+			checkConcatExprs(s.Rhs)
+		case *ast.LocalAssignStmt:
+			checkConcatExprs(s.Exprs)
+		case *ast.FuncCallStmt:
+			s.Expr = checkConcatExpr(s.Expr)
+		case *ast.DoBlockStmt:
+			checkConcat(s.Stmts)
+		case *ast.WhileStmt:
+			s.Condition = checkConcatExpr(s.Condition)
+			checkConcat(s.Stmts)
+		case *ast.RepeatStmt:
+			s.Condition = checkConcatExpr(s.Condition)
+			checkConcat(s.Stmts)
+		case *ast.IfStmt:
+			s.Condition = checkConcatExpr(s.Condition)
+			checkConcat(s.Then)
+			checkConcat(s.Else)
+		case *ast.NumberForStmt:
+			s.Init, s.Limit = checkConcatExpr(s.Init), checkConcatExpr(s.Limit)
+			if s.Step != nil {
+				s.Step = checkConcatExpr(s.Step)
+			}
+			checkConcat(s.Stmts)
+		case *ast.GenericForStmt:
+			checkConcatExprs(s.Exprs)
+			checkConcat(s.Stmts)
+		case *ast.FuncDefStmt:
+			checkConcat(s.Func.Stmts)
+		case *ast.ReturnStmt:
+			checkConcatExprs(s.Exprs)
+		}
+	}
+}
+
+func checkConcatExprs(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		exprs[i] = checkConcatExpr(expr)
+	}
+}
+
+// checkConcatExpr returns expr with every '..' in it replaced by a call to the checked concatenation
+func checkConcatExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.StringConcatOpExpr:
+		call := &ast.FuncCallExpr{Func: &ast.IdentExpr{Value: scriptConcat},
+			Args: []ast.Expr{checkConcatExpr(e.Lhs), checkConcatExpr(e.Rhs)}, AdjustRet: true}
+		call.SetLine(e.Line())
+		call.SetLastLine(e.LastLine())
+		call.Func.SetLine(e.Line())
+		call.Func.SetLastLine(e.LastLine())
+		return call
+	case *ast.AttrGetExpr:
+		e.Object, e.Key = checkConcatExpr(e.Object), checkConcatExpr(e.Key)
+	case *ast.TableExpr:
+		for _, field := range e.Fields {
+			if field.Key != nil {
+				field.Key = checkConcatExpr(field.Key)
+			}
+			field.Value = checkConcatExpr(field.Value)
+		}
+	case *ast.FuncCallExpr:
+		if e.Func != nil {
+			e.Func = checkConcatExpr(e.Func)
+		}
+		if e.Receiver != nil {
+			e.Receiver = checkConcatExpr(e.Receiver)
+		}
+		checkConcatExprs(e.Args)
+	case *ast.LogicalOpExpr:
+		e.Lhs, e.Rhs = checkConcatExpr(e.Lhs), checkConcatExpr(e.Rhs)
+	case *ast.RelationalOpExpr:
+		e.Lhs, e.Rhs = checkConcatExpr(e.Lhs), checkConcatExpr(e.Rhs)
+	case *ast.ArithmeticOpExpr:
+		e.Lhs, e.Rhs = checkConcatExpr(e.Lhs), checkConcatExpr(e.Rhs)
+	case *ast.UnaryMinusOpExpr:
+		e.Expr = checkConcatExpr(e.Expr)
+	case *ast.UnaryNotOpExpr:
+		e.Expr = checkConcatExpr(e.Expr)
+	case *ast.UnaryLenOpExpr:
+		e.Expr = checkConcatExpr(e.Expr)
+	case *ast.FunctionExpr:
+		checkConcat(e.Stmts)
+	}
+	return expr
+}
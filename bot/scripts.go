@@ -0,0 +1,295 @@
+// scripts.go handles custom commands backed by a Lua script. Scripts run in a sandbox that only has Lua's base, string,
+// table and math libraries along with a small API for talking to chat, so they can't reach the filesystem or network.
+// A script is stopped once it runs longer than the bot's ScriptTimeout, and the strings it can build are limited as
+// described in scriptlimits.go.
+//
+// The API given to a script:
+//
+//	args            the words after the command, e.g. {"2", "6"} for '!roll 2 6'
+//	user            the sender, with name, perm and moderator fields
+//	command         the command's name, e.g. "!roll"
+//	send(msg)       sends a message to chat, a string returned by the script is also sent
+//	store.get(key)  reads a value the command stored, or nil
+//	store.set(key, value), store.delete(key)
+//	now()           the current unix time in seconds
+//	duration(secs)  formats seconds as e.g. "1h2m3s"
+//	chatters()      the users that have chatted in the last 10 minutes, at most the 100 most recent
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	defaultScriptTimeout = 250 * time.Millisecond
+	scriptMaxMessages    = 3   // messages a single run of a script can send
+	scriptMaxValueSize   = 500 // length of a value a script can store
+	scriptMaxStoreKeys   = 100 // keys a single command can store
+	scriptMaxChatters    = 100 // users chatters() returns
+)
+
+var errNoStorage = errors.New("scripts need a database to store values")
+
+// unsafeBaseFunctions are removed from a script's base library, since they load code or reach outside the sandbox
+var unsafeBaseFunctions = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "print",
+	"collectgarbage", "setfenv", "getfenv", "newproxy"}
+
+// SetCommandScript sets the Lua script run by a command, an empty source removes the command's script so it goes back
+// to sending its response
+func (bot *Bot) SetCommandScript(name, source string) error {
+	command, ok := bot.Commands[name]
+	if !ok {
+		return NonFatalError{Err: fmt.Errorf("could not find command with key '%s'", name)}
+	}
+
+	if source != "" {
+		if _, err := compileScript(name, source); err != nil {
+			return NonFatalError{Err: fmt.Errorf("the script for %s does not compile: %v", name, err)}
+		}
+	}
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Delete("command_scripts", "commandname", name)
+		if err == nil && source != "" {
+			err = bot.Storage.DB.Insert("command_scripts", []string{"commandname", "script"}, []string{name, source})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	command.Script = source
+	return nil
+}
+
+// LoadScripts gives each command the script it has in storage
+func (bot *Bot) LoadScripts() error {
+	rows, err := bot.Storage.DB.Query("select commandname, script from command_scripts")
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var name, source string
+		if err = rows.Scan(&name, &source); err != nil {
+			return err
+		}
+		if command, ok := bot.Commands[name]; ok {
+			command.Script = source
+		}
+	}
+	return nil
+}
+
+// RunScript runs the script for the command item calls, and returns the messages it sends to chat
+func (bot *Bot) RunScript(item Item) ([]string, error) {
+	command, ok := bot.Commands[item.Type]
+	if !ok || command.Script == "" {
+		return nil, NonFatalError{Err: fmt.Errorf("the command '%s' has no script", item.Type)}
+	}
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true, CallStackSize: 64, RegistrySize: 1024, RegistryMaxSize: 64 * 1024})
+	defer L.Close()
+
+	timeout := bot.ScriptTimeout
+	if timeout <= 0 {
+		timeout = defaultScriptTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	var messages []string
+	send := func(msg string) error {
+		if len(messages) >= scriptMaxMessages {
+			return fmt.Errorf("a script can only send %d messages", scriptMaxMessages)
+		}
+		messages = append(messages, msg)
+		return nil
+	}
+	proto, err := compileScript(item.Type, command.Script)
+	if err != nil {
+		return nil, NonFatalError{Err: fmt.Errorf("the script for %s does not compile: %v", item.Type, err)}
+	}
+	openSandbox(L)
+	limitStrings(L, &scriptBudget{})
+	bot.setScriptGlobals(L, item, send)
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err = L.PCall(0, lua.MultRet, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, NonFatalError{Err: fmt.Errorf("the script for %s took longer than %s", item.Type, timeout)}
+		}
+		return nil, NonFatalError{Err: fmt.Errorf("the script for %s failed: %v", item.Type, err)}
+	}
+	if result, ok := L.Get(-1).(lua.LString); ok && L.GetTop() > 0 && result != "" {
+		if err = send(string(result)); err != nil {
+			return nil, NonFatalError{Err: err}
+		}
+	}
+	return messages, nil
+}
+
+// openSandbox opens the libraries a script may use, without anything that reaches outside the sandbox
+func openSandbox(L *lua.LState) {
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	for _, name := range unsafeBaseFunctions {
+		L.SetGlobal(name, lua.LNil)
+	}
+}
+
+// setScriptGlobals gives a script the bot's API for running item
+func (bot *Bot) setScriptGlobals(L *lua.LState, item Item, send func(string) error) {
+	args := L.NewTable()
+	for _, arg := range strings.Fields(strings.Join([]string{item.Command, item.Key, item.Contents}, " ")) {
+		args.Append(lua.LString(arg))
+	}
+	L.SetGlobal("args", args)
+
+	user := L.NewTable()
+	L.SetField(user, "name", lua.LString(item.Sender.Name))
+	L.SetField(user, "perm", lua.LNumber(item.Sender.Perm))
+	L.SetField(user, "moderator", lua.LBool(item.Sender.IsModerator()))
+	L.SetGlobal("user", user)
+	L.SetGlobal("command", lua.LString(item.Type))
+
+	L.SetGlobal("send", L.NewFunction(func(L *lua.LState) int {
+		if err := send(L.CheckString(1)); err != nil {
+			L.RaiseError("%v", err)
+		}
+		return 0
+	}))
+	L.SetGlobal("now", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LNumber(time.Now().Unix()))
+		return 1
+	}))
+	L.SetGlobal("duration", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString((time.Duration(L.CheckInt64(1)) * time.Second).String()))
+		return 1
+	}))
+	L.SetGlobal("chatters", L.NewFunction(func(L *lua.LState) int {
+		names := make([]string, 0, len(bot.lastMessages))
+		for name, tracker := range bot.lastMessages {
+			if time.Since(tracker.sent) < repeatWindow {
+				names = append(names, name)
+			}
+		}
+		if len(names) > scriptMaxChatters {
+			sort.Slice(names, func(i, j int) bool {
+				return bot.lastMessages[names[i]].sent.After(bot.lastMessages[names[j]].sent)
+			})
+			names = names[:scriptMaxChatters]
+		}
+		sort.Strings(names)
+		chatters := L.NewTable()
+		for _, name := range names {
+			chatters.Append(lua.LString(name))
+		}
+		L.Push(chatters)
+		return 1
+	}))
+
+	store := L.NewTable()
+	L.SetField(store, "get", L.NewFunction(func(L *lua.LState) int {
+		value, found, err := bot.scriptValue(item.Type, L.CheckString(1))
+		if err != nil {
+			L.RaiseError("%v", err)
+		}
+		if !found {
+			L.Push(lua.LNil)
+		} else {
+			L.Push(lua.LString(value))
+		}
+		return 1
+	}))
+	L.SetField(store, "set", L.NewFunction(func(L *lua.LState) int {
+		if err := bot.setScriptValue(item.Type, L.CheckString(1), L.CheckString(2)); err != nil {
+			L.RaiseError("%v", err)
+		}
+		return 0
+	}))
+	L.SetField(store, "delete", L.NewFunction(func(L *lua.LState) int {
+		if bot.Storage == nil {
+			L.RaiseError("%v", errNoStorage)
+		}
+		err := bot.Storage.DB.ArbitraryExec("delete from script_data where commandname = ? and key = ?", item.Type, L.CheckString(1))
+		if err != nil {
+			L.RaiseError("%v", err)
+		}
+		return 0
+	}))
+	L.SetGlobal("store", store)
+}
+
+// scriptValue reads a value stored by a command's script
+func (bot *Bot) scriptValue(command, key string) (string, bool, error) {
+	if bot.Storage == nil {
+		return "", false, errNoStorage
+	}
+	rows, err := bot.Storage.DB.Query("select value from script_data where commandname = ? and key = ?", command, key)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", false, rows.Err()
+	}
+	var value string
+	err = rows.Scan(&value)
+	return value, err == nil, err
+}
+
+// setScriptValue stores a value for a command's script
+func (bot *Bot) setScriptValue(command, key, value string) error {
+	if bot.Storage == nil {
+		return errNoStorage
+	}
+	if len(value) > scriptMaxValueSize {
+		return fmt.Errorf("stored values can't be longer than %d", scriptMaxValueSize)
+	}
+
+	if _, found, err := bot.scriptValue(command, key); err != nil {
+		return err
+	} else if found {
+		return bot.Storage.DB.ArbitraryExec("update script_data set value = ? where commandname = ? and key = ?", value, command, key)
+	}
+
+	rows, err := bot.Storage.DB.Query("select count(*) from script_data where commandname = ?", command)
+	if err != nil {
+		return err
+	}
+	var count int
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if count >= scriptMaxStoreKeys {
+		return fmt.Errorf("a command can only store %d values", scriptMaxStoreKeys)
+	}
+	return bot.Storage.DB.Insert("script_data", []string{"commandname", "key", "value"}, []string{command, key, value})
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScript(t *testing.T) {
+	database := newTestDatabase(t)
+
+	tests := []struct {
+		description  string
+		script       string
+		inputItem    Item
+		wantMessages []string
+		wantErr      string
+	}{
+		{
+			description:  "should send the returned string",
+			script:       `return "hi " .. user.name .. ", you rolled " .. args[1]`,
+			inputItem:    Item{Type: "!test", Command: "6", Sender: User{Name: "viewer"}},
+			wantMessages: []string{"hi viewer, you rolled 6"},
+		},
+		{
+			description:  "should send messages and remember values between runs",
+			script:       `local count = tonumber(store.get("count") or "0") + 1; store.set("count", tostring(count)); send("run " .. count)`,
+			inputItem:    Item{Type: "!test"},
+			wantMessages: []string{"run 1"},
+		},
+		{
+			description: "should not be able to reach the filesystem",
+			script:      `return io.open("/etc/passwd")`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "failed",
+		},
+		{
+			description: "should not be able to load code",
+			script:      `return loadstring("return 1")()`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "failed",
+		},
+		{
+			description: "should stop a script that runs too long",
+			script:      `while true do end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "took longer than",
+		},
+		{
+			description: "should limit how many messages are sent",
+			script:      `for i = 1, 10 do send("spam") end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can only send",
+		},
+		{
+			description: "should limit string.rep",
+			script:      `return string.rep("a", 100000)`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "string.rep",
+		},
+		{
+			description: "should not overflow the string.rep limit",
+			script:      `return string.rep("ab", 2^62)`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "string.rep",
+		},
+		{
+			description: "should stop a string from doubling",
+			script:      `local s = "a" while true do s = s .. s end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can't build strings longer than",
+		},
+		{
+			description: "should stop a script building too many strings",
+			script:      `local t, s = {}, string.rep("a", 4000) for i = 1, 1e9 do t[i] = s .. i end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can only build",
+		},
+		{
+			description: "should check the concatenation inside functions",
+			script:      `local function grow(s) return s .. s end local s = "a" while true do s = grow(s) end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can't build strings longer than",
+		},
+		{
+			description: "should not let a script swap out the checked concatenation",
+			script:      `_G["(concat)"] = function(a, b) return "" end local s = "a" while true do s = s .. s end`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can't build strings longer than",
+		},
+		{
+			description: "should limit table.concat",
+			script:      `local t = {} for i = 1, 1000 do t[i] = "abcdefgh" end return table.concat(t)`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "table.concat",
+		},
+		{
+			description: "should limit string.gsub",
+			script:      `local s = string.rep("a", 100) return (s:gsub("a", s))`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "can't build strings longer than",
+		},
+		{
+			description: "should refuse huge widths in string.format",
+			script:      `return string.format("%999999999s", "a")`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "width or precision too long",
+		},
+		{
+			description:  "should still concatenate numbers, __concat and library strings",
+			script:       `local t = setmetatable({}, {__concat = function(a, b) return "t" .. b end}) return (t .. 1) .. " " .. 2.5 .. " " .. table.concat({"a", "b"}, ",") .. " " .. ("x"):rep(3)`,
+			inputItem:    Item{Type: "!test"},
+			wantMessages: []string{"t1 2.5 a,b xxx"},
+		},
+		{
+			description: "should still fail to concatenate nil",
+			script:      `return "a" .. nil`,
+			inputItem:   Item{Type: "!test"},
+			wantErr:     "failed",
+		},
+	}
+
+	for _, test := range tests {
+		bot := &Bot{Storage: database, ScriptTimeout: 50 * time.Millisecond,
+			Commands: map[string]*CommandValue{"!test": {Perm: "all"}}}
+		if err := bot.SetCommandScript("!test", test.script); err != nil {
+			t.Fatalf("%s\ncould not set the script: %v", test.description, err)
+		}
+
+		messages, err := bot.RunScript(test.inputItem)
+		if !reflect.DeepEqual(messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messages, test.wantMessages)
+		}
+		if (err == nil && test.wantErr != "") || (err != nil && (test.wantErr == "" || !strings.Contains(err.Error(), test.wantErr))) {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant - %v", test.description, err, test.wantErr)
+		}
+	}
+
+	// a second bot loading from storage should get the script and the stored count
+	bot := &Bot{Storage: database, Commands: map[string]*CommandValue{"!test": {Perm: "all"}}}
+	if err := bot.SetCommandScript("!test", `store.set("count", tostring(tonumber(store.get("count")) + 1))`); err != nil {
+		t.Fatalf("could not set the script: %v", err)
+	}
+	bot.Commands["!test"].Script = ""
+	if err := bot.LoadScripts(); err != nil || bot.Commands["!test"].Script == "" {
+		t.Fatalf("did not load the script: %v", err)
+	}
+	bot.RunScript(Item{Type: "!test"})
+	if value, _, _ := bot.scriptValue("!test", "count"); value != "2" {
+		t.Errorf("did not get the expected stored value\ngot - %v\nwant - %v", value, "2")
+	}
+}
+
+func TestScriptChatters(t *testing.T) {
+	database := newTestDatabase(t)
+
+	bot := &Bot{Storage: database, Commands: map[string]*CommandValue{"!test": {Perm: "all"}},
+		lastMessages: map[string]*repeatTracker{"gone": {sent: time.Now().Add(-repeatWindow)}}}
+	for i := 0; i < 150; i++ {
+		bot.lastMessages[fmt.Sprintf("user%03d", i)] = &repeatTracker{sent: time.Now().Add(-time.Duration(i) * time.Second)}
+	}
+	if err := bot.SetCommandScript("!test", `local c = chatters() return #c .. " " .. c[1] .. " " .. c[#c]`); err != nil {
+		t.Fatalf("could not set the script: %v", err)
+	}
+
+	// only the 100 most recent chatters are given, the user who stopped chatting isn't
+	want := []string{"100 user000 user099"}
+	if messages, err := bot.RunScript(Item{Type: "!test"}); err != nil || !reflect.DeepEqual(messages, want) {
+		t.Errorf("did not get the expected chatters\ngot - %v %v\nwant - %v", messages, err, want)
+	}
+}
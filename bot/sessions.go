@@ -0,0 +1,299 @@
+// sessions.go handles stream sessions, which run from when the stream goes live until it goes offline. Chat activity
+// is tallied while a session is live, and when it ends a summary is stored that can be exported as Markdown or JSON.
+
+package bot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTopCommands is how many of the most used commands a session summary lists
+const maxTopCommands = 5
+
+var errNoSession = errors.New("the stream isn't live")
+
+// Session is a single stream, it is also the data for an EventSession
+type Session struct {
+	ID        int64           `json:"id"`
+	Started   time.Time       `json:"started"`
+	Ended     time.Time       `json:"ended,omitempty"` // zero while the session is live
+	StartedBy string          `json:"started_by"`
+	Summary   *SessionSummary `json:"summary,omitempty"` // set once the session has ended
+	lastQuote int             // the highest quote ID when the session started, later quotes were added during it
+	messages  int64
+	chatters  map[string]bool
+	commands  map[string]int
+}
+
+// SessionSummary sums up what happened during a session
+type SessionSummary struct {
+	Minutes           int               `json:"minutes"`
+	Messages          int64             `json:"messages"`
+	UniqueChatters    int               `json:"unique_chatters"`
+	MessagesPerMinute float64           `json:"messages_per_minute"`
+	TopCommands       []CommandUses     `json:"top_commands"`
+	NewChatters       []string          `json:"new_chatters"`
+	ModActions        map[ModAction]int `json:"mod_actions"`
+	QuotesAdded       int               `json:"quotes_added"`
+}
+
+// CommandUses is how many times a command was used
+type CommandUses struct {
+	Command string `json:"command"`
+	Uses    int    `json:"uses"`
+}
+
+// LoadSession picks up the session that was live when the bot last stopped, if there was one. Its tallies start
+// again from 0, since they are only kept in memory.
+func (bot *Bot) LoadSession() error {
+	rows, err := bot.Storage.DB.Query("select id, started, started_by, last_quote from sessions where ended = '' order by id desc limit 1")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		session := newSession()
+		var started string
+		if err = rows.Scan(&session.ID, &started, &session.StartedBy, &session.lastQuote); err != nil {
+			return err
+		}
+		session.Started, _ = time.Parse(time.RFC3339, started)
+		bot.Session = session
+	}
+	return rows.Err()
+}
+
+func newSession() *Session {
+	return &Session{chatters: make(map[string]bool), commands: make(map[string]int)}
+}
+
+// StartSession starts a new session and resets the counters that are kept per stream
+func (bot *Bot) StartSession(startedBy string) (*Session, error) {
+	if bot.Session != nil {
+		return nil, NonFatalError{Err: fmt.Errorf("the stream is already live, end it with !offline")}
+	}
+
+	session := newSession()
+	session.Started, session.StartedBy = time.Now(), startedBy
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		err := tx.QueryRow("select coalesce(max(id), 0) from quotes").Scan(&session.lastQuote)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec("insert into sessions (started, ended, started_by, last_quote, summary) values (?, '', ?, ?, '')",
+			session.Started.Format(time.RFC3339), startedBy, session.lastQuote)
+		if err != nil {
+			return err
+		}
+		session.ID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bot.Session = session
+	if _, err = bot.ResetStreamCounters(); err != nil {
+		return session, err
+	}
+	bot.Publish(EventSession, *session)
+	return session, nil
+}
+
+// TallySession counts a chat message or command event towards the live session, other events are ignored
+func (bot *Bot) TallySession(event Event) {
+	session := bot.Session
+	if session == nil {
+		return
+	}
+	switch data := event.Data.(type) {
+	case Item:
+		session.messages++
+		session.chatters[strings.ToLower(data.Sender.Name)] = true
+	case CommandEvent:
+		if data.Error == "" {
+			session.commands[data.Type]++
+		}
+	}
+}
+
+// EndSession ends the live session and stores its summary
+func (bot *Bot) EndSession() (*Session, error) {
+	session := bot.Session
+	if session == nil {
+		return nil, NonFatalError{Err: errNoSession}
+	}
+
+	session.Ended = time.Now()
+	summary, err := bot.summarize(session)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	err = bot.Storage.DB.ArbitraryExec("update sessions set ended = ?, summary = ? where id = ?",
+		session.Ended.Format(time.RFC3339), string(encoded), session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Summary = &summary
+	bot.Session = nil
+	bot.Publish(EventSession, *session)
+	return session, nil
+}
+
+// summarize sums up a session. Messages, chatters and commands come from its tallies, while new chatters, moderation
+// actions and quotes are counted from the database.
+func (bot *Bot) summarize(session *Session) (SessionSummary, error) {
+	summary := SessionSummary{Messages: session.messages, UniqueChatters: len(session.chatters),
+		TopCommands: []CommandUses{}, NewChatters: []string{}, ModActions: make(map[ModAction]int)}
+	length := session.Ended.Sub(session.Started)
+	summary.Minutes = int(length.Minutes())
+	if length >= time.Minute {
+		summary.MessagesPerMinute = math.Round(float64(session.messages)/length.Minutes()*100) / 100
+	}
+
+	for command, uses := range session.commands {
+		summary.TopCommands = append(summary.TopCommands, CommandUses{Command: command, Uses: uses})
+	}
+	sort.Slice(summary.TopCommands, func(i, j int) bool {
+		if summary.TopCommands[i].Uses != summary.TopCommands[j].Uses {
+			return summary.TopCommands[i].Uses > summary.TopCommands[j].Uses
+		}
+		return summary.TopCommands[i].Command < summary.TopCommands[j].Command
+	})
+	if len(summary.TopCommands) > maxTopCommands {
+		summary.TopCommands = summary.TopCommands[:maxTopCommands]
+	}
+
+	err := bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		rows, err := tx.Query("select username from chatters where first_seen >= ? and first_seen <= ? order by first_seen",
+			session.Started.Format(time.RFC3339), session.Ended.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			summary.NewChatters = append(summary.NewChatters, name)
+		}
+		rows.Close()
+
+		rows, err = tx.Query("select action, count(*) from modlog where timestamp >= ? and timestamp <= ? group by action",
+			session.Started.Format(modLogTimeFormat), session.Ended.Format(modLogTimeFormat))
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var action string
+			var count int
+			if err = rows.Scan(&action, &count); err != nil {
+				rows.Close()
+				return err
+			}
+			summary.ModActions[ModAction(action)] = count
+		}
+		rows.Close()
+
+		return tx.QueryRow("select count(*) from quotes where id > ?", session.lastQuote).Scan(&summary.QuotesAdded)
+	})
+	return summary, err
+}
+
+// Sessions returns the last limit sessions, newest first
+func (bot *Bot) Sessions(limit int) ([]Session, error) {
+	return bot.querySessions("select id, started, ended, started_by, summary from sessions order by id desc limit ?", limit)
+}
+
+// GetSession returns the session with the given ID
+func (bot *Bot) GetSession(id int64) (Session, error) {
+	sessions, err := bot.querySessions("select id, started, ended, started_by, summary from sessions where id = ?", id)
+	if err != nil {
+		return Session{}, err
+	}
+	if len(sessions) == 0 {
+		return Session{}, NonFatalError{Err: fmt.Errorf("there is no session with the ID %d", id)}
+	}
+	return sessions[0], nil
+}
+
+func (bot *Bot) querySessions(query string, args ...interface{}) ([]Session, error) {
+	rows, err := bot.Storage.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var started, ended, summary string
+		if err = rows.Scan(&session.ID, &started, &ended, &session.StartedBy, &summary); err != nil {
+			return nil, err
+		}
+		session.Started, _ = time.Parse(time.RFC3339, started)
+		session.Ended, _ = time.Parse(time.RFC3339, ended)
+		if summary != "" {
+			session.Summary = &SessionSummary{}
+			if err = json.Unmarshal([]byte(summary), session.Summary); err != nil {
+				return nil, err
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Markdown writes up a session as a Markdown report
+func (session Session) Markdown() string {
+	const stamp = "2006-01-02 15:04"
+	var report strings.Builder
+	fmt.Fprintf(&report, "# Stream session %d\n\n", session.ID)
+	fmt.Fprintf(&report, "- Started: %s by %s\n", session.Started.Format(stamp), session.StartedBy)
+	if session.Summary == nil {
+		report.WriteString("- Still live\n")
+		return report.String()
+	}
+
+	summary := session.Summary
+	fmt.Fprintf(&report, "- Ended: %s (%d minutes)\n", session.Ended.Format(stamp), summary.Minutes)
+	fmt.Fprintf(&report, "- Messages: %d (%.2f per minute)\n", summary.Messages, summary.MessagesPerMinute)
+	fmt.Fprintf(&report, "- Unique chatters: %d\n", summary.UniqueChatters)
+	fmt.Fprintf(&report, "- New chatters: %d\n", len(summary.NewChatters))
+	fmt.Fprintf(&report, "- Quotes added: %d\n", summary.QuotesAdded)
+
+	if len(summary.TopCommands) > 0 {
+		report.WriteString("\n## Top commands\n\n| Command | Uses |\n| --- | --- |\n")
+		for _, command := range summary.TopCommands {
+			fmt.Fprintf(&report, "| %s | %d |\n", command.Command, command.Uses)
+		}
+	}
+	if len(summary.NewChatters) > 0 {
+		fmt.Fprintf(&report, "\n## New chatters\n\n%s\n", strings.Join(summary.NewChatters, ", "))
+	}
+	if len(summary.ModActions) > 0 {
+		report.WriteString("\n## Moderation\n\n| Action | Count |\n| --- | --- |\n")
+		actions := make([]string, 0, len(summary.ModActions))
+		for action := range summary.ModActions {
+			actions = append(actions, string(action))
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			fmt.Fprintf(&report, "| %s | %d |\n", action, summary.ModActions[ModAction(action)])
+		}
+	}
+	return report.String()
+}
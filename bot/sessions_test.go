@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	database := newTestDatabase(t,
+		"INSERT INTO quotes (quote, timestamp, submitter) VALUES ('an old quote', '2022-01-01', 'someone')",
+		"INSERT INTO chatters VALUES ('1', 'regular', '2022-01-01T00:00:00Z', '2022-01-01T00:00:00Z', 50)",
+		"INSERT INTO counters VALUES ('deaths', 12, 1)")
+	bot := &Bot{Storage: database, Counters: map[string]*Counter{"deaths": {Name: "deaths", Value: 12, PerStream: true}}}
+
+	if _, err := bot.EndSession(); !errors.As(err, &NonFatalError{}) {
+		t.Errorf("ended a session while offline, got - %v", err)
+	}
+	if _, err := bot.StartSession("somemod"); err != nil {
+		t.Fatalf("could not start a session: %v", err)
+	}
+	if _, err := bot.StartSession("somemod"); !errors.As(err, &NonFatalError{}) {
+		t.Errorf("started a second session, got - %v", err)
+	}
+	if bot.Counters["deaths"].Value != 0 {
+		t.Errorf("the per stream counter was not reset, got - %d", bot.Counters["deaths"].Value)
+	}
+
+	// a session picked up after a restart keeps its ID and start
+	restarted := &Bot{Storage: database}
+	if err := restarted.LoadSession(); err != nil || restarted.Session == nil || restarted.Session.ID != bot.Session.ID {
+		t.Errorf("did not load the live session, got - %+v %v", restarted.Session, err)
+	}
+
+	bot.Session.Started = bot.Session.Started.Add(-10 * time.Minute)
+	events := []Event{
+		{Data: Item{Sender: User{ID: "1", Name: "regular"}, Contents: "hi"}},
+		{Data: Item{Sender: User{ID: "2", Name: "newbie"}, Contents: "first time here"}},
+		{Data: Item{Sender: User{ID: "1", Name: "Regular"}, Type: "!quote"}},
+		{Data: CommandEvent{Type: "!quote", User: "regular"}},
+		{Data: CommandEvent{Type: "!quote", User: "newbie"}},
+		{Data: CommandEvent{Type: "!hug", User: "newbie"}},
+		{Data: CommandEvent{Type: "!com", User: "newbie", Error: "you can't do that"}},
+		{Data: TimerEvent{Name: "ignored"}},
+	}
+	for _, event := range events {
+		bot.TallySession(event)
+	}
+	now := time.Now().Format(time.RFC3339)
+	database.DB.ArbitraryExec("insert into chatters values ('2', 'newbie', ?, ?, 2)", now, now)
+	database.DB.ArbitraryExec("insert into quotes (quote, timestamp, submitter) values ('a new quote', '2022-01-02', 'newbie')")
+	bot.LogModAction(ModLogEntry{Action: ModActionTimeout, Actor: "somemod", Target: "spammer"})
+
+	session, err := bot.EndSession()
+	if err != nil {
+		t.Fatalf("could not end the session: %v", err)
+	}
+	want := SessionSummary{Minutes: 10, Messages: 3, UniqueChatters: 2, MessagesPerMinute: 0.3,
+		TopCommands: []CommandUses{{Command: "!quote", Uses: 2}, {Command: "!hug", Uses: 1}}, NewChatters: []string{"newbie"},
+		ModActions: map[ModAction]int{ModActionTimeout: 1}, QuotesAdded: 1}
+	if !reflect.DeepEqual(*session.Summary, want) {
+		t.Errorf("did not get the expected summary\ngot - %+v\nwant - %+v", *session.Summary, want)
+	}
+	if bot.Session != nil {
+		t.Errorf("the session is still live after ending it")
+	}
+
+	stored, err := bot.GetSession(session.ID)
+	if err != nil || stored.Summary == nil || !reflect.DeepEqual(*stored.Summary, want) || stored.StartedBy != "somemod" {
+		t.Errorf("did not read back the expected session, got - %+v %v", stored, err)
+	}
+	if report := stored.Markdown(); !strings.Contains(report, "| !quote | 2 |") || !strings.Contains(report, "- Unique chatters: 2") {
+		t.Errorf("did not get the expected report, got - %s", report)
+	}
+	if sessions, err := bot.Sessions(10); err != nil || len(sessions) != 1 {
+		t.Errorf("did not list the expected sessions, got - %+v %v", sessions, err)
+	}
+	if _, err = bot.GetSession(99); !errors.As(err, &NonFatalError{}) {
+		t.Errorf("got a session that doesn't exist, got - %v", err)
+	}
+}
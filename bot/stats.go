@@ -0,0 +1,305 @@
+// stats.go keeps chat statistics: how many messages each user sends in each hour of each day, and how often each emote
+// is used per day. Together with the use counts of custom commands these make up the channel's stats and leaderboards,
+// which can also be exported as CSV.
+
+package bot
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	statsDayFormat = "2006-01-02"
+	maxStatsTop    = 10 // how many users, emotes and commands the channel's stats list
+)
+
+// StatsKinds are the tables of stats that can be exported
+var StatsKinds = []string{"days", "hours", "chatters", "emotes", "commands"}
+
+// ChatStats sums up the channel's chat since a point in time
+type ChatStats struct {
+	Messages    int64          `json:"messages"`
+	Chatters    int            `json:"chatters"`
+	Days        []DayStats     `json:"days"`  // oldest first, only days with messages are included
+	Hours       [24]int64      `json:"hours"` // messages sent during each hour of the day
+	TopChatters []ChatterStats `json:"top_chatters"`
+	TopEmotes   []EmoteStats   `json:"top_emotes"`
+	TopCommands []CommandUses  `json:"top_commands"` // custom commands by how often they've ever been used
+}
+
+// DayStats is the chat activity of a single day
+type DayStats struct {
+	Day      string `json:"day"` // e.g. 2022-01-31
+	Messages int64  `json:"messages"`
+	Chatters int    `json:"chatters"`
+}
+
+// ChatterStats is how many messages a user has sent
+type ChatterStats struct {
+	User     string `json:"user"`
+	Messages int64  `json:"messages"`
+}
+
+// EmoteStats is how many times an emote has been used
+type EmoteStats struct {
+	Emote string `json:"emote"`
+	Uses  int64  `json:"uses"`
+}
+
+// RecordStats counts a chat message and its emotes towards the stats for the hour it was sent in
+func (bot *Bot) RecordStats(item Item, sent time.Time) error {
+	if item.Sender.Name == "" || bot.Storage == nil {
+		return nil
+	}
+	day := sent.Format(statsDayFormat)
+	emotes := make(map[string]int64)
+	for _, emote := range item.Emotes {
+		emotes[emote]++
+	}
+
+	return bot.Storage.DB.Transaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`insert into chat_stats (day, hour, username, messages) values (?, ?, ?, 1)
+			on conflict (day, hour, username) do update set messages = messages + 1`, day, sent.Hour(),
+			strings.ToLower(item.Sender.Name))
+		if err != nil {
+			return err
+		}
+		for emote, uses := range emotes {
+			_, err = tx.Exec(`insert into emote_stats (day, emote, uses) values (?, ?, ?)
+				on conflict (day, emote) do update set uses = uses + excluded.uses`, day, emote, uses)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stats sums up the chat since the given time, which is counted by day. A zero since covers everything.
+func (bot *Bot) Stats(since time.Time) (ChatStats, error) {
+	var stats ChatStats
+	var err error
+	if stats.Days, err = bot.DailyStats(since); err != nil {
+		return stats, err
+	}
+	if stats.Hours, err = bot.HourlyStats(since); err != nil {
+		return stats, err
+	}
+	if stats.TopChatters, err = bot.TopChatters(since, maxStatsTop); err != nil {
+		return stats, err
+	}
+	if stats.TopEmotes, err = bot.TopEmotes(since, maxStatsTop); err != nil {
+		return stats, err
+	}
+	stats.TopCommands = bot.TopCommands(maxStatsTop)
+
+	for _, day := range stats.Days {
+		stats.Messages += day.Messages
+	}
+	rows, err := bot.Storage.DB.Query("select count(distinct username) from chat_stats where day >= ?", statsDay(since))
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err = rows.Scan(&stats.Chatters)
+	}
+	return stats, err
+}
+
+// DailyStats returns the messages and chatters of each day since the given time, oldest first
+func (bot *Bot) DailyStats(since time.Time) ([]DayStats, error) {
+	rows, err := bot.Storage.DB.Query(`select day, sum(messages), count(distinct username) from chat_stats
+		where day >= ? group by day order by day`, statsDay(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DayStats{}
+	for rows.Next() {
+		var day DayStats
+		if err = rows.Scan(&day.Day, &day.Messages, &day.Chatters); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// HourlyStats returns how many messages have been sent during each hour of the day since the given time
+func (bot *Bot) HourlyStats(since time.Time) ([24]int64, error) {
+	var hours [24]int64
+	rows, err := bot.Storage.DB.Query("select hour, sum(messages) from chat_stats where day >= ? group by hour",
+		statsDay(since))
+	if err != nil {
+		return hours, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour int
+		var messages int64
+		if err = rows.Scan(&hour, &messages); err != nil {
+			return hours, err
+		}
+		if hour >= 0 && hour < len(hours) {
+			hours[hour] = messages
+		}
+	}
+	return hours, rows.Err()
+}
+
+// UserStats returns the messages a user has sent on each day since the given time, oldest first
+func (bot *Bot) UserStats(user string, since time.Time) ([]DayStats, error) {
+	rows, err := bot.Storage.DB.Query(`select day, sum(messages) from chat_stats where username = ? and day >= ?
+		group by day order by day`, strings.ToLower(strings.TrimPrefix(user, "@")), statsDay(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DayStats{}
+	for rows.Next() {
+		day := DayStats{Chatters: 1}
+		if err = rows.Scan(&day.Day, &day.Messages); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// TopChatters returns the users who have sent the most messages since the given time, every user if limit is 0
+func (bot *Bot) TopChatters(since time.Time, limit int) ([]ChatterStats, error) {
+	query := "select username, sum(messages) as total from chat_stats where day >= ? group by username order by total desc, username"
+	if limit > 0 {
+		query += fmt.Sprintf(" limit %d", limit)
+	}
+	rows, err := bot.Storage.DB.Query(query, statsDay(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chatters := []ChatterStats{}
+	for rows.Next() {
+		var chatter ChatterStats
+		if err = rows.Scan(&chatter.User, &chatter.Messages); err != nil {
+			return nil, err
+		}
+		chatters = append(chatters, chatter)
+	}
+	return chatters, rows.Err()
+}
+
+// TopEmotes returns the emotes used the most since the given time, every emote if limit is 0
+func (bot *Bot) TopEmotes(since time.Time, limit int) ([]EmoteStats, error) {
+	query := "select emote, sum(uses) as total from emote_stats where day >= ? group by emote order by total desc, emote"
+	if limit > 0 {
+		query += fmt.Sprintf(" limit %d", limit)
+	}
+	rows, err := bot.Storage.DB.Query(query, statsDay(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emotes := []EmoteStats{}
+	for rows.Next() {
+		var emote EmoteStats
+		if err = rows.Scan(&emote.Emote, &emote.Uses); err != nil {
+			return nil, err
+		}
+		emotes = append(emotes, emote)
+	}
+	return emotes, rows.Err()
+}
+
+// TopCommands returns the custom commands that have been used the most, every used command if limit is 0
+func (bot *Bot) TopCommands(limit int) []CommandUses {
+	commands := []CommandUses{}
+	for name, com := range bot.Commands {
+		if com.Count > 0 {
+			commands = append(commands, CommandUses{Command: name, Uses: com.Count})
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if commands[i].Uses != commands[j].Uses {
+			return commands[i].Uses > commands[j].Uses
+		}
+		return commands[i].Command < commands[j].Command
+	})
+	if limit > 0 && len(commands) > limit {
+		commands = commands[:limit]
+	}
+	return commands
+}
+
+// WriteStatsCSV writes one of the StatsKinds of stats since the given time to w as CSV, with a header row
+func (bot *Bot) WriteStatsCSV(w io.Writer, kind string, since time.Time) error {
+	var records [][]string
+	switch kind {
+	case "days":
+		days, err := bot.DailyStats(since)
+		if err != nil {
+			return err
+		}
+		records = append(records, []string{"day", "messages", "chatters"})
+		for _, day := range days {
+			records = append(records, []string{day.Day, strconv.FormatInt(day.Messages, 10), strconv.Itoa(day.Chatters)})
+		}
+	case "hours":
+		hours, err := bot.HourlyStats(since)
+		if err != nil {
+			return err
+		}
+		records = append(records, []string{"hour", "messages"})
+		for hour, messages := range hours {
+			records = append(records, []string{strconv.Itoa(hour), strconv.FormatInt(messages, 10)})
+		}
+	case "chatters":
+		chatters, err := bot.TopChatters(since, 0)
+		if err != nil {
+			return err
+		}
+		records = append(records, []string{"user", "messages"})
+		for _, chatter := range chatters {
+			records = append(records, []string{chatter.User, strconv.FormatInt(chatter.Messages, 10)})
+		}
+	case "emotes":
+		emotes, err := bot.TopEmotes(since, 0)
+		if err != nil {
+			return err
+		}
+		records = append(records, []string{"emote", "uses"})
+		for _, emote := range emotes {
+			records = append(records, []string{emote.Emote, strconv.FormatInt(emote.Uses, 10)})
+		}
+	case "commands":
+		records = append(records, []string{"command", "uses"})
+		for _, command := range bot.TopCommands(0) {
+			records = append(records, []string{command.Command, strconv.Itoa(command.Uses)})
+		}
+	default:
+		return NonFatalError{Err: fmt.Errorf("'%s' is not a kind of stats, use one of %s", kind, strings.Join(StatsKinds, ", "))}
+	}
+
+	writer := csv.NewWriter(w)
+	return writer.WriteAll(records)
+}
+
+// statsDay returns the first day counted for stats since the given time
+func statsDay(since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+	return since.Format(statsDayFormat)
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	database := newTestDatabase(t,
+		"INSERT INTO commands (commandname, commandresponse, perm, count) VALUES ('!discord', 'join the discord', 'all', 2)",
+		"INSERT INTO commands (commandname, commandresponse, perm, count) VALUES ('lurk', 'enjoy the lurk', 'all', NULL)")
+	bot := &Bot{Storage: database, Commands: make(map[string]*CommandValue)}
+	if err := bot.LoadCommands(); err != nil {
+		t.Fatalf("could not load the commands: %v", err)
+	}
+
+	// commands stored without their '!' are counted too, and the counts are kept in the database
+	for _, command := range []string{"!discord", "!lurk", "!lurk", "!lurk"} {
+		if err := bot.IncrementCommandCount(command); err != nil {
+			t.Fatalf("could not count a command: %v", err)
+		}
+	}
+	bot.Commands = make(map[string]*CommandValue)
+	if err := bot.LoadCommands(); err != nil {
+		t.Fatalf("could not reload the commands: %v", err)
+	}
+	if want := []CommandUses{{Command: "!discord", Uses: 3}, {Command: "!lurk", Uses: 3}}; !reflect.DeepEqual(bot.TopCommands(0), want) {
+		t.Errorf("did not get the expected command uses\ngot - %+v\nwant - %+v", bot.TopCommands(0), want)
+	}
+
+	old := time.Date(2022, 1, 30, 20, 15, 0, 0, time.Local)
+	recent := time.Date(2022, 2, 1, 9, 0, 0, 0, time.Local)
+	records := []struct {
+		item Item
+		sent time.Time
+	}{
+		{Item{Sender: User{Name: "Regular"}, Emotes: []string{"Kappa"}}, old},
+		{Item{Sender: User{Name: "regular"}, Emotes: []string{"Kappa", "Kappa"}}, old.Add(10 * time.Minute)},
+		{Item{Sender: User{Name: "viewer"}}, old},
+		{Item{Sender: User{Name: "viewer"}, Emotes: []string{"PogChamp"}}, recent},
+		{Item{Sender: User{Name: "viewer"}}, recent.Add(time.Hour)},
+		{Item{IsServerInfo: true}, recent},
+	}
+	for _, record := range records {
+		if err := bot.RecordStats(record.item, record.sent); err != nil {
+			t.Fatalf("could not record the stats: %v", err)
+		}
+	}
+
+	stats, err := bot.Stats(time.Time{})
+	if err != nil {
+		t.Fatalf("could not get the stats: %v", err)
+	}
+	wantDays := []DayStats{{Day: "2022-01-30", Messages: 3, Chatters: 2}, {Day: "2022-02-01", Messages: 2, Chatters: 1}}
+	if stats.Messages != 5 || stats.Chatters != 2 || !reflect.DeepEqual(stats.Days, wantDays) || stats.Hours[20] != 3 ||
+		stats.Hours[9] != 1 || stats.Hours[10] != 1 {
+		t.Errorf("did not get the expected stats: %+v", stats)
+	}
+	if want := []ChatterStats{{User: "viewer", Messages: 3}, {User: "regular", Messages: 2}}; !reflect.DeepEqual(stats.TopChatters, want) {
+		t.Errorf("did not get the expected chatters\ngot - %+v\nwant - %+v", stats.TopChatters, want)
+	}
+	if want := []EmoteStats{{Emote: "Kappa", Uses: 3}, {Emote: "PogChamp", Uses: 1}}; !reflect.DeepEqual(stats.TopEmotes, want) {
+		t.Errorf("did not get the expected emotes\ngot - %+v\nwant - %+v", stats.TopEmotes, want)
+	}
+
+	// stats since a time are counted from the start of its day
+	days, err := bot.UserStats("@Viewer", recent.Add(5*time.Hour))
+	if err != nil || !reflect.DeepEqual(days, []DayStats{{Day: "2022-02-01", Messages: 2, Chatters: 1}}) {
+		t.Errorf("did not get the expected user stats, got - %+v %v", days, err)
+	}
+
+	var export bytes.Buffer
+	if err = bot.WriteStatsCSV(&export, "chatters", recent); err != nil {
+		t.Fatalf("could not export the stats: %v", err)
+	}
+	if want := "user,messages\nviewer,2\n"; export.String() != want {
+		t.Errorf("did not get the expected export\ngot - %q\nwant - %q", export.String(), want)
+	}
+	if err = bot.WriteStatsCSV(&export, "followers", recent); !errors.As(err, &NonFatalError{}) {
+		t.Errorf("exported an unknown kind of stats, got - %v", err)
+	}
+}
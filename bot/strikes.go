@@ -0,0 +1,191 @@
+// strikes.go handles the warning-strike system. Each infraction a user commits adds strikes, and the number of active
+// (non-decayed) strikes a user has decides which step of the escalation ladder they get.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const strikeTimeFormat = "2006-01-02 15:04:05"
+
+// Infraction is a kind of rule break that can earn a user strikes
+type Infraction string
+
+const (
+	InfractionLink        Infraction = "link"
+	InfractionDeniedLink  Infraction = "deniedlink"
+	InfractionLongMessage Infraction = "longmsg"
+	InfractionBadWord     Infraction = "badword"
+)
+
+// Strike is a single infraction recorded against a user
+type Strike struct {
+	User      string     `json:"user"`
+	Reason    Infraction `json:"reason"`
+	Amount    int        `json:"amount"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// Punishment is one step of a strike escalation ladder, e.g. a 10 minute timeout
+type Punishment struct {
+	Severity Severity      `json:"severity"`
+	Duration time.Duration `json:"duration"` // only used for SeverityTimeout
+}
+
+// ParsePunishment reads in a ladder step from the config. A step is either the name of a severity such as "warn" or
+// "ban", or a duration such as "10m" which means a timeout of that length.
+func ParsePunishment(value string) (Punishment, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return Punishment{Severity: SeverityTimeout, Duration: duration}, nil
+	}
+
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return Punishment{}, err
+	}
+	return Punishment{Severity: severity}, nil
+}
+
+// Harsher returns true if p is a harsher punishment than other
+func (p Punishment) Harsher(other Punishment) bool {
+	if p.Severity == other.Severity {
+		return p.Duration > other.Duration
+	}
+	return severityRanks[p.Severity] > severityRanks[other.Severity]
+}
+
+func (p Punishment) String() string {
+	if p.Severity == SeverityTimeout {
+		return fmt.Sprintf("%s timeout", p.Duration)
+	}
+	return p.Severity.String()
+}
+
+// AddStrike records an infraction against username and returns the user's total active strikes along with the
+// punishment the escalation ladder calls for. If the infraction is worth no strikes, the total is 0 and the punishment
+// is a warning.
+func (bot *Bot) AddStrike(username string, infraction Infraction) (int, Punishment, error) {
+	amount, ok := bot.StrikePoints[infraction]
+	if !ok {
+		amount = 1
+	}
+	if amount <= 0 {
+		return 0, Punishment{Severity: SeverityWarn}, nil
+	}
+
+	if bot.Strikes == nil {
+		bot.Strikes = make(map[string][]Strike)
+	}
+
+	strike := Strike{User: username, Reason: infraction, Amount: amount, Timestamp: time.Now()}
+	bot.Strikes[username] = append(bot.ActiveStrikes(username), strike)
+
+	var err error
+	if bot.Storage != nil {
+		err = bot.Storage.DB.Insert("strikes", []string{"user", "reason", "amount", "timestamp"},
+			[]string{username, string(infraction), strconv.Itoa(amount), strike.Timestamp.Format(strikeTimeFormat)})
+	}
+
+	total := bot.StrikeCount(username)
+	return total, bot.ladderStep(total), err
+}
+
+// ActiveStrikes returns the strikes for username that have not decayed yet
+func (bot *Bot) ActiveStrikes(username string) []Strike {
+	var active []Strike
+	for _, strike := range bot.Strikes[username] {
+		if bot.StrikeDecay <= 0 || time.Since(strike.Timestamp) < bot.StrikeDecay {
+			active = append(active, strike)
+		}
+	}
+	return active
+}
+
+// StrikeCount returns the total amount of active strikes for username
+func (bot *Bot) StrikeCount(username string) int {
+	var total int
+	for _, strike := range bot.ActiveStrikes(username) {
+		total += strike.Amount
+	}
+	return total
+}
+
+// PardonUser removes every strike from username and returns how many active strikes were removed
+func (bot *Bot) PardonUser(username string) (int, error) {
+	total := bot.StrikeCount(username)
+	delete(bot.Strikes, username)
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Delete("strikes", "user", username)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ladderStep returns the punishment for a user with the given amount of strikes. Users with more strikes than there
+// are steps get the last step.
+func (bot *Bot) ladderStep(strikes int) Punishment {
+	if len(bot.StrikeLadder) == 0 || strikes <= 0 {
+		return Punishment{Severity: SeverityWarn}
+	}
+	if strikes > len(bot.StrikeLadder) {
+		strikes = len(bot.StrikeLadder)
+	}
+	return bot.StrikeLadder[strikes-1]
+}
+
+// LoadStrikes loads the strikes that have not decayed yet from the database
+func (bot *Bot) LoadStrikes() error {
+	bot.Strikes = make(map[string][]Strike)
+	rows, err := bot.Storage.DB.Query("select user, reason, amount, timestamp from strikes")
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var user, reason, timestamp string
+		var amount int
+		err = rows.Scan(&user, &reason, &amount, &timestamp)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := time.ParseInLocation(strikeTimeFormat, timestamp, time.Local)
+		if err != nil {
+			return err
+		}
+		if bot.StrikeDecay > 0 && time.Since(parsed) >= bot.StrikeDecay {
+			continue
+		}
+		bot.Strikes[user] = append(bot.Strikes[user], Strike{User: user, Reason: Infraction(reason), Amount: amount, Timestamp: parsed})
+	}
+	return nil
+}
+
+// loadStrikeConfig reads the strike settings from the config
+func (bot *Bot) loadStrikeConfig() error {
+	bot.StrikesEnabled = bot.Config.GetBool("Strikes.Enabled")
+	bot.StrikeDecay = bot.Config.GetDuration("Strikes.Decay")
+
+	bot.StrikeLadder = nil
+	for _, step := range bot.Config.GetStringSlice("Strikes.Ladder") {
+		punishment, err := ParsePunishment(strings.TrimSpace(step))
+		if err != nil {
+			return FatalError{Err: fmt.Errorf("invalid strike ladder step: %v", err)}
+		}
+		bot.StrikeLadder = append(bot.StrikeLadder, punishment)
+	}
+
+	bot.StrikePoints = make(map[Infraction]int)
+	for infraction := range bot.Config.GetStringMap("Strikes.Points") {
+		bot.StrikePoints[Infraction(infraction)] = bot.Config.GetInt(fmt.Sprintf("Strikes.Points.%s", infraction))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAddStrike(t *testing.T) {
+	ladder := []Punishment{{Severity: SeverityWarn}, {Severity: SeverityTimeout, Duration: 10 * time.Second}, {Severity: SeverityBan}}
+	tests := []struct {
+		description    string
+		strikes        map[string][]Strike
+		points         map[Infraction]int
+		infraction     Infraction
+		wantTotal      int
+		wantPunishment Punishment
+	}{
+		{
+			description:    "first strike should get the first ladder step",
+			strikes:        map[string][]Strike{},
+			infraction:     InfractionLink,
+			wantTotal:      1,
+			wantPunishment: Punishment{Severity: SeverityWarn},
+		},
+		{
+			description:    "should use the points configured for the infraction",
+			strikes:        map[string][]Strike{},
+			points:         map[Infraction]int{InfractionBadWord: 2},
+			infraction:     InfractionBadWord,
+			wantTotal:      2,
+			wantPunishment: Punishment{Severity: SeverityTimeout, Duration: 10 * time.Second},
+		},
+		{
+			description:    "decayed strikes should not count",
+			strikes:        map[string][]Strike{"viewer": {{User: "viewer", Amount: 1, Timestamp: time.Now().Add(-2 * time.Hour)}}},
+			infraction:     InfractionLink,
+			wantTotal:      1,
+			wantPunishment: Punishment{Severity: SeverityWarn},
+		},
+		{
+			description: "should stay on the last step once the ladder runs out",
+			strikes: map[string][]Strike{"viewer": {
+				{User: "viewer", Amount: 3, Timestamp: time.Now()},
+			}},
+			infraction:     InfractionLongMessage,
+			wantTotal:      4,
+			wantPunishment: Punishment{Severity: SeverityBan},
+		},
+		{
+			description:    "an infraction worth no strikes should only warn",
+			strikes:        map[string][]Strike{},
+			points:         map[Infraction]int{InfractionLink: 0},
+			infraction:     InfractionLink,
+			wantTotal:      0,
+			wantPunishment: Punishment{Severity: SeverityWarn},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			bot := &Bot{Strikes: test.strikes, StrikePoints: test.points, StrikeLadder: ladder, StrikeDecay: time.Hour}
+			total, punishment, err := bot.AddStrike("viewer", test.infraction)
+			if err != nil {
+				t.Errorf("got an unexpected error: %v", err)
+			}
+
+			if total != test.wantTotal {
+				t.Errorf("did not get the expected total\ngot - %d\nwant - %d", total, test.wantTotal)
+			}
+
+			if !reflect.DeepEqual(punishment, test.wantPunishment) {
+				t.Errorf("did not get the expected punishment\ngot - %v\nwant - %v", punishment, test.wantPunishment)
+			}
+		})
+	}
+}
+
+func TestPardonUser(t *testing.T) {
+	bot := &Bot{Strikes: map[string][]Strike{"viewer": {{User: "viewer", Amount: 2, Timestamp: time.Now()}}}}
+
+	total, err := bot.PardonUser("viewer")
+	if err != nil {
+		t.Errorf("got an unexpected error: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("did not get the expected amount of pardoned strikes\ngot - %d\nwant - 2", total)
+	}
+	if count := bot.StrikeCount("viewer"); count != 0 {
+		t.Errorf("user still has %d strikes after a pardon", count)
+	}
+}
+
+func TestParsePunishment(t *testing.T) {
+	tests := []struct {
+		input          string
+		wantPunishment Punishment
+		wantErr        bool
+	}{
+		{input: "warn", wantPunishment: Punishment{Severity: SeverityWarn}},
+		{input: "10m", wantPunishment: Punishment{Severity: SeverityTimeout, Duration: 10 * time.Minute}},
+		{input: "ban", wantPunishment: Punishment{Severity: SeverityBan}},
+		{input: "forever", wantErr: true},
+	}
+
+	for _, test := range tests {
+		punishment, err := ParsePunishment(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("did not get the expected error for %s: %v", test.input, err)
+		}
+		if !reflect.DeepEqual(punishment, test.wantPunishment) {
+			t.Errorf("did not get the expected punishment\ngot - %v\nwant - %v", punishment, test.wantPunishment)
+		}
+	}
+}
+
+func TestPunishmentHarsher(t *testing.T) {
+	short := Punishment{Severity: SeverityTimeout, Duration: time.Second}
+	long := Punishment{Severity: SeverityTimeout, Duration: time.Hour}
+
+	if !long.Harsher(short) || short.Harsher(long) {
+		t.Errorf("a longer timeout should be harsher than a shorter one")
+	}
+	if !(Punishment{Severity: SeverityBan}).Harsher(long) {
+		t.Errorf("a ban should be harsher than a timeout")
+	}
+	if (Punishment{Severity: SeverityWarn}).Harsher(Punishment{Severity: SeverityDelete}) {
+		t.Errorf("a warning should not be harsher than a deletion")
+	}
+}
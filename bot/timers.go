@@ -9,9 +9,9 @@ import (
 
 // TimedValues contains the values needed to run a single timed command.
 type TimedValue struct {
-	Message string
-	Minutes int
-	Enabled bool
+	Message string `json:"message"`
+	Minutes int    `json:"minutes"`
+	Enabled bool   `json:"enabled"`
 }
 
 // AddTimer takes in an item and parses it to add an associated timer. Will assume enabled by default.
@@ -39,6 +39,28 @@ func (bot *Bot) AddTimer(item Item) error {
 	return nil
 }
 
+// EditTimer changes a timer's message, minutes and whether it is enabled. Like added and deleted timers, RunTimers
+// picks up the change once the bot restarts.
+func (bot *Bot) EditTimer(name string, timer TimedValue) error {
+	if _, ok := bot.Timers[name]; !ok {
+		return NonFatalError{Err: fmt.Errorf("the timer '%s' does not exist", name)}
+	}
+	if timer.Message == "" || timer.Minutes < 1 {
+		return NonFatalError{Err: fmt.Errorf("a timer needs a message and at least 1 minute between messages")}
+	}
+
+	if bot.Storage != nil {
+		err := bot.Storage.DB.Update("timers", "timername", name, []string{"message", "minutes", "enabled"},
+			[]string{timer.Message, strconv.Itoa(timer.Minutes), strconv.FormatBool(timer.Enabled)})
+		if err != nil {
+			return err
+		}
+	}
+	// the timer is replaced rather than changed, since a running timer still holds on to the old one
+	bot.Timers[name] = &timer
+	return nil
+}
+
 // DeleteTimer will delete a timer from the map and DB.
 func (bot *Bot) DeleteTimer(item Item) error {
 	if _, ok := bot.Timers[item.Key]; ok {
@@ -58,15 +80,16 @@ func (bot *Bot) DeleteTimer(item Item) error {
 // A service who wants to use RunTimers must define a Messenger.Message definition so this function knows
 // how to send the timer's contents.
 func (bot *Bot) RunTimers(messenger Messenger) {
-	for _, tv := range bot.Timers {
-		go func(timedVal *TimedValue) {
-			if tv.Enabled {
+	for name, tv := range bot.Timers {
+		go func(name string, timedVal *TimedValue) {
+			if timedVal.Enabled {
 				for range time.NewTicker(time.Minute * time.Duration(timedVal.Minutes)).C {
 					messenger.Message(timedVal.Message)
+					bot.Publish(EventTimer, TimerEvent{Name: name, Message: timedVal.Message})
 				}
 			}
 
-		}(tv)
+		}(name, tv)
 	}
 }
 
@@ -0,0 +1,214 @@
+// tokens.go handles the tokens used to access the API. Only a hash of each token is stored, so a token can't be
+// recovered from the database once it has been created.
+
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tokenTimeFormat = "2006-01-02 15:04:05"
+	tokenPrefix     = "pb_"
+)
+
+var (
+	ErrInvalidToken = errors.New("the API token is not valid")
+	ErrExpiredToken = errors.New("the API token has expired")
+	ErrRevokedToken = errors.New("the API token has been revoked")
+)
+
+// APIToken describes a token and what it may access. Scopes are of the form '<resource>:<read|write>', where write
+// also allows reading, and a resource of '*' covers every resource.
+type APIToken struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Scopes  []string  `json:"scopes"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"` // zero if the token never expires
+	Revoked bool      `json:"revoked"`
+}
+
+// APIRequest is a single audited request made with a token
+type APIRequest struct {
+	TokenID   int       `json:"token_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Allows returns true if the token has a scope for resource. write should be true for requests that change data.
+func (token APIToken) Allows(resource string, write bool) bool {
+	for _, scope := range token.Scopes {
+		split := strings.SplitN(scope, ":", 2)
+		if len(split) != 2 || (split[0] != resource && split[0] != "*") {
+			continue
+		}
+		if split[1] == "write" || (split[1] == "read" && !write) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashToken returns the value stored in place of a token
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken makes a new token with the given scopes that lasts for ttl, or forever if ttl is 0. The returned
+// string is the token itself, which is not stored anywhere and must be handed to the user.
+func (bot *Bot) CreateAPIToken(name string, scopes []string, ttl time.Duration) (string, APIToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", APIToken{}, err
+	}
+	secret := tokenPrefix + hex.EncodeToString(raw)
+
+	token := APIToken{Name: name, Scopes: scopes, Created: time.Now()}
+	expires := ""
+	if ttl > 0 {
+		token.Expires = token.Created.Add(ttl)
+		expires = token.Expires.Format(tokenTimeFormat)
+	}
+
+	err := bot.Storage.DB.Insert("api_tokens", []string{"name", "hash", "scopes", "created", "expires", "revoked"},
+		[]string{name, hashToken(secret), strings.Join(scopes, ","), token.Created.Format(tokenTimeFormat), expires, "0"})
+	if err != nil {
+		return "", APIToken{}, err
+	}
+
+	rows, err := bot.Storage.DB.Query("select id from api_tokens where hash = ?", hashToken(secret))
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&token.ID)
+	}
+	return secret, token, err
+}
+
+// FindAPIToken returns the token matching secret, or an error if it doesn't exist or can no longer be used
+func (bot *Bot) FindAPIToken(secret string) (APIToken, error) {
+	tokens, err := bot.queryAPITokens("where hash = ?", hashToken(secret))
+	if err != nil {
+		return APIToken{}, err
+	}
+	if len(tokens) == 0 {
+		return APIToken{}, ErrInvalidToken
+	}
+
+	token := tokens[0]
+	if token.Revoked {
+		return token, ErrRevokedToken
+	}
+	if !token.Expires.IsZero() && time.Now().After(token.Expires) {
+		return token, ErrExpiredToken
+	}
+	return token, nil
+}
+
+// ListAPITokens returns every token that has been created, including revoked and expired ones
+func (bot *Bot) ListAPITokens() ([]APIToken, error) {
+	return bot.queryAPITokens("order by id")
+}
+
+// RevokeAPIToken stops the token with the given ID from being used
+func (bot *Bot) RevokeAPIToken(id int) error {
+	tokens, err := bot.queryAPITokens("where id = ?", id)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return NonFatalError{Err: fmt.Errorf("there is no API token with the ID %d", id)}
+	}
+	return bot.Storage.DB.Update("api_tokens", "id", strconv.Itoa(id), []string{"revoked"}, []string{"1"})
+}
+
+// queryAPITokens returns the tokens matching the end of a query, e.g. 'where id = ?'
+func (bot *Bot) queryAPITokens(clause string, args ...interface{}) ([]APIToken, error) {
+	rows, err := bot.Storage.DB.Query("select id, name, scopes, created, expires, revoked from api_tokens "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var tokens []APIToken
+	for rows.Next() {
+		var token APIToken
+		var scopes, created, expires string
+		var revoked int
+		err = rows.Scan(&token.ID, &token.Name, &scopes, &created, &expires, &revoked)
+		if err != nil {
+			return nil, err
+		}
+
+		token.Scopes = strings.Split(scopes, ",")
+		token.Revoked = Itob(revoked)
+		if token.Created, err = time.ParseInLocation(tokenTimeFormat, created, time.Local); err != nil {
+			return nil, err
+		}
+		if expires != "" {
+			if token.Expires, err = time.ParseInLocation(tokenTimeFormat, expires, time.Local); err != nil {
+				return nil, err
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// LogAPIRequest records a request made with a token in the API audit log
+func (bot *Bot) LogAPIRequest(request APIRequest) error {
+	if request.Timestamp.IsZero() {
+		request.Timestamp = time.Now()
+	}
+	return bot.Storage.DB.Insert("api_audit", []string{"token_id", "method", "path", "status", "timestamp"},
+		[]string{strconv.Itoa(request.TokenID), request.Method, request.Path, strconv.Itoa(request.Status),
+			request.Timestamp.Format(tokenTimeFormat)})
+}
+
+// APIAudit returns the audited requests, newest first. A tokenID of 0 returns requests for every token.
+func (bot *Bot) APIAudit(tokenID int, limit int) ([]APIRequest, error) {
+	query := "select token_id, method, path, status, timestamp from api_audit"
+	var args []interface{}
+	if tokenID > 0 {
+		query += " where token_id = ?"
+		args = append(args, tokenID)
+	}
+	query += " order by id desc"
+	if limit > 0 {
+		query += fmt.Sprintf(" limit %d", limit)
+	}
+
+	rows, err := bot.Storage.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var requests []APIRequest
+	for rows.Next() {
+		var request APIRequest
+		var timestamp string
+		err = rows.Scan(&request.TokenID, &request.Method, &request.Path, &request.Status, &timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if request.Timestamp, err = time.ParseInLocation(tokenTimeFormat, timestamp, time.Local); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
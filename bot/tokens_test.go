@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		description string
+		scopes      []string
+		resource    string
+		write       bool
+		want        bool
+	}{
+		{description: "read scope should allow reading", scopes: []string{"quotes:read"}, resource: "quotes", want: true},
+		{description: "read scope should not allow writing", scopes: []string{"quotes:read"}, resource: "quotes", write: true},
+		{description: "write scope should allow reading", scopes: []string{"quotes:write"}, resource: "quotes", want: true},
+		{description: "should not allow other resources", scopes: []string{"quotes:write"}, resource: "commands"},
+		{description: "wildcard should allow any resource", scopes: []string{"*:write"}, resource: "timers", write: true, want: true},
+		{description: "should ignore malformed scopes", scopes: []string{"quotes"}, resource: "quotes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got := APIToken{Scopes: test.scopes}.Allows(test.resource, test.write)
+			if got != test.want {
+				t.Errorf("did not get the expected result\ngot - %v\nwant - %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	database := newTestDatabase(t)
+
+	bot := &Bot{Storage: database}
+	secret, token, err := bot.CreateAPIToken("dashboard", []string{"quotes:read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("could not create a token: %v", err)
+	}
+
+	found, err := bot.FindAPIToken(secret)
+	if err != nil || found.ID != token.ID || found.Name != "dashboard" {
+		t.Errorf("did not find the created token: %+v %v", found, err)
+	}
+	if _, err = bot.FindAPIToken("pb_notatoken"); err != ErrInvalidToken {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, ErrInvalidToken)
+	}
+
+	if err = bot.RevokeAPIToken(token.ID); err != nil {
+		t.Fatalf("could not revoke the token: %v", err)
+	}
+	if _, err = bot.FindAPIToken(secret); err != ErrRevokedToken {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, ErrRevokedToken)
+	}
+	if err = bot.RevokeAPIToken(token.ID + 1); err == nil {
+		t.Errorf("expected an error revoking a token that doesn't exist")
+	}
+
+	expiredSecret, _, _ := bot.CreateAPIToken("old", []string{"*:read"}, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err = bot.FindAPIToken(expiredSecret); err != ErrExpiredToken {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, ErrExpiredToken)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/cobra"
+)
+
+// logsCmd groups the commands for the chat log
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "work with the chat log",
+}
+
+var logsSearchCmd = &cobra.Command{
+	Use:   "search [term]",
+	Short: "search the chat log",
+	Long: `Prints the chat messages and moderation actions in the chat log, newest first. If a term is given, only lines
+with every word of it are shown. Dates can be given as 2006-01-02 or as a duration into the past such as 2d or 12h.`,
+	Example: `  pleasantbot logs search --user someviewer --since 2d "discord link"`,
+	Args:    cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter bot.ChatLogFilter
+		var err error
+
+		filter.Term = strings.Join(args, " ")
+		filter.User, _ = cmd.Flags().GetString("user")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		since, _ := cmd.Flags().GetString("since")
+		if filter.Since, err = bot.ParseSince(since); err != nil {
+			return err
+		}
+		until, _ := cmd.Flags().GetString("until")
+		if filter.Until, err = bot.ParseUntil(until); err != nil {
+			return err
+		}
+
+		return withBot(func(b *bot.Bot) error {
+			lines, err := b.ChatLog(filter)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "TIME\tKIND\tUSER\tMESSAGE")
+			for _, line := range lines {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", line.Timestamp.Format("2006-01-02 15:04:05"), line.Kind, line.User,
+					line.Message)
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+func init() {
+	logsSearchCmd.Flags().StringP("user", "u", "", "only show lines sent by or about this user")
+	logsSearchCmd.Flags().String("since", "", "only show lines on or after this date")
+	logsSearchCmd.Flags().String("until", "", "only show lines on or before this date")
+	logsSearchCmd.Flags().IntP("limit", "n", 100, "the most lines to show, 0 for all")
+	logsCmd.AddCommand(logsSearchCmd)
+	rootCmd.AddCommand(logsCmd)
+}
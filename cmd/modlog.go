@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/cobra"
+)
+
+// modlogCmd prints the moderation audit log
+var modlogCmd = &cobra.Command{
+	Use:   "modlog",
+	Short: "browse the moderation audit log",
+	Long: `Prints the moderation actions the bot has taken or recorded, newest first.
+Dates can be given as 2006-01-02 or as a duration into the past such as 2d or 12h.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter bot.ModLogFilter
+		var err error
+
+		filter.User, _ = cmd.Flags().GetString("user")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		since, _ := cmd.Flags().GetString("since")
+		if filter.Since, err = bot.ParseSince(since); err != nil {
+			return err
+		}
+		until, _ := cmd.Flags().GetString("until")
+		if filter.Until, err = bot.ParseUntil(until); err != nil {
+			return err
+		}
+
+		return withBot(func(b *bot.Bot) error {
+			entries, err := b.ModLog(filter)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "TIME\tACTION\tACTOR\tTARGET\tRULE\tREASON\tMESSAGE")
+			for _, entry := range entries {
+				action := string(entry.Action)
+				if entry.Duration > 0 {
+					action = fmt.Sprintf("%s (%s)", action, entry.Duration)
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Timestamp.Format("2006-01-02 15:04:05"), action,
+					entry.Actor, entry.Target, entry.Rule, entry.Reason, entry.Excerpt)
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+func init() {
+	modlogCmd.Flags().StringP("user", "u", "", "only show actions taken against or by this user")
+	modlogCmd.Flags().String("since", "", "only show actions on or after this date")
+	modlogCmd.Flags().String("until", "", "only show actions on or before this date")
+	modlogCmd.Flags().IntP("limit", "n", 50, "the most entries to show, 0 for all")
+	rootCmd.AddCommand(modlogCmd)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/cobra"
+)
+
+// sessionsCmd groups the commands for stream sessions
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "look back at stream sessions",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the latest stream sessions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		return withBot(func(b *bot.Bot) error {
+			sessions, err := b.Sessions(limit)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tSTARTED\tMINUTES\tMESSAGES\tCHATTERS\tNEW CHATTERS")
+			for _, session := range sessions {
+				if session.Summary == nil {
+					fmt.Fprintf(writer, "%d\t%s\tlive\t\t\t\n", session.ID, session.Started.Format("2006-01-02 15:04"))
+					continue
+				}
+				fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%d\t%d\n", session.ID, session.Started.Format("2006-01-02 15:04"),
+					session.Summary.Minutes, session.Summary.Messages, session.Summary.UniqueChatters,
+					len(session.Summary.NewChatters))
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+var sessionsExportCmd = &cobra.Command{
+	Use:     "export <id>",
+	Short:   "print a stream session's summary as Markdown or JSON",
+	Example: "  pleasantbot sessions export 12 --format markdown > stream-12.md",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a session ID", args[0])
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if format != "markdown" && format != "json" {
+			return fmt.Errorf("the format has to be markdown or json")
+		}
+
+		return withBot(func(b *bot.Bot) error {
+			session, err := b.GetSession(id)
+			if err != nil {
+				return err
+			}
+			if format == "markdown" {
+				fmt.Print(session.Markdown())
+				return nil
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(session)
+		})
+	},
+}
+
+func init() {
+	sessionsListCmd.Flags().IntP("limit", "n", 20, "the most sessions to show")
+	sessionsExportCmd.Flags().StringP("format", "f", "markdown", "markdown or json")
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsExportCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd groups the commands for the channel's chat stats
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "work with the channel's chat stats",
+}
+
+var statsExportCmd = &cobra.Command{
+	Use:   "export <kind>",
+	Short: "print one kind of chat stats as CSV",
+	Long: fmt.Sprintf(`Prints one kind of the channel's chat stats as CSV.
+Kinds: %s.
+Dates can be given as 2006-01-02 or as a duration into the past such as 30d, or left empty for everything.`,
+		strings.Join(bot.StatsKinds, ", ")),
+	Example: "  pleasantbot stats export chatters --since 30d > chatters.csv",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, _ := cmd.Flags().GetString("since")
+		since, err := bot.ParseSince(value)
+		if err != nil {
+			return err
+		}
+		return withBot(func(b *bot.Bot) error {
+			return b.WriteStatsCSV(os.Stdout, args[0], since)
+		})
+	},
+}
+
+func init() {
+	statsExportCmd.Flags().String("since", "", "only count chat on or after this date")
+	statsCmd.AddCommand(statsExportCmd)
+	rootCmd.AddCommand(statsCmd)
+}
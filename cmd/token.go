@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/api"
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/twitch"
+	"github.com/spf13/cobra"
+)
+
+// tokenCmd groups the commands for managing API tokens
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "manage the tokens used to access the API",
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a new API token",
+	Long: fmt.Sprintf(`Creates a new API token and prints it. The token is only shown once, only a hash of it is stored.
+Scopes are of the form <resource>:<read|write>, where write also allows reading.
+Resources: %s, or * for all of them.`, strings.Join(api.Resources, ", ")),
+	Example: "  pleasantbot token create --name dashboard --scope commands:write --scope quotes:read --expires 30d",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		expires, _ := cmd.Flags().GetString("expires")
+
+		if len(scopes) == 0 {
+			return fmt.Errorf("at least one --scope is needed")
+		}
+		for _, scope := range scopes {
+			if err := api.ValidateScope(scope); err != nil {
+				return err
+			}
+		}
+
+		var ttl time.Duration
+		var err error
+		if expires != "" {
+			if ttl, err = bot.ParseDuration(expires); err != nil {
+				return err
+			}
+		}
+
+		return withBot(func(b *bot.Bot) error {
+			secret, token, err := b.CreateAPIToken(name, scopes, ttl)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created token %d (%s) with scopes %s\n", token.ID, token.Name, strings.Join(token.Scopes, ", "))
+			fmt.Printf("%s\n\nthis token will not be shown again\n", secret)
+			return nil
+		})
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list every API token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withBot(func(b *bot.Bot) error {
+			tokens, err := b.ListAPITokens()
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tNAME\tSCOPES\tCREATED\tEXPIRES\tSTATUS")
+			for _, token := range tokens {
+				expires, status := "never", "active"
+				if !token.Expires.IsZero() {
+					expires = token.Expires.Format("2006-01-02 15:04")
+					if time.Now().After(token.Expires) {
+						status = "expired"
+					}
+				}
+				if token.Revoked {
+					status = "revoked"
+				}
+				fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\n", token.ID, token.Name, strings.Join(token.Scopes, ","),
+					token.Created.Format("2006-01-02 15:04"), expires, status)
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "stop an API token from being used",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("'%s' is not a token ID", args[0])
+		}
+		return withBot(func(b *bot.Bot) error {
+			if err := b.RevokeAPIToken(id); err != nil {
+				return err
+			}
+			fmt.Printf("token %d has been revoked\n", id)
+			return nil
+		})
+	},
+}
+
+var tokenAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "show the changes made through the API and which token made them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, _ := cmd.Flags().GetInt("id")
+		limit, _ := cmd.Flags().GetInt("limit")
+		return withBot(func(b *bot.Bot) error {
+			requests, err := b.APIAudit(id, limit)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "TIME\tTOKEN\tMETHOD\tPATH\tSTATUS")
+			for _, request := range requests {
+				fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%d\n", request.Timestamp.Format("2006-01-02 15:04:05"), request.TokenID,
+					request.Method, request.Path, request.Status)
+			}
+			return writer.Flush()
+		})
+	},
+}
+
+// withBot loads the bot's data without connecting to a service and runs f with it
+func withBot(f func(b *bot.Bot) error) error {
+	tw := &twitch.Twitch{}
+	if err := tw.Setup(); err != nil {
+		return err
+	}
+	defer tw.Bot.Storage.DB.Close()
+	return f(tw.Bot)
+}
+
+func init() {
+	tokenCreateCmd.Flags().String("name", "", "a name to remember the token by")
+	tokenCreateCmd.Flags().StringSlice("scope", nil, "a scope for the token, can be given more than once")
+	tokenCreateCmd.Flags().String("expires", "", "how long until the token expires, such as 30d or 12h. Never expires if empty")
+	tokenAuditCmd.Flags().Int("id", 0, "only show requests made with this token")
+	tokenAuditCmd.Flags().IntP("limit", "n", 50, "the most requests to show, 0 for all")
+
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd, tokenAuditCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
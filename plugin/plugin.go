@@ -0,0 +1,183 @@
+// Package plugin lets other Go packages add features to the bot without changing it. A plugin registers itself from
+// an init function, and once it is enabled in the config file its Setup adds the chat commands, scheduled jobs and HTTP
+// routes it needs:
+//
+//	func init() {
+//		plugin.Register(plugin.Plugin{Name: "hello", APIVersion: plugin.APIVersion, Setup: setup})
+//	}
+//
+// The plugin is then built into the bot by importing it from main, e.g. import _ "example.com/hello".
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/viper"
+)
+
+// APIVersion is the version of the plugin API. It changes whenever a change to this package could break a plugin, and
+// plugins built against another version are not loaded.
+const APIVersion = 1
+
+// nameRegex is what a plugin's name must look like, since it is used in table names, config keys and URLs
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string]Plugin)
+)
+
+// Plugin describes a feature that can be added to the bot
+type Plugin struct {
+	Name       string // e.g. "giveaways", used for the plugin's config section, tables and HTTP routes
+	APIVersion int    // the APIVersion the plugin was built against
+	Setup      func(ctx *Context) error
+}
+
+// Action handles a chat command, it has the same methods as the Twitch service's ActionTaker
+type Action interface {
+	Condition(item bot.Item, b *bot.Bot) bool
+	Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error
+}
+
+// Command connects chat command types to the Action that handles them
+type Command struct {
+	Types    []string // e.g. "!hello", a command without types is tried for every chat command
+	Action   Action
+	Perm     uint8         // lowest permission level that can use the command, e.g. bot.PermModerator
+	Cooldown time.Duration // how long a user waits between uses, moderators never wait
+}
+
+// Job is something a plugin runs on a schedule. The bot's data is locked while a job runs.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(b *bot.Bot, messenger bot.Messenger) error
+}
+
+// Route is an HTTP route served by the API under /api/plugins/<plugin name>. The bot's data is locked while the
+// handler runs, and requests need a token with a 'plugins' scope.
+type Route struct {
+	Method  string // e.g. http.MethodGet
+	Path    string // e.g. "/entries/:id"
+	Handler gin.HandlerFunc
+}
+
+// Context is handed to a plugin's Setup, it is how a plugin adds its features to the bot
+type Context struct {
+	Bot      *bot.Bot
+	Name     string
+	Config   *viper.Viper // the plugin's settings, with those set for the bot's channel taking priority
+	Commands []Command
+	Jobs     []Job
+	Routes   []Route
+}
+
+// Register makes a plugin available to the bot, it is meant to be called from the plugin's init function. It panics
+// if the plugin is invalid or a plugin with the same name is already registered.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !nameRegex.MatchString(p.Name) {
+		panic(fmt.Sprintf("plugin: '%s' is not a valid plugin name, use lowercase letters, numbers and underscores", p.Name))
+	}
+	if p.Setup == nil {
+		panic(fmt.Sprintf("plugin: %s has no Setup function", p.Name))
+	}
+	if _, ok := registry[p.Name]; ok {
+		panic(fmt.Sprintf("plugin: %s is registered twice", p.Name))
+	}
+	registry[p.Name] = p
+}
+
+// Registered returns the names of every registered plugin, sorted
+func Registered() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load sets up every registered plugin that is enabled for the bot's channel, in order of name. A plugin is enabled
+// by 'Enabled = true' under [Plugins.<name>], and any of its settings can be changed for a single channel under
+// [Plugins.<name>.Channels.<channel>].
+func Load(b *bot.Bot) ([]*Context, error) {
+	var loaded []*Context
+	for _, name := range Registered() {
+		mu.Lock()
+		p := registry[name]
+		mu.Unlock()
+
+		ctx := &Context{Bot: b, Name: name, Config: settings(b, name)}
+		if !ctx.Config.GetBool("Enabled") {
+			continue
+		}
+		if p.APIVersion != APIVersion {
+			return nil, fmt.Errorf("the %s plugin was built for plugin API version %d, this bot uses version %d",
+				name, p.APIVersion, APIVersion)
+		}
+		if err := p.Setup(ctx); err != nil {
+			return nil, fmt.Errorf("could not set up the %s plugin: %w", name, err)
+		}
+		loaded = append(loaded, ctx)
+	}
+	return loaded, nil
+}
+
+// settings builds a plugin's config from its section of the bot's config, merging in the bot's channel's settings
+func settings(b *bot.Bot, name string) *viper.Viper {
+	v := viper.New()
+	if b.Config == nil {
+		return v
+	}
+	if base := b.Config.Sub("Plugins." + name); base != nil {
+		values := base.AllSettings()
+		delete(values, "channels")
+		v.MergeConfigMap(values)
+	}
+	if channel := b.Config.Sub(fmt.Sprintf("Plugins.%s.Channels.%s", name, strings.ToLower(b.ChannelName))); channel != nil {
+		v.MergeConfigMap(channel.AllSettings())
+	}
+	return v
+}
+
+// AddCommand adds a chat command to the bot
+func (ctx *Context) AddCommand(command Command) {
+	ctx.Commands = append(ctx.Commands, command)
+}
+
+// AddJob adds a job that runs every interval once the bot has connected
+func (ctx *Context) AddJob(job Job) {
+	ctx.Jobs = append(ctx.Jobs, job)
+}
+
+// Handle adds an HTTP route to the API
+func (ctx *Context) Handle(method, path string, handler gin.HandlerFunc) {
+	ctx.Routes = append(ctx.Routes, Route{Method: method, Path: path, Handler: handler})
+}
+
+// Table returns the name of one of the plugin's tables. Plugins keep their data in their own tables so they can't
+// clash with the bot or each other.
+func (ctx *Context) Table(name string) string {
+	return fmt.Sprintf("plugin_%s_%s", ctx.Name, name)
+}
+
+// CreateTable creates one of the plugin's tables if it doesn't exist, columns is the table's column definitions e.g.
+// "id INTEGER PRIMARY KEY, name TEXT"
+func (ctx *Context) CreateTable(name, columns string) error {
+	if ctx.Bot.Storage == nil {
+		return nil
+	}
+	return ctx.Bot.Storage.DB.ArbitraryExec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", ctx.Table(name), columns))
+}
@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/spf13/viper"
+)
+
+func TestLoad(t *testing.T) {
+	config := viper.New()
+	config.SetConfigType("toml")
+	err := config.ReadConfig(strings.NewReader(`
+[Plugins.greeter]
+Enabled = true
+Greeting = "hello"
+
+[Plugins.greeter.Channels.somechannel]
+Greeting = "howdy"
+
+[Plugins.disabled]
+Enabled = false
+
+[Plugins.outdated]
+Enabled = true
+`))
+	if err != nil {
+		t.Fatalf("could not read the test config: %v", err)
+	}
+
+	tests := []struct {
+		description  string
+		channel      string
+		plugins      []Plugin
+		wantLoaded   []string
+		wantGreeting string
+		wantErr      bool
+	}{
+		{
+			description:  "should load enabled plugins with their settings",
+			channel:      "otherchannel",
+			plugins:      []Plugin{{Name: "greeter"}, {Name: "disabled"}, {Name: "missing"}},
+			wantLoaded:   []string{"greeter"},
+			wantGreeting: "hello",
+		},
+		{
+			description:  "should use the settings for the bot's channel",
+			channel:      "SomeChannel",
+			plugins:      []Plugin{{Name: "greeter"}},
+			wantLoaded:   []string{"greeter"},
+			wantGreeting: "howdy",
+		},
+		{
+			description: "should not load a plugin built for another API version",
+			channel:     "somechannel",
+			plugins:     []Plugin{{Name: "outdated", APIVersion: APIVersion + 1}},
+			wantErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		registry = make(map[string]Plugin)
+		var greeting string
+		for _, p := range test.plugins {
+			if p.APIVersion == 0 {
+				p.APIVersion = APIVersion
+			}
+			p.Setup = func(ctx *Context) error {
+				greeting = ctx.Config.GetString("Greeting")
+				return nil
+			}
+			Register(p)
+		}
+
+		loaded, err := Load(&bot.Bot{Config: config, ChannelName: test.channel})
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant error - %v", test.description, err, test.wantErr)
+		}
+		var names []string
+		for _, ctx := range loaded {
+			names = append(names, ctx.Name)
+		}
+		if !reflect.DeepEqual(names, test.wantLoaded) {
+			t.Errorf("%s\ndid not load the expected plugins\ngot - %v\nwant - %v", test.description, names, test.wantLoaded)
+		}
+		if greeting != test.wantGreeting {
+			t.Errorf("%s\ndid not get the expected greeting\ngot - %v\nwant - %v", test.description, greeting, test.wantGreeting)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registry = make(map[string]Plugin)
+	setup := func(ctx *Context) error { return nil }
+	tests := []struct {
+		description string
+		plugin      Plugin
+		wantPanic   bool
+	}{
+		{description: "should register a valid plugin", plugin: Plugin{Name: "hello", Setup: setup}},
+		{description: "should not register a plugin twice", plugin: Plugin{Name: "hello", Setup: setup}, wantPanic: true},
+		{description: "should not register an invalid name", plugin: Plugin{Name: "Hello World", Setup: setup}, wantPanic: true},
+		{description: "should not register a plugin without Setup", plugin: Plugin{Name: "nosetup"}, wantPanic: true},
+	}
+
+	for _, test := range tests {
+		panicked := func() (panicked bool) {
+			defer func() { panicked = recover() != nil }()
+			Register(test.plugin)
+			return false
+		}()
+		if panicked != test.wantPanic {
+			t.Errorf("%s\ndid not get the expected panic\ngot - %v\nwant - %v", test.description, panicked, test.wantPanic)
+		}
+	}
+
+	if got := (&Context{Name: "hello"}).Table("entries"); got != "plugin_hello_entries" {
+		t.Errorf("did not get the expected table name\ngot - %v\nwant - %v", got, "plugin_hello_entries")
+	}
+}
@@ -36,9 +36,9 @@ func Init(path string, sq *Sqlite, prepareFunc DatabasePrepareFunc) error {
 	return err
 }
 
-// Query takes in a query and returns the resulting rows
-func (sq *Sqlite) Query(query string) (*sql.Rows, error) {
-	rows, err := sq.db.Query(query)
+// Query takes in a query and returns the resulting rows. Any args are bound to '?' placeholders in the query.
+func (sq *Sqlite) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := sq.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,18 +51,21 @@ func (sq *Sqlite) Insert(tableName string, columns, values []string) error {
 		return ColValLengthError
 	}
 
-	// sqlite query needs quotes around string values, there's probably a better way to do this
+	// values are bound as arguments so that quotes inside of them can't break the statement
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
 	for i := range values {
-		values[i] = fmt.Sprintf("'%s'", values[i])
+		placeholders[i] = "?"
+		args[i] = values[i]
 	}
 
 	// format the columns and values to work with the SQLite insert statement
 	columnsFormatted := strings.Join(columns, ", ")
-	valuesFormatted := strings.Join(values, ", ")
+	valuesFormatted := strings.Join(placeholders, ", ")
 
 	// insert formatted data into DB
 	stmt := fmt.Sprintf("insert into %s(%s) values(%s)", tableName, columnsFormatted, valuesFormatted)
-	_, err := sq.db.Exec(stmt)
+	_, err := sq.db.Exec(stmt, args...)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") { // duplicate entry is the most expected error to occur
 			return fmt.Errorf(fmt.Sprintf("the item '%s' already exists", values[0]))
@@ -77,38 +80,38 @@ func (sq *Sqlite) Update(tableName, keyColumn, keyValue string, columns, values
 		return ColValLengthError
 	}
 
-	// same as Insert; must wrap values in single quotes
-	for i := range values {
-		values[i] = fmt.Sprintf("'%s'", values[i])
-	}
-
 	stmt := `
 	UPDATE %s
 	SET %s
 	WHERE %s
 	`
 
+	// same as Insert; values are bound as arguments, with the key value last
 	var setPairs []string
+	var args []interface{}
 	for i := range values {
-		setPairs = append(setPairs, fmt.Sprintf("%s = %s", columns[i], values[i]))
+		setPairs = append(setPairs, fmt.Sprintf("%s = ?", columns[i]))
+		args = append(args, values[i])
 	}
+	args = append(args, keyValue)
 
-	stmt = fmt.Sprintf(stmt, tableName, strings.Join(setPairs, ",\n"), fmt.Sprintf("%s = '%s'", keyColumn, keyValue))
+	stmt = fmt.Sprintf(stmt, tableName, strings.Join(setPairs, ",\n"), fmt.Sprintf("%s = ?", keyColumn))
 
-	return sq.ArbitraryExec(stmt)
+	return sq.ArbitraryExec(stmt, args...)
 }
 
 func (sq *Sqlite) Delete(tableName string, keyColumn string, keyValue string) error {
-	stmt := fmt.Sprintf("delete from %s where %s = '%s'", tableName, keyColumn, keyValue)
-	err := sq.ArbitraryExec(stmt)
+	stmt := fmt.Sprintf("delete from %s where %s = ?", tableName, keyColumn)
+	err := sq.ArbitraryExec(stmt, keyValue)
 	if err != nil {
 		return fmt.Errorf("error deleting value %s from column %s due to error: %s", keyValue, keyColumn, err)
 	}
 	return nil
 }
 
-func (sq *Sqlite) ArbitraryExec(statement string) error {
-	_, err := sq.db.Exec(statement)
+// ArbitraryExec runs any statement, args are bound to '?' placeholders in the statement
+func (sq *Sqlite) ArbitraryExec(statement string, args ...interface{}) error {
+	_, err := sq.db.Exec(statement, args...)
 	if err != nil {
 		return err
 	}
@@ -116,6 +119,19 @@ func (sq *Sqlite) ArbitraryExec(statement string) error {
 	return nil
 }
 
+// Transaction runs f inside a transaction, which is committed if f returns nil and rolled back otherwise
+func (sq *Sqlite) Transaction(f func(tx *sql.Tx) error) error {
+	tx, err := sq.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func (sq *Sqlite) Close() error {
 	return sq.db.Close()
 }
@@ -2,62 +2,76 @@ package twitch
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/liamphmurphy/pleasantbot/bot"
 )
 
+// ActionTaker handles a command. Condition decides if an item should be handled, the Router has already checked the
+// item's type and the sender's permission by the time Action is called.
 type ActionTaker interface {
 	Condition(payload bot.Item, bot *bot.Bot) bool
 	Action(payload bot.Item, bot *bot.Bot, messenger bot.Messenger) error
 }
 
-// NoopAction will be used as a placeholder when no other action was found
-type NoOpAction struct{}
-
 type CommandAction struct{}
 
+type CustomCommandAction struct{}
+
 type QuoteAction struct{}
 
 type TimerAction struct{}
 
+type BadWordAction struct{}
+
+type StrikeAction struct{}
+
+type PermitAction struct{}
+
+type UnbanAction struct{}
+
 func (ca *CommandAction) Condition(item bot.Item, bot *bot.Bot) bool {
-	return item.Type != ""
+	return item.Type == "!com"
 }
 
-// Action for a CommandAction defines the actions that can be made with a command
+// Action for a CommandAction lets moderators add, edit and delete custom commands
 func (ca *CommandAction) Action(item bot.Item, bot *bot.Bot, messenger bot.Messenger) error {
 	var err error
 	var response string
 
-	if item.Type == "!com" {
-		switch item.Command {
-		case "add", "new":
-			err = bot.AddCommand(item)
-			if err == nil {
-				response = fmt.Sprintf("%s was successfully added", item.Key)
-			}
-		case "del", "rm", "delete", "remove":
-			var found bool
-			found, err = bot.RemoveCommand(item.Key)
-			if err == nil {
-				if !found {
-					response = fmt.Sprintf("%s does not exist", item.Key)
-				} else {
-					response = fmt.Sprintf("%s was successfully deleted", item.Key)
-				}
-			}
-		case "edit":
-			err = bot.EditCommand(item)
-			if err == nil {
-				response = fmt.Sprintf("'%s' has been updated.", item.Key)
+	switch item.Command {
+	case "add", "new":
+		err = bot.AddCommand(item)
+		if err == nil {
+			response = fmt.Sprintf("%s was successfully added", item.Key)
+		}
+	case "del", "rm", "delete", "remove":
+		var found bool
+		found, err = bot.RemoveCommand(item.Key)
+		if err == nil {
+			if !found {
+				response = fmt.Sprintf("%s does not exist", item.Key)
+			} else {
+				response = fmt.Sprintf("%s was successfully deleted", item.Key)
 			}
 		}
-		// handle finding custom commands
-	} else {
-		found, com := bot.FindCommand(item.Type)
-		if found {
-			messenger.Message(com.Response)
+	case "edit":
+		err = bot.EditCommand(item)
+		if err == nil {
+			response = fmt.Sprintf("'%s' has been updated.", item.Key)
+		}
+	case "script":
+		// '!com script !roll <lua>' sets the command's script, leaving out the script removes it
+		err = bot.SetCommandScript(item.Key, item.Contents)
+		if err == nil && item.Contents == "" {
+			response = fmt.Sprintf("'%s' no longer has a script", item.Key)
+		} else if err == nil {
+			response = fmt.Sprintf("'%s' now runs a script", item.Key)
 		}
+	default:
+		err = fmt.Errorf("usage: !com <add|edit|del|script> <command> [response]")
 	}
 
 	if err == nil {
@@ -69,6 +83,40 @@ func (ca *CommandAction) Action(item bot.Item, bot *bot.Bot, messenger bot.Messe
 	return err
 }
 
+func (cca *CustomCommandAction) Condition(item bot.Item, bot *bot.Bot) bool {
+	found, _ := bot.FindCommand(item.Type)
+	return found
+}
+
+// Action for a CustomCommandAction sends a custom command's response or runs its script, if the sender has the
+// command's permission
+func (cca *CustomCommandAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	_, com := b.FindCommand(item.Type)
+	perm, err := b.ConvertPermToInt(com.Perm)
+	if err != nil {
+		return err
+	}
+	if item.Sender.Perm < perm {
+		return errNotPermitted
+	}
+	if err = b.IncrementCommandCount(item.Type); err != nil {
+		fmt.Println(err)
+	}
+	if com.Script == "" {
+		return messenger.Message(b.ExpandCounters(com.Response))
+	}
+
+	messages, err := b.RunScript(item)
+	if err != nil {
+		messenger.Message(fmt.Sprintf("@%s %s", item.Sender.Name, err.Error()))
+		return err
+	}
+	for _, msg := range messages {
+		messenger.Message(msg)
+	}
+	return nil
+}
+
 func (qa *QuoteAction) Condition(item bot.Item, bot *bot.Bot) bool {
 	return item.Type == "!quote"
 }
@@ -90,6 +138,14 @@ func (qa *QuoteAction) Action(item bot.Item, bot *bot.Bot, messenger bot.Messeng
 		if err == nil {
 			response = fmt.Sprintf("deleted quote with ID: %s", item.Key)
 		}
+	default:
+		// '!quote 5' shows the quote with that ID
+		var id int
+		if id, err = strconv.Atoi(item.Command); err == nil {
+			response, err = bot.GetQuote(id)
+		} else {
+			err = fmt.Errorf("usage: !quote [id], !quote add <quote> or !quote del <id>")
+		}
 	}
 
 	if err == nil {
@@ -131,14 +187,205 @@ func (ta *TimerAction) Action(item bot.Item, bot *bot.Bot, messenger bot.Messeng
 	return err
 }
 
-// Action for a NoOpAction returns a nil error, in other words, this is a stub that does nothing
-func (noop *NoOpAction) Action(item bot.Item, bot *bot.Bot, messenger bot.Messenger) error {
+func (ba *BadWordAction) Condition(item bot.Item, bot *bot.Bot) bool {
+	return item.Type == "!badword"
+}
+
+// Action for a BadWordAction lets moderators manage bad words from chat, e.g. '!badword add timeout some phrase'
+func (ba *BadWordAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+
+	switch item.Command {
+	case "add", "new":
+		split := strings.SplitN(item.Contents, " ", 2)
+		if len(split) < 2 {
+			err = fmt.Errorf("usage: !badword add <delete|purge|timeout|ban> <phrase>")
+			break
+		}
+		var severity bot.Severity
+		severity, err = bot.ParseSeverity(split[0])
+		if err == nil {
+			err = b.AddBadWord(split[1], severity)
+		}
+		if err == nil {
+			response = fmt.Sprintf("'%s' was added as a bad word with severity %s", split[1], severity)
+		}
+	case "del", "rm", "delete", "remove":
+		var found bool
+		found, err = b.RemoveBadWord(item.Contents)
+		if err == nil {
+			if !found {
+				response = fmt.Sprintf("'%s' is not a bad word", item.Contents)
+			} else {
+				response = fmt.Sprintf("'%s' is no longer a bad word", item.Contents)
+			}
+		}
+	case "list":
+		var words []string
+		for _, badWord := range b.BadWords {
+			words = append(words, fmt.Sprintf("%s (%s)", badWord.Phrase, badWord.Severity))
+		}
+		response = "there are no bad words"
+		if len(words) > 0 {
+			response = strings.Join(words, ", ")
+		}
+	case "test":
+		if found, badWord := b.ParseForBadWord(item.Contents); found {
+			response = fmt.Sprintf("matched '%s', the sender would get: %s", badWord.Phrase, badWord.Severity)
+		} else {
+			response = "no bad words were found in that message"
+		}
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+
+	return err
+}
+
+func (sa *StrikeAction) Condition(item bot.Item, bot *bot.Bot) bool {
+	return item.Type == "!strikes" || item.Type == "!pardon"
+}
+
+// Action for a StrikeAction lets moderators view and pardon a user's strikes with '!strikes @user' and '!pardon @user'
+func (sa *StrikeAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	if username == "" {
+		err := fmt.Errorf("usage: %s @user", item.Type)
+		messenger.Message(err.Error())
+		return err
+	}
+
+	if item.Type == "!pardon" {
+		total, err := b.PardonUser(username)
+		if err == nil {
+			err = b.LogModAction(bot.ModLogEntry{Action: bot.ModActionPardon, Actor: item.Sender.Name, Target: username,
+				Reason: fmt.Sprintf("%d strike(s) removed", total)})
+		}
+		if err != nil {
+			messenger.Message(err.Error())
+			return err
+		}
+		messenger.Message(fmt.Sprintf("@%s has been pardoned, %d strike(s) removed", username, total))
+		return nil
+	}
+
+	strikes := b.ActiveStrikes(username)
+	if len(strikes) == 0 {
+		messenger.Message(fmt.Sprintf("@%s has no active strikes", username))
+		return nil
+	}
+
+	var reasons []string
+	for _, strike := range strikes {
+		reasons = append(reasons, fmt.Sprintf("%s (%s ago)", strike.Reason, time.Since(strike.Timestamp).Round(time.Minute)))
+	}
+	messenger.Message(fmt.Sprintf("@%s has %d active strike(s): %s", username, b.StrikeCount(username), strings.Join(reasons, ", ")))
+	return nil
+}
+
+func (pa *PermitAction) Condition(item bot.Item, bot *bot.Bot) bool {
+	return item.Type == "!permit"
+}
+
+// Action for a PermitAction gives a user a pass to post links, '!permit @user' allows one link while
+// '!permit @user 120' allows links for the next 120 seconds
+func (pa *PermitAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	if username == "" {
+		err := fmt.Errorf("usage: !permit @user [seconds]")
+		messenger.Message(err.Error())
+		return err
+	}
+
+	if item.Contents == "" {
+		b.AddPermittedUser(username, 0)
+		messenger.Message(fmt.Sprintf("@%s may post one link", username))
+		return nil
+	}
+
+	seconds, err := strconv.Atoi(item.Contents)
+	if err != nil || seconds <= 0 {
+		err = fmt.Errorf("'%s' is not a valid amount of seconds", item.Contents)
+		messenger.Message(err.Error())
+		return err
+	}
+	b.AddPermittedUser(username, time.Duration(seconds)*time.Second)
+	messenger.Message(fmt.Sprintf("@%s may post links for the next %d seconds", username, seconds))
 	return nil
 }
 
-func (noop *NoOpAction) Condition(item bot.Item, bot *bot.Bot) bool { return true }
+func (ua *UnbanAction) Condition(item bot.Item, bot *bot.Bot) bool {
+	return item.Type == "!unban"
+}
 
-// setupDefaultActions prepares the default ActionTaker pipeline items
-func setupDefaultActions() []ActionTaker {
-	return []ActionTaker{&CommandAction{}, &QuoteAction{}, &TimerAction{}}
+// Action for an UnbanAction lifts a ban or timeout with '!unban @user [reason]'
+func (ua *UnbanAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	if username == "" {
+		err := fmt.Errorf("usage: !unban @user [reason]")
+		messenger.Message(err.Error())
+		return err
+	}
+
+	err := messenger.Message(fmt.Sprintf("/unban %s", username))
+	if err != nil {
+		return err
+	}
+
+	err = b.LogModAction(bot.ModLogEntry{Action: bot.ModActionUnban, Actor: item.Sender.Name, Target: username, Reason: item.Contents})
+	if err != nil {
+		messenger.Message(err.Error())
+		return err
+	}
+	messenger.Message(fmt.Sprintf("@%s has been unbanned", username))
+	return nil
+}
+
+// targetUser turns a username given in chat such as '@SomeUser' into the form the bot stores users as
+func targetUser(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+}
+
+// newRouter creates the router used for chat commands, with the default actions, the plugins' commands and middleware
+func (t *Twitch) newRouter() *Router {
+	b := t.Bot
+	router := NewRouter(Recover(), t.Metrics.Middleware(), LogCommands(t.queueCommand), RequirePerm(), Cooldown())
+	router.Add(Route{Types: []string{"!com"}, Action: &CommandAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!quote"}, Action: &QuoteAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	router.Add(Route{Types: []string{"!timer"}, Action: &TimerAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!badword"}, Action: &BadWordAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!strikes", "!pardon"}, Action: &StrikeAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!permit"}, Action: &PermitAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!unban"}, Action: &UnbanAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!points"}, Action: &PointsAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	router.Add(Route{Types: []string{"!give"}, Action: &GiveAction{}, Perm: bot.PermAll})
+	router.Add(Route{Types: []string{"!top"}, Action: &TopStatsAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	router.Add(Route{Types: []string{"!top"}, Action: &TopAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	router.Add(Route{Types: []string{"!addpoints", "!setpoints"}, Action: &AdjustPointsAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!giveaway"}, Action: &GiveawayAction{Lock: &t.mu}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!poll"}, Action: &PollAction{Lock: &t.mu}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!vote"}, Action: &VoteAction{}, Perm: bot.PermAll})
+	router.Add(Route{Types: []string{"!bet"}, Action: &BetAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!bet"}, Action: &WagerAction{}, Perm: bot.PermAll})
+	router.Add(Route{Types: []string{"!join", "!leave", "!position"}, Action: &JoinQueueAction{}, Perm: bot.PermAll,
+		Cooldown: b.CommandCooldown})
+	router.Add(Route{Types: []string{"!queue", "!next"}, Action: &QueueAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!userinfo", "!note"}, Action: &ProfileAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!live", "!offline"}, Action: &SessionAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Types: []string{"!stats"}, Action: &StatsAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	for _, p := range t.plugins {
+		for _, command := range p.Commands {
+			router.Add(Route{Types: command.Types, Action: command.Action, Perm: command.Perm, Cooldown: command.Cooldown})
+		}
+	}
+	router.Add(Route{Types: []string{"!counter"}, Action: &CounterAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Action: &CountAction{}, Perm: bot.PermModerator})
+	router.Add(Route{Action: &ShowCountAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	router.Add(Route{Action: &CustomCommandAction{}, Perm: bot.PermAll, Cooldown: b.CommandCooldown})
+	return router
 }
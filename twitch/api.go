@@ -32,5 +32,6 @@ func (t *Twitch) craftInitialConnMessages() []string {
 		fmt.Sprintf("JOIN #%s", t.Bot.ChannelName),
 		"CAP REQ :twitch.tv/tags",
 		"CAP REQ :twitch.tv/commands",
+		"CAP REQ :twitch.tv/membership",
 	}
 }
@@ -0,0 +1,107 @@
+// bets.go holds the chat side of predictions, from opening and resolving them to placing wagers
+
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type BetAction struct{}
+
+type WagerAction struct{}
+
+func (ba *BetAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!bet" && b.PointsEnabled && bot.IsBetCommand(item.Command)
+}
+
+// Action for a BetAction runs predictions: '!bet open "question" outcome|outcome' starts one, '!bet close' locks in
+// the wagers, '!bet resolve <outcome>' pays out the winners and '!bet cancel' refunds everyone
+func (ba *BetAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+
+	switch strings.ToLower(item.Command) {
+	case "open":
+		match := questionRegex.FindStringSubmatch(strings.TrimSpace(strings.Join([]string{item.Key, item.Contents}, " ")))
+		if match == nil {
+			err = fmt.Errorf(`usage: !bet open "question" outcome|outcome`)
+			break
+		}
+		var bet *bot.Bet
+		if bet, err = b.OpenBet(match[1], strings.Split(match[2], "|"), item.Sender.Name); err == nil {
+			response = fmt.Sprintf("prediction: %s bet with !bet <%s> <amount>", bet.Question, strings.Join(bet.Outcomes, "|"))
+		}
+	case "close":
+		var bet *bot.Bet
+		if bet, err = b.CloseBet(); err == nil {
+			response = fmt.Sprintf("betting on '%s' is closed! %s", bet.Question, bet.Summary())
+		}
+	case "resolve":
+		bet := b.Bet
+		var payouts map[string]int64
+		var refunded bool
+		if payouts, refunded, err = b.ResolveBet(item.Key); err != nil {
+			break
+		}
+		if refunded {
+			response = fmt.Sprintf("nobody bet on %s, so all %s were refunded", strings.ToLower(item.Key),
+				b.FormatPoints(bet.Total()))
+		} else {
+			response = fmt.Sprintf("the prediction '%s' was resolved! %d winners shared %s", bet.Question, len(payouts),
+				b.FormatPoints(bet.Total()))
+		}
+	case "cancel":
+		var refunds map[string]int64
+		if refunds, err = b.CancelBet(); err == nil {
+			response = fmt.Sprintf("the prediction was cancelled and %d wagers were refunded", len(refunds))
+		}
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+	return err
+}
+
+func (wa *WagerAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!bet" && b.PointsEnabled && !bot.IsBetCommand(item.Command)
+}
+
+// Action for a WagerAction places a wager with '!bet <outcome> <amount>', and shows the running prediction with '!bet'
+func (wa *WagerAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	args := strings.Fields(strings.Join([]string{item.Command, item.Key, item.Contents}, " "))
+	if len(args) == 0 {
+		if b.Bet == nil {
+			messenger.Message("there is no prediction running")
+			return nil
+		}
+		messenger.Message(fmt.Sprintf("%s %s", b.Bet.Question, b.Bet.Summary()))
+		return nil
+	}
+
+	amount, err := strconv.ParseInt(args[len(args)-1], 10, 64)
+	if len(args) != 2 || err != nil {
+		err = fmt.Errorf("usage: !bet <outcome> <amount>")
+		messenger.Message(err.Error())
+		return err
+	}
+
+	wager, err := b.PlaceBet(item.Sender.Name, args[0], amount)
+	if errors.Is(err, bot.ErrNotEnoughPoints) {
+		messenger.Message(fmt.Sprintf("@%s you don't have %s", item.Sender.Name, b.FormatPoints(amount)))
+		return err
+	} else if err != nil {
+		messenger.Message(fmt.Sprintf("@%s %s", item.Sender.Name, err.Error()))
+		return err
+	}
+	messenger.Message(fmt.Sprintf("@%s has %s on %s", item.Sender.Name, b.FormatPoints(wager.Amount),
+		b.Bet.Outcomes[wager.Outcome]))
+	return nil
+}
@@ -0,0 +1,112 @@
+package twitch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+func TestBetCommands(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database, PointsEnabled: true}
+	for _, user := range []string{"alice", "bob"} {
+		if err := b.SetPoints(user, 1000); err != nil {
+			t.Fatalf("could not give %s points: %v", user, err)
+		}
+	}
+
+	mod := bot.User{Name: "test-mod", Perm: bot.PermModerator}
+	alice, bob := bot.User{Name: "alice"}, bot.User{Name: "bob"}
+	tests := []struct {
+		description  string
+		inputItem    bot.Item
+		wantMessages []string
+		wantBalances map[string]int64
+	}{
+		{
+			description:  "should open a prediction",
+			inputItem:    bot.Item{Type: "!bet", Command: "open", Contents: `"Will we win?" yes|no`, Sender: mod},
+			wantMessages: []string{"prediction: Will we win? bet with !bet <yes|no> <amount>"},
+		},
+		{
+			description:  "should take a wager",
+			inputItem:    bot.Item{Type: "!bet", Command: "yes", Contents: "300", Sender: alice},
+			wantMessages: []string{"@alice has 300 points on yes"},
+			wantBalances: map[string]int64{"alice": 700},
+		},
+		{
+			description:  "should take a wager on another outcome",
+			inputItem:    bot.Item{Type: "!bet", Command: "no", Contents: "100", Sender: bob},
+			wantMessages: []string{"@bob has 100 points on no"},
+			wantBalances: map[string]int64{"bob": 900},
+		},
+		{
+			description:  "should not let viewers close a prediction",
+			inputItem:    bot.Item{Type: "!bet", Command: "close", Sender: bob},
+			wantMessages: []string{"@bob you don't have permission to use that command"},
+		},
+		{
+			description:  "should close the prediction",
+			inputItem:    bot.Item{Type: "!bet", Command: "close", Sender: mod},
+			wantMessages: []string{"betting on 'Will we win?' is closed! yes: 300 (1 bet), no: 100 (1 bet)"},
+		},
+		{
+			description:  "should not take wagers once closed",
+			inputItem:    bot.Item{Type: "!bet", Command: "no", Contents: "100", Sender: alice},
+			wantMessages: []string{"@alice a non-fatal error occurred: betting is closed"},
+			wantBalances: map[string]int64{"alice": 700},
+		},
+		{
+			description:  "should pay the whole pool to the winners",
+			inputItem:    bot.Item{Type: "!bet", Command: "resolve", Key: "yes", Sender: mod},
+			wantMessages: []string{"the prediction 'Will we win?' was resolved! 1 winners shared 400 points"},
+			wantBalances: map[string]int64{"alice": 1100, "bob": 900},
+		},
+		{
+			description:  "should open a prediction with more outcomes",
+			inputItem:    bot.Item{Type: "!bet", Command: "open", Contents: `"Which boss first?" dragon|golem|lich`, Sender: mod},
+			wantMessages: []string{"prediction: Which boss first? bet with !bet <dragon|golem|lich> <amount>"},
+		},
+		{
+			description:  "should take a wager by the outcome's number",
+			inputItem:    bot.Item{Type: "!bet", Command: "2", Contents: "50", Sender: bob},
+			wantMessages: []string{"@bob has 50 points on golem"},
+			wantBalances: map[string]int64{"bob": 850},
+		},
+		{
+			description:  "should refund every wager when cancelled",
+			inputItem:    bot.Item{Type: "!bet", Command: "cancel", Sender: mod},
+			wantMessages: []string{"the prediction was cancelled and 1 wagers were refunded"},
+			wantBalances: map[string]int64{"alice": 1100, "bob": 900},
+		},
+		{
+			description:  "should show how to open a prediction",
+			inputItem:    bot.Item{Type: "!bet", Command: "open", Contents: "yes|no", Sender: mod},
+			wantMessages: []string{`usage: !bet open "question" outcome|outcome`},
+		},
+	}
+
+	router := (&Twitch{Bot: b}).newRouter()
+	for _, test := range tests {
+		messenger := &recordMessenger{}
+		router.Dispatch(test.inputItem, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+		for user, want := range test.wantBalances {
+			balance, err := b.GetBalance(user)
+			if err != nil {
+				t.Fatalf("could not get %s's balance: %v", user, err)
+			}
+			if balance.Points != want {
+				t.Errorf("%s\ndid not get the expected balance for %s\ngot - %d\nwant - %d", test.description, user, balance.Points, want)
+			}
+		}
+	}
+}
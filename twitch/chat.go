@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,9 +12,10 @@ import (
 )
 
 var (
-	typeRegex             = regexp.MustCompile(`^(\![\w]*)$`)                            // regexp for new item of form !itemcommand, such as "!quote" (note the absence of any values / content)
+	typeRegex             = regexp.MustCompile(`^(\![\w]*[+-]?)$`)                       // regexp for new item of form !itemcommand, such as "!quote" or "!deaths+" (note the absence of any values / content)
+	typeCommandRegex      = regexp.MustCompile(`^(\![\w]*)\s(\S+)$`)                     // regexp for request of form '!badword list' (a type and command only)
 	commandNoContentRegex = regexp.MustCompile(`^(\![\w]*)\s(.)*\s(\![.\w]*)$`)          // regexp for request of form '!com del !somecommand'
-	typeCommandNoKeyRegex = regexp.MustCompile(`^(\![\w]*)\s(.)*\s([.\w]*)$`)            // regexp for requests of form '!quote add this is a new quote' (no key present)
+	typeCommandNoKeyRegex = regexp.MustCompile(`^(\![\w]*)\s(.)*\s(\S*)$`)               // regexp for requests of form '!quote add this is a new quote' (no key present)
 	fullCommandRegex      = regexp.MustCompile(`^(\![\w]*)\s(.)*\s(\![.\w]*)\s([.\w]*)`) // regexp for request of form '!com add !somecommand this is a test command'
 	errComParse           = errors.New("command invocation failed")
 )
@@ -28,34 +30,29 @@ func (t *Twitch) Message(msg string) error {
 	return t.Bot.WriteToConn(fmt.Sprintf("PRIVMSG #%s :%s", t.Bot.ChannelName, msg))
 }
 
-// Handler will contain the root logic for handling any kind of message from twitch; whether from the IRC server itself,
-// or messages from the Twitch chat
-func (t *Twitch) Handler(item bot.Item, defaultActions []ActionTaker) error {
-	var at ActionTaker
-
-	// see if the message will prompt a default action
-	for _, v := range defaultActions {
-		if v.Condition(item, t.Bot) {
-			at = v
-		}
-	}
+// purges a user by sending a timeout of 1 second
+func (t *Twitch) purgeUser(username string) {
+	t.Message(fmt.Sprintf("/timeout %s 1", username))
+}
 
-	if at == nil {
-		at = &NoOpAction{}
-	}
+// DeleteMessage deletes a single message by its ID
+func (t *Twitch) DeleteMessage(id string) error {
+	return t.Message(fmt.Sprintf("/delete %s", id))
+}
 
-	// perform action
-	return at.Action(item, t.Bot, t)
+// Timeout times out a user for the given amount of seconds
+func (t *Twitch) Timeout(username string, seconds int) error {
+	return t.Message(fmt.Sprintf("/timeout %s %d", username, seconds))
 }
 
-// purges a user by sending a timeout of 1 second
-func (t *Twitch) purgeUser(username string) {
-	t.Message(fmt.Sprintf("/timeout %s 1", username))
+// Ban permanently bans a user
+func (t *Twitch) Ban(username string) error {
+	return t.Message(fmt.Sprintf("/ban %s", username))
 }
 
-func (t *Twitch) banUser(username string, reason string) {
-	t.Bot.Storage.DB.Insert("ban_history", []string{"user", "reason", "timestamp"}, []string{username, reason, time.Now().Format("2006-01-02 15:04:05")}) // insert into ban_history table
-	t.Message(fmt.Sprintf("/ban %s", username))
+// Unban lifts a ban or timeout
+func (t *Twitch) Unban(username string) error {
+	return t.Message(fmt.Sprintf("/unban %s", username))
 }
 
 // newTwitchItem is a parser for the raw return from the bot's net.Conn.
@@ -65,23 +62,28 @@ func newTwitchItem(response string) (bot.Item, error) {
 		return bot.Item{IsServerInfo: true, Contents: response}, nil
 	}
 
-	rawSplit := strings.Split(response, ";")
-	metadata := make(map[string]string)
-	for _, m := range rawSplit {
-		keyValSplit := strings.Split(m, "=")
-		metadata[keyValSplit[0]] = keyValSplit[1]
-	}
-
-	messageSplit := strings.Split(rawSplit[len(rawSplit)-1], ":")
+	metadata, rest := parseTags(response)
 
 	var item bot.Item
+	item.ID = metadata["id"]
 	item.Sender.Name = strings.ToLower(metadata["display-name"])
+	item.Sender.ID = metadata["user-id"]
+	item.Sender.Perm = parsePerm(metadata)
+	item.FirstMessage = metadata["first-msg"] == "1"
 
+	// the message itself is everything after the channel, e.g. 'PRIVMSG #channel :the message'. Splitting on only
+	// the first ' :' keeps any colons inside the message, such as in links.
+	messageSplit := strings.SplitN(rest[strings.Index(rest, "PRIVMSG"):], " :", 2)
 	msg := strings.TrimSpace(messageSplit[len(messageSplit)-1])
+	item.Emotes = parseEmotes(metadata["emotes"], msg)
 	// detect a potential command invocation, if you're confused on what the match means, look at the comments next to the regexp vars
 	if msg[0] == '!' {
 		if typeRegex.MatchString(msg) {
 			item.Type = msg
+		} else if typeCommandRegex.MatchString(msg) {
+			split := strings.Split(msg, " ")
+			item.Type = split[0]
+			item.Command = split[1]
 		} else if commandNoContentRegex.MatchString(msg) {
 			split := strings.Split(msg, " ")
 			item.Type = split[0]
@@ -99,7 +101,7 @@ func newTwitchItem(response string) (bot.Item, error) {
 			item.Command = split[1]
 			item.Contents = strings.Join(split[2:], " ")
 		} else {
-			return bot.Item{Sender: bot.User{Name: item.Sender.Name}}, bot.NonFatalError{Err: errComParse}
+			return bot.Item{Sender: item.Sender}, bot.NonFatalError{Err: errComParse}
 		}
 	} else {
 		// in this case, just a standard chat message
@@ -108,3 +110,168 @@ func newTwitchItem(response string) (bot.Item, error) {
 
 	return item, nil
 }
+
+// parseTags splits a raw message into its tags, which come first in the form '@key=value;key=value' followed by a
+// space, and the rest of the message
+func parseTags(response string) (map[string]string, string) {
+	metadata := make(map[string]string)
+	if !strings.HasPrefix(response, "@") {
+		return metadata, response
+	}
+
+	split := strings.SplitN(response, " ", 2)
+	for _, m := range strings.Split(strings.TrimPrefix(split[0], "@"), ";") {
+		keyValSplit := strings.SplitN(m, "=", 2)
+		if len(keyValSplit) == 2 {
+			metadata[keyValSplit[0]] = keyValSplit[1]
+		}
+	}
+	if len(split) == 2 {
+		return metadata, split[1]
+	}
+	return metadata, ""
+}
+
+// newAlert reads a USERNOTICE announcing a sub, resub, gifted sub or raid. ok is false for any other message.
+func newAlert(response string) (alert bot.AlertEvent, ok bool) {
+	metadata, rest := parseTags(response)
+	index := strings.Index(rest, "USERNOTICE")
+	if index == -1 {
+		return alert, false
+	}
+
+	alert.User = strings.ToLower(metadata["login"])
+	switch kind := metadata["msg-id"]; kind {
+	case bot.AlertSub, bot.AlertResub:
+		alert.Kind = kind
+		alert.Months, _ = strconv.Atoi(metadata["msg-param-cumulative-months"])
+	case bot.AlertSubGift:
+		alert.Kind = kind
+		alert.Recipient = strings.ToLower(metadata["msg-param-recipient-user-name"])
+	case bot.AlertRaid:
+		alert.Kind = kind
+		alert.Viewers, _ = strconv.Atoi(metadata["msg-param-viewerCount"])
+	default:
+		return alert, false
+	}
+
+	// a sub can come with a message shared by the user, e.g. 'USERNOTICE #channel :the message'
+	if split := strings.SplitN(rest[index:], " :", 2); len(split) == 2 {
+		alert.Message = strings.TrimSpace(split[1])
+	}
+	return alert, true
+}
+
+// newModEvent reads a CLEARCHAT or CLEARMSG, which Twitch sends when a user is banned, timed out or has a message
+// deleted, whether by the bot or by a moderator on Twitch. ok is false for any other message, including a CLEARCHAT that
+// clears the whole chat.
+func newModEvent(response string) (entry bot.ModLogEntry, ok bool) {
+	metadata, rest := parseTags(response)
+	// e.g. ':tmi.twitch.tv CLEARCHAT #channel :user'
+	fields := strings.SplitN(rest, " ", 4)
+	if len(fields) != 4 || !strings.HasPrefix(fields[3], ":") {
+		return entry, false
+	}
+	trailing := strings.TrimPrefix(fields[3], ":")
+
+	switch fields[1] {
+	case "CLEARCHAT":
+		entry.Target = strings.ToLower(strings.TrimSpace(trailing))
+		entry.TargetID = metadata["target-user-id"]
+		seconds, err := strconv.Atoi(metadata["ban-duration"])
+		switch {
+		case err != nil:
+			entry.Action = bot.ModActionBan
+		case seconds <= 1: // how the bot purges a user's messages
+			entry.Action = bot.ModActionPurge
+		default:
+			entry.Action = bot.ModActionTimeout
+			entry.Duration = time.Duration(seconds) * time.Second
+		}
+	case "CLEARMSG":
+		entry.Action = bot.ModActionDelete
+		entry.Target = strings.ToLower(metadata["login"])
+		entry.Excerpt = trailing
+	default:
+		return entry, false
+	}
+	if entry.Target == "" {
+		return entry, false
+	}
+
+	if sent, err := strconv.ParseInt(metadata["tmi-sent-ts"], 10, 64); err == nil {
+		entry.Timestamp = time.UnixMilli(sent)
+	}
+	return entry, true
+}
+
+// newMembership reads a JOIN or PART of the form ':user!user@user.tmi.twitch.tv JOIN #channel', returning the event
+// type along with the user. ok is false for any other message.
+func newMembership(response string) (eventType bot.EventType, user bot.UserEvent, ok bool) {
+	fields := strings.Fields(response)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], ":") || !strings.HasPrefix(fields[2], "#") {
+		return eventType, user, false
+	}
+
+	switch fields[1] {
+	case "JOIN":
+		eventType = bot.EventJoin
+	case "PART":
+		eventType = bot.EventPart
+	default:
+		return eventType, user, false
+	}
+	user.User = strings.ToLower(strings.SplitN(strings.TrimPrefix(fields[0], ":"), "!", 2)[0])
+	return eventType, user, true
+}
+
+// parseEmotes reads the emotes tag, of the form 'emoteID:start-end,start-end/emoteID:start-end', and returns the name
+// of each emote used in msg
+func parseEmotes(tag, msg string) []string {
+	if tag == "" {
+		return nil
+	}
+
+	var emotes []string
+	runes := []rune(msg) // positions are given in characters, not bytes
+	for _, emote := range strings.Split(tag, "/") {
+		split := strings.SplitN(emote, ":", 2)
+		if len(split) != 2 {
+			continue
+		}
+		for _, position := range strings.Split(split[1], ",") {
+			var start, end int
+			if _, err := fmt.Sscanf(position, "%d-%d", &start, &end); err != nil || start > end || end >= len(runes) {
+				continue
+			}
+			emotes = append(emotes, string(runes[start:end+1]))
+		}
+	}
+	return emotes
+}
+
+// parsePerm determines the sender's highest permission level from a message's badges / tags
+func parsePerm(metadata map[string]string) uint8 {
+	perm := bot.PermAll
+	if metadata["subscriber"] == "1" {
+		perm = bot.PermSubscriber
+	}
+	if metadata["mod"] == "1" {
+		perm = bot.PermModerator
+	}
+
+	for _, badge := range strings.Split(metadata["badges"], ",") {
+		switch strings.Split(badge, "/")[0] {
+		case "broadcaster":
+			return bot.PermBroadcaster
+		case "moderator":
+			perm = bot.PermModerator
+		case "subscriber", "founder":
+			if perm < bot.PermSubscriber {
+				perm = bot.PermSubscriber
+			}
+		}
+	}
+
+	return perm
+}
@@ -3,6 +3,7 @@ package twitch
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/liamphmurphy/pleasantbot/bot"
 )
@@ -17,7 +18,7 @@ func TestNewTwitchItem(t *testing.T) {
 		{
 			description: "should process a standard chat message",
 			inputMsg:    "@badge-info=subscriber/91;badges=broadcaster/1,subscriber/3000,premium/1;client-nonce=2d59456ff9792c4aa9521d53f109091b;color=#D3D3D3;display-name=test-user;emotes=;first-msg=0;flags=;id=a6416f66-c477-47e2-ad6c-44c38a20f919;mod=0;room-id=26692942;subscriber=1;tmi-sent-ts=1642452235079;turbo=0;user-id=26692942;user-type= :test-user!test-user@test-user.tmi.twitch.tv PRIVMSG #test-user :test message",
-			wantItem:    bot.Item{Contents: "test message", Sender: bot.User{Name: "test-user"}},
+			wantItem:    bot.Item{ID: "a6416f66-c477-47e2-ad6c-44c38a20f919", Contents: "test message", Sender: bot.User{ID: "26692942", Name: "test-user", Perm: bot.PermBroadcaster}},
 			wantErr:     nil,
 		},
 		{
@@ -29,13 +30,19 @@ func TestNewTwitchItem(t *testing.T) {
 		{
 			description: "detect a case of a command invocation without any key, e.g. !quote or !help.",
 			inputMsg:    "@badge-info=subscriber/91;badges=broadcaster/1,subscriber/3000,premium/1;client-nonce=2d59456ff9792c4aa9521d53f109091b;color=#D3D3D3;display-name=test-user;emotes=;first-msg=0;flags=;id=a6416f66-c477-47e2-ad6c-44c38a20f919;mod=0;room-id=26692942;subscriber=1;tmi-sent-ts=1642452235079;turbo=0;user-id=26692942;user-type= :test-user!test-user@test-user.tmi.twitch.tv PRIVMSG #test-user :!quote",
-			wantItem:    bot.Item{Type: "!quote", Sender: bot.User{Name: "test-user"}},
+			wantItem:    bot.Item{ID: "a6416f66-c477-47e2-ad6c-44c38a20f919", Type: "!quote", Sender: bot.User{ID: "26692942", Name: "test-user", Perm: bot.PermBroadcaster}},
+			wantErr:     nil,
+		},
+		{
+			description: "detect a counter being changed, e.g. !deaths+",
+			inputMsg:    "@badge-info=;badges=moderator/1;display-name=test-user;emotes=;first-msg=0;flags=;id=b1;mod=1;user-id=1;user-type=mod :test-user!test-user@test-user.tmi.twitch.tv PRIVMSG #test-user :!deaths+",
+			wantItem:    bot.Item{ID: "b1", Type: "!deaths+", Sender: bot.User{ID: "1", Name: "test-user", Perm: bot.PermModerator}},
 			wantErr:     nil,
 		},
 		{
 			description: "detect a case of a full command invocation, in this example, !",
 			inputMsg:    "@badge-info=subscriber/91;badges=broadcaster/1,subscriber/3000,premium/1;client-nonce=2d59456ff9792c4aa9521d53f109091b;color=#D3D3D3;display-name=test-user;emotes=;first-msg=0;flags=;id=a6416f66-c477-47e2-ad6c-44c38a20f919;mod=0;room-id=26692942;subscriber=1;tmi-sent-ts=1642452235079;turbo=0;user-id=26692942;user-type= :test-user!test-user@test-user.tmi.twitch.tv PRIVMSG #test-user :!com add !somecommand this is a test command",
-			wantItem:    bot.Item{Sender: bot.User{Name: "test-user"}, Type: "!com", Command: "add", Key: "!somecommand", Contents: "this is a test command"},
+			wantItem:    bot.Item{ID: "a6416f66-c477-47e2-ad6c-44c38a20f919", Sender: bot.User{ID: "26692942", Name: "test-user", Perm: bot.PermBroadcaster}, Type: "!com", Command: "add", Key: "!somecommand", Contents: "this is a test command"},
 			wantErr:     nil,
 		},
 		{
@@ -43,8 +50,11 @@ func TestNewTwitchItem(t *testing.T) {
 			inputMsg:    "@badge-info=subscriber/91;badges=broadcaster/1,subscriber/3000,premium/1;client-nonce=2d59456ff9792c4aa9521d53f109091b;color=#D3D3D3;display-name=test-user;emotes=;first-msg=0;flags=;id=a6416f66-c477-47e2-ad6c-44c38a20f919;mod=0;room-id=26692942;subscriber=1;tmi-sent-ts=1642452235079;turbo=0;user-id=26692942;user-type= :test-user!test-user@test-user.tmi.twitch.tv PRIVMSG #test-user :!quote add this is a new quote",
 			wantItem: bot.Item{
 				IsServerInfo: false,
+				ID:           "a6416f66-c477-47e2-ad6c-44c38a20f919",
 				Sender: bot.User{
+					ID:   "26692942",
 					Name: "test-user",
+					Perm: bot.PermBroadcaster,
 				},
 				Type:     "!quote",
 				Command:  "add",
@@ -53,6 +63,36 @@ func TestNewTwitchItem(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			description: "detect a case of a Type and Command only, from a moderator",
+			inputMsg:    "@badge-info=;badges=moderator/1;color=;display-name=Test-Mod;emotes=;first-msg=0;flags=;id=b1;mod=1;room-id=26692942;subscriber=0;tmi-sent-ts=1642452235079;turbo=0;user-id=1234;user-type=mod :test-mod!test-mod@test-mod.tmi.twitch.tv PRIVMSG #test-user :!badword list",
+			wantItem:    bot.Item{ID: "b1", Sender: bot.User{ID: "1234", Name: "test-mod", Perm: bot.PermModerator}, Type: "!badword", Command: "list"},
+			wantErr:     nil,
+		},
+		{
+			description: "should read the emotes used in a message",
+			inputMsg:    "@badge-info=;badges=;color=;display-name=viewer;emotes=25:0-4,12-16/1902:6-10;first-msg=0;flags=;id=c1;mod=0;room-id=26692942;subscriber=0;tmi-sent-ts=1642452235079;turbo=0;user-id=99;user-type= :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #test-user :Kappa Keepo Kappa",
+			wantItem:    bot.Item{ID: "c1", Sender: bot.User{ID: "99", Name: "viewer"}, Contents: "Kappa Keepo Kappa", Emotes: []string{"Kappa", "Kappa", "Keepo"}},
+			wantErr:     nil,
+		},
+		{
+			description: "should keep colons and semicolons inside the message",
+			inputMsg:    "@badge-info=;badges=subscriber/6;color=;display-name=viewer;emotes=;first-msg=0;flags=;id=d1;mod=0;room-id=26692942;subscriber=1;tmi-sent-ts=1642452235079;turbo=0;user-id=99;user-type= :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #test-user :look: https://example.com; neat",
+			wantItem:    bot.Item{ID: "d1", Sender: bot.User{ID: "99", Name: "viewer", Perm: bot.PermSubscriber}, Contents: "look: https://example.com; neat"},
+			wantErr:     nil,
+		},
+		{
+			description: "should notice a user's first message in the channel",
+			inputMsg:    "@badge-info=;badges=;color=;display-name=newbie;emotes=;first-msg=1;flags=;id=e1;mod=0;room-id=26692942;subscriber=0;tmi-sent-ts=1642452235079;turbo=0;user-id=98;user-type= :newbie!newbie@newbie.tmi.twitch.tv PRIVMSG #test-user :hello!",
+			wantItem:    bot.Item{ID: "e1", Sender: bot.User{ID: "98", Name: "newbie"}, Contents: "hello!", FirstMessage: true},
+			wantErr:     nil,
+		},
+		{
+			description: "should keep punctuation in the last word of a command's contents",
+			inputMsg:    "@badge-info=;badges=moderator/1;color=;display-name=Test-Mod;emotes=;first-msg=0;flags=;id=f1;mod=1;room-id=26692942;subscriber=0;tmi-sent-ts=1642452235079;turbo=0;user-id=1234;user-type=mod :test-mod!test-mod@test-mod.tmi.twitch.tv PRIVMSG #test-user :!bet open \"Will we win?\" yes|no",
+			wantItem:    bot.Item{ID: "f1", Sender: bot.User{ID: "1234", Name: "test-mod", Perm: bot.PermModerator}, Type: "!bet", Command: "open", Contents: "\"Will we win?\" yes|no"},
+			wantErr:     nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -72,3 +112,106 @@ func TestNewTwitchItem(t *testing.T) {
 		})
 	}
 }
+
+func TestNewModEvent(t *testing.T) {
+	tests := []struct {
+		description string
+		inputMsg    string
+		wantEntry   bot.ModLogEntry
+		wantOk      bool
+	}{
+		{
+			description: "should read a ban",
+			inputMsg:    "@room-id=26692942;target-user-id=99;tmi-sent-ts=1642452235079 :tmi.twitch.tv CLEARCHAT #test-user :Spammer",
+			wantEntry:   bot.ModLogEntry{Action: bot.ModActionBan, Target: "spammer", TargetID: "99", Timestamp: time.UnixMilli(1642452235079)},
+			wantOk:      true,
+		},
+		{
+			description: "should read a timeout",
+			inputMsg:    "@ban-duration=600;room-id=26692942;target-user-id=99;tmi-sent-ts=1642452235079 :tmi.twitch.tv CLEARCHAT #test-user :viewer",
+			wantEntry: bot.ModLogEntry{Action: bot.ModActionTimeout, Target: "viewer", TargetID: "99", Duration: 10 * time.Minute,
+				Timestamp: time.UnixMilli(1642452235079)},
+			wantOk: true,
+		},
+		{
+			description: "should read a purge",
+			inputMsg:    "@ban-duration=1;room-id=26692942;target-user-id=99;tmi-sent-ts=1642452235079 :tmi.twitch.tv CLEARCHAT #test-user :viewer",
+			wantEntry:   bot.ModLogEntry{Action: bot.ModActionPurge, Target: "viewer", TargetID: "99", Timestamp: time.UnixMilli(1642452235079)},
+			wantOk:      true,
+		},
+		{
+			description: "should read a deleted message",
+			inputMsg:    "@login=viewer;room-id=;target-msg-id=abc;tmi-sent-ts=1642452235079 :tmi.twitch.tv CLEARMSG #test-user :buy followers: cheap",
+			wantEntry:   bot.ModLogEntry{Action: bot.ModActionDelete, Target: "viewer", Excerpt: "buy followers: cheap", Timestamp: time.UnixMilli(1642452235079)},
+			wantOk:      true,
+		},
+		{
+			description: "should ignore the whole chat being cleared",
+			inputMsg:    "@room-id=26692942;tmi-sent-ts=1642452235079 :tmi.twitch.tv CLEARCHAT #test-user",
+		},
+		{
+			description: "should ignore chat messages",
+			inputMsg:    "@display-name=viewer;id=d1;user-id=99 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #test-user :CLEARCHAT #test-user :viewer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			entry, ok := newModEvent(test.inputMsg)
+			if ok != test.wantOk {
+				t.Fatalf("did not get the expected result\ngot - %v\nwant - %v", ok, test.wantOk)
+			}
+			if ok && !reflect.DeepEqual(entry, test.wantEntry) {
+				t.Errorf("did not get an expected entry\ngot - %+v\nwant - %+v", entry, test.wantEntry)
+			}
+		})
+	}
+}
+
+func TestNewAlert(t *testing.T) {
+	tests := []struct {
+		description string
+		inputMsg    string
+		wantAlert   bot.AlertEvent
+		wantOk      bool
+	}{
+		{
+			description: "should read a resub with a message",
+			inputMsg:    "@badge-info=subscriber/14;badges=subscriber/12;display-name=Viewer;login=viewer;msg-id=resub;msg-param-cumulative-months=14;msg-param-sub-plan=1000;room-id=26692942;user-id=99 :tmi.twitch.tv USERNOTICE #test-user :still here: love the stream",
+			wantAlert:   bot.AlertEvent{Kind: bot.AlertResub, User: "viewer", Months: 14, Message: "still here: love the stream"},
+			wantOk:      true,
+		},
+		{
+			description: "should read a gifted sub",
+			inputMsg:    "@display-name=Gifter;login=gifter;msg-id=subgift;msg-param-months=1;msg-param-recipient-display-name=Lucky;msg-param-recipient-user-name=lucky;room-id=26692942;user-id=98 :tmi.twitch.tv USERNOTICE #test-user",
+			wantAlert:   bot.AlertEvent{Kind: bot.AlertSubGift, User: "gifter", Recipient: "lucky"},
+			wantOk:      true,
+		},
+		{
+			description: "should read a raid",
+			inputMsg:    "@display-name=Raider;login=raider;msg-id=raid;msg-param-displayName=Raider;msg-param-viewerCount=42;room-id=26692942;user-id=97 :tmi.twitch.tv USERNOTICE #test-user",
+			wantAlert:   bot.AlertEvent{Kind: bot.AlertRaid, User: "raider", Viewers: 42},
+			wantOk:      true,
+		},
+		{
+			description: "should ignore other user notices",
+			inputMsg:    "@display-name=Viewer;login=viewer;msg-id=ritual;msg-param-ritual-name=new_chatter;room-id=26692942;user-id=99 :tmi.twitch.tv USERNOTICE #test-user :HeyGuys",
+		},
+		{
+			description: "should ignore chat messages",
+			inputMsg:    "@display-name=viewer;id=d1;user-id=99 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #test-user :msg-id=raid USERNOTICE",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			alert, ok := newAlert(test.inputMsg)
+			if ok != test.wantOk {
+				t.Fatalf("did not get the expected result\ngot - %v\nwant - %v", ok, test.wantOk)
+			}
+			if ok && !reflect.DeepEqual(alert, test.wantAlert) {
+				t.Errorf("did not get an expected alert\ngot - %+v\nwant - %+v", alert, test.wantAlert)
+			}
+		})
+	}
+}
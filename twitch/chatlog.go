@@ -0,0 +1,39 @@
+// chatlog.go writes chat messages and moderation actions to the chat log as they come off the event bus, and prunes
+// it of old lines
+
+package twitch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// chatLogBuffer is how many lines can wait to be written. The log can't miss a line, so the read loop waits once it is
+// full, but it is large enough for the log to fall behind for a while without holding up chat.
+const chatLogBuffer = 1024
+
+// logChat writes a chat message or moderation action to the chat log. It doesn't take the lock, since moderation
+// actions are published while it is held and the log would wait on itself.
+func (t *Twitch) logChat(event bot.Event) {
+	if err := t.Bot.LogEvent(event); err != nil {
+		fmt.Printf("could not write to the chat log: %v\n", err)
+	}
+}
+
+// pruneChatLog deletes old lines from the chat log now and every hour after, it is only stopped by the bot exiting
+func (t *Twitch) pruneChatLog() {
+	prune := func() {
+		t.mu.Lock()
+		err := t.Bot.PruneChatLog()
+		t.mu.Unlock()
+		if err != nil {
+			fmt.Printf("could not prune the chat log: %v\n", err)
+		}
+	}
+	prune()
+	for range time.NewTicker(time.Hour).C {
+		prune()
+	}
+}
@@ -0,0 +1,83 @@
+// chatters.go holds the moderator commands for looking at and adding notes to chatter profiles
+
+package twitch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// maxNotesShown is how many of a chatter's latest notes !userinfo shows, to keep the message short
+const maxNotesShown = 3
+
+type ProfileAction struct{}
+
+func (pa *ProfileAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!userinfo" || item.Type == "!note"
+}
+
+// Action for a ProfileAction shows a chatter's profile with '!userinfo @user', and adds a note to it with
+// '!note @user <text>'
+func (pa *ProfileAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+	username := targetUser(item.Command)
+
+	if username == "" {
+		err = fmt.Errorf("usage: %s @user", item.Type)
+		if item.Type == "!note" {
+			err = fmt.Errorf("usage: !note @user <text>")
+		}
+	}
+
+	var profile bot.Profile
+	if err == nil {
+		profile, err = b.FindChatter(username)
+	}
+	if err == nil && item.Type == "!note" {
+		if err = b.AddNote(profile.ID, item.Sender.Name, item.Contents); err == nil {
+			response = fmt.Sprintf("added a note to %s's profile", profile.Name)
+		}
+	} else if err == nil {
+		response = describeProfile(profile)
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(fmt.Sprintf("@%s %s", item.Sender.Name, err.Error()))
+	}
+	return err
+}
+
+// describeProfile sums up a profile in a single chat message
+func describeProfile(profile bot.Profile) string {
+	const day = "Jan 2 2006"
+	description := fmt.Sprintf("%s: first seen %s, last seen %s, %d messages", profile.Name,
+		profile.FirstSeen.Format(day), profile.LastSeen.Format(day), profile.Messages)
+
+	var names []string
+	for _, change := range profile.Names {
+		if change.Name != profile.Name {
+			names = append(names, change.Name)
+		}
+	}
+	if len(names) > 0 {
+		description += ", previously known as " + strings.Join(names, ", ")
+	}
+
+	if len(profile.Notes) > 0 {
+		var notes []string
+		latest := profile.Notes
+		if len(latest) > maxNotesShown {
+			latest = latest[len(latest)-maxNotesShown:]
+		}
+		for _, note := range latest {
+			notes = append(notes, fmt.Sprintf("%s (%s)", note.Note, note.Author))
+		}
+		description += fmt.Sprintf(" - %d notes: %s", len(profile.Notes), strings.Join(notes, "; "))
+	}
+	return description
+}
@@ -0,0 +1,143 @@
+// counters.go holds the chat side of counters, managing them with !counter and using each one as its own command
+
+package twitch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type CounterAction struct{}
+
+type CountAction struct{}
+
+type ShowCountAction struct{}
+
+func (ca *CounterAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!counter"
+}
+
+// Action for a CounterAction manages counters: '!counter add deaths [stream]' creates one, reset each stream if
+// 'stream' is given, '!counter del deaths' removes it, '!counter reset' resets the per stream counters and
+// '!counter list' shows them all
+func (ca *CounterAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+	args := strings.Fields(strings.ToLower(strings.Join([]string{item.Key, item.Contents}, " ")))
+
+	switch item.Command {
+	case "add", "new":
+		if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "stream") {
+			err = fmt.Errorf("usage: !counter add <name> [stream]")
+			break
+		}
+		var counter *bot.Counter
+		if counter, err = b.AddCounter(strings.TrimPrefix(args[0], "!"), len(args) == 2); err == nil {
+			response = fmt.Sprintf("added the counter !%s, change it with !%s+, !%s- and !%s set <value>", counter.Name,
+				counter.Name, counter.Name, counter.Name)
+		}
+	case "del", "rm", "delete", "remove":
+		if len(args) != 1 {
+			err = fmt.Errorf("usage: !counter del <name>")
+			break
+		}
+		var found bool
+		name := strings.TrimPrefix(args[0], "!")
+		if found, err = b.RemoveCounter(name); err == nil && found {
+			response = fmt.Sprintf("removed the counter !%s", name)
+		} else if err == nil {
+			response = fmt.Sprintf("there is no counter named !%s", name)
+		}
+	case "reset":
+		if len(args) == 1 {
+			name := strings.TrimPrefix(args[0], "!")
+			if err = b.SetCounter(name, 0); err == nil {
+				response = fmt.Sprintf("!%s has been reset to 0", name)
+			}
+			break
+		}
+		var reset []string
+		if reset, err = b.ResetStreamCounters(); err == nil && len(reset) > 0 {
+			response = fmt.Sprintf("reset the counters for a new stream: %s", strings.Join(reset, ", "))
+		} else if err == nil {
+			response = "there are no counters that reset each stream, add one with !counter add <name> stream"
+		}
+	case "list", "":
+		var counters []string
+		for _, counter := range b.SortedCounters() {
+			counters = append(counters, fmt.Sprintf("%s: %d", counter.Name, counter.Value))
+		}
+		response = "there are no counters yet"
+		if len(counters) > 0 {
+			response = "counters - " + strings.Join(counters, ", ")
+		}
+	default:
+		err = fmt.Errorf("usage: !counter <add|del|reset|list>")
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+	return err
+}
+
+// counterName returns the counter a command is for, e.g. "deaths" for '!deaths+', and the change it makes: "+", "-",
+// "set" or "" to show it, anything else after the command is ignored. found is false if there is no such counter.
+func counterName(item bot.Item, b *bot.Bot) (name string, change string, found bool) {
+	name = strings.TrimPrefix(strings.ToLower(item.Type), "!")
+	if strings.HasSuffix(name, "+") || strings.HasSuffix(name, "-") {
+		name, change = name[:len(name)-1], name[len(name)-1:]
+	} else if strings.EqualFold(item.Command, "set") {
+		change = "set"
+	}
+	_, found = b.Counters[name]
+	return name, change, found
+}
+
+func (ca *CountAction) Condition(item bot.Item, b *bot.Bot) bool {
+	_, change, found := counterName(item, b)
+	return found && change != ""
+}
+
+// Action for a CountAction changes a counter with '!deaths+', '!deaths-' or '!deaths set 10'
+func (ca *CountAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	name, change, _ := counterName(item, b)
+
+	var err error
+	var value int64
+	switch change {
+	case "+":
+		value, err = b.AdjustCounter(name, 1)
+	case "-":
+		value, err = b.AdjustCounter(name, -1)
+	default:
+		if value, err = strconv.ParseInt(strings.TrimSpace(item.Contents), 10, 64); err != nil {
+			err = fmt.Errorf("usage: !%s set <value>", name)
+			break
+		}
+		err = b.SetCounter(name, value)
+	}
+
+	if err != nil {
+		messenger.Message(err.Error())
+		return err
+	}
+	messenger.Message(fmt.Sprintf("%s: %d", name, value))
+	return nil
+}
+
+func (sa *ShowCountAction) Condition(item bot.Item, b *bot.Bot) bool {
+	_, change, found := counterName(item, b)
+	return found && change == ""
+}
+
+// Action for a ShowCountAction shows a counter's value with '!deaths'
+func (sa *ShowCountAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	name, _, _ := counterName(item, b)
+	return messenger.Message(fmt.Sprintf("%s: %d", name, b.Counters[name].Value))
+}
@@ -0,0 +1,81 @@
+package twitch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+func TestCounterCommands(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database, Commands: map[string]*bot.CommandValue{
+		"!deathcount": {Response: "we have died {counter:deaths} times", Perm: "all"},
+	}}
+	if err := b.LoadCounters(); err != nil {
+		t.Fatalf("could not load the counters: %v", err)
+	}
+
+	mod := bot.User{Name: "mod", Perm: bot.PermModerator}
+	viewer := bot.User{Name: "viewer"}
+	tests := []struct {
+		description  string
+		inputItem    bot.Item
+		wantMessages []string
+	}{
+		{
+			description:  "should add a counter",
+			inputItem:    bot.Item{Type: "!counter", Command: "add", Contents: "deaths stream", Sender: mod},
+			wantMessages: []string{"added the counter !deaths, change it with !deaths+, !deaths- and !deaths set <value>"},
+		},
+		{
+			description:  "should add one to a counter",
+			inputItem:    bot.Item{Type: "!deaths+", Sender: mod},
+			wantMessages: []string{"deaths: 1"},
+		},
+		{
+			description:  "should not let viewers change a counter",
+			inputItem:    bot.Item{Type: "!deaths+", Sender: viewer},
+			wantMessages: []string{"@viewer you don't have permission to use that command"},
+		},
+		{
+			description:  "should set a counter",
+			inputItem:    bot.Item{Type: "!deaths", Command: "set", Contents: "10", Sender: mod},
+			wantMessages: []string{"deaths: 10"},
+		},
+		{
+			description:  "should take one from a counter",
+			inputItem:    bot.Item{Type: "!deaths-", Sender: mod},
+			wantMessages: []string{"deaths: 9"},
+		},
+		{
+			description:  "should show a counter to viewers",
+			inputItem:    bot.Item{Type: "!deaths", Command: "lol", Sender: viewer},
+			wantMessages: []string{"deaths: 9"},
+		},
+		{
+			description:  "should fill in a counter in a command's response",
+			inputItem:    bot.Item{Type: "!deathcount", Sender: viewer},
+			wantMessages: []string{"we have died 9 times"},
+		},
+		{
+			description:  "should reset the per stream counters",
+			inputItem:    bot.Item{Type: "!counter", Command: "reset", Sender: mod},
+			wantMessages: []string{"reset the counters for a new stream: deaths"},
+		},
+	}
+
+	router := (&Twitch{Bot: b}).newRouter()
+	for _, test := range tests {
+		messenger := &recordMessenger{}
+		router.Dispatch(test.inputItem, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// giveaways.go holds the chat side of giveaways, from the moderator commands to entering with the keyword
+
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type GiveawayAction struct {
+	Lock sync.Locker // held while a winner's time to claim the prize runs out, so it can't race with chat
+}
+
+func (ga *GiveawayAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!giveaway"
+}
+
+// Action for a GiveawayAction runs giveaways: '!giveaway start <keyword> [cost] [sub luck]' opens entries,
+// '!giveaway draw' closes them and draws a winner, '!giveaway reroll' draws someone else and '!giveaway end' finishes
+// the giveaway
+func (ga *GiveawayAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+
+	switch item.Command {
+	case "start", "open":
+		args := strings.Fields(strings.Join([]string{item.Key, item.Contents}, " "))
+		var cost int64
+		subLuck := 1
+		if len(args) > 1 {
+			cost, err = strconv.ParseInt(args[1], 10, 64)
+		}
+		if len(args) > 2 && err == nil {
+			subLuck, err = strconv.Atoi(args[2])
+		}
+		if len(args) == 0 || err != nil {
+			err = fmt.Errorf("usage: !giveaway start <keyword> [cost] [sub luck]")
+			break
+		}
+
+		var giveaway *bot.Giveaway
+		if giveaway, err = b.StartGiveaway(args[0], cost, subLuck, item.Sender.Name); err == nil {
+			response = fmt.Sprintf("a giveaway has started! type %s to enter", giveaway.Keyword)
+			if cost > 0 {
+				response += fmt.Sprintf(", it costs %s", b.FormatPoints(cost))
+			}
+			if giveaway.SubLuck > 1 {
+				response += fmt.Sprintf(", subscribers get %d times the luck", giveaway.SubLuck)
+			}
+		}
+	case "draw", "reroll":
+		var winner bot.Winner
+		if winner, err = b.DrawGiveaway(); err == nil {
+			response = fmt.Sprintf("@%s has won the giveaway!", winner.User)
+			if claimTime := b.Giveaway.ClaimTime; claimTime > 0 {
+				response += fmt.Sprintf(" say something in chat within %s to claim it", claimTime)
+				ga.expireClaim(b, messenger, winner.User, claimTime)
+			}
+		}
+	case "end", "close":
+		var giveaway *bot.Giveaway
+		if giveaway, err = b.EndGiveaway(); err == nil {
+			response = fmt.Sprintf("the giveaway has ended with %d entries", len(giveaway.Entries))
+		}
+	default:
+		if b.Giveaway == nil {
+			err = fmt.Errorf("usage: !giveaway <start|draw|reroll|end>")
+			break
+		}
+		response = fmt.Sprintf("the giveaway for '%s' has %d entries", b.Giveaway.Keyword, len(b.Giveaway.Entries))
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+	return err
+}
+
+// expireClaim forfeits a winner that hasn't claimed the prize once claimTime is up
+func (ga *GiveawayAction) expireClaim(b *bot.Bot, messenger bot.Messenger, user string, claimTime time.Duration) {
+	time.AfterFunc(claimTime, func() {
+		ga.Lock.Lock()
+		defer ga.Lock.Unlock()
+		forfeited, err := b.ForfeitGiveaway(user)
+		if err != nil {
+			fmt.Printf("could not forfeit the giveaway for %s: %v\n", user, err)
+		} else if forfeited {
+			messenger.Message(fmt.Sprintf("@%s didn't claim the giveaway in time, use !giveaway reroll to draw again", user))
+		}
+	})
+}
+
+// giveawayMessage enters a chat message's sender into the running giveaway and lets a winner claim their prize
+func (t *Twitch) giveawayMessage(item bot.Item) {
+	if claimed, err := t.Bot.ClaimGiveaway(item.Sender.Name); err != nil {
+		fmt.Printf("could not claim the giveaway for %s: %v\n", item.Sender.Name, err)
+	} else if claimed {
+		t.Message(fmt.Sprintf("@%s has claimed the giveaway, congratulations!", item.Sender.Name))
+	}
+
+	_, err := t.Bot.EnterGiveaway(item)
+	if errors.Is(err, bot.ErrNotEnoughPoints) {
+		t.Message(fmt.Sprintf("@%s you need %s to enter the giveaway", item.Sender.Name, t.Bot.FormatPoints(t.Bot.Giveaway.Cost)))
+	} else if err != nil {
+		fmt.Printf("could not enter %s into the giveaway: %v\n", item.Sender.Name, err)
+	}
+}
@@ -0,0 +1,97 @@
+package twitch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+func TestGiveawayCommands(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database}
+
+	mod := bot.User{Name: "test-mod", Perm: bot.PermModerator}
+	tests := []struct {
+		description  string
+		inputItem    bot.Item
+		wantMessages []string
+		wantKeyword  string
+		wantCost     int64
+		wantSubLuck  int
+	}{
+		{
+			description:  "should start a giveaway with a plain keyword",
+			inputItem:    bot.Item{Type: "!giveaway", Command: "start", Contents: "win 50 2", Sender: mod},
+			wantMessages: []string{"a giveaway has started! type win to enter, it costs 50 points, subscribers get 2 times the luck"},
+			wantKeyword:  "win",
+			wantCost:     50,
+			wantSubLuck:  2,
+		},
+		{
+			description:  "should start a giveaway with a ! keyword",
+			inputItem:    bot.Item{Type: "!giveaway", Command: "start", Key: "!enter", Contents: "25", Sender: mod},
+			wantMessages: []string{"a giveaway has started! type !enter to enter, it costs 25 points"},
+			wantKeyword:  "!enter",
+			wantCost:     25,
+			wantSubLuck:  1,
+		},
+		{
+			description:  "should start a free giveaway with only a ! keyword",
+			inputItem:    bot.Item{Type: "!giveaway", Command: "open", Key: "!enter", Sender: mod},
+			wantMessages: []string{"a giveaway has started! type !enter to enter"},
+			wantKeyword:  "!enter",
+			wantSubLuck:  1,
+		},
+		{
+			description:  "should show how to start a giveaway",
+			inputItem:    bot.Item{Type: "!giveaway", Command: "start", Key: "!enter", Contents: "lots", Sender: mod},
+			wantMessages: []string{"usage: !giveaway start <keyword> [cost] [sub luck]"},
+		},
+	}
+
+	router := (&Twitch{Bot: b}).newRouter()
+	for _, test := range tests {
+		b.Giveaway = nil
+		messenger := &recordMessenger{}
+		router.Dispatch(test.inputItem, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+		if test.wantKeyword == "" {
+			continue
+		}
+		if g := b.Giveaway; g == nil || g.Keyword != test.wantKeyword || g.Cost != test.wantCost || g.SubLuck != test.wantSubLuck {
+			t.Errorf("%s\ndid not start the expected giveaway\ngot - %+v\nwant - %s %d %d", test.description, g,
+				test.wantKeyword, test.wantCost, test.wantSubLuck)
+		}
+	}
+}
+
+func TestGiveawayEntryWithTickets(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database}
+	b.SetPoints("viewer", 100)
+	if _, err := b.StartGiveaway("!enter", 10, 1, "test-mod"); err != nil {
+		t.Fatalf("could not start the giveaway: %v", err)
+	}
+
+	// '!enter 3' is parsed as a command, the number of tickets ending up in Command
+	tw := &Twitch{Bot: b}
+	tw.giveawayMessage(bot.Item{Type: "!enter", Command: "3", Sender: bot.User{Name: "viewer"}})
+	if tickets := b.Giveaway.Entries["viewer"]; tickets != 3 {
+		t.Errorf("did not get the expected tickets\ngot - %v\nwant - %v", tickets, 3)
+	}
+	if balance, _ := b.GetBalance("viewer"); balance.Points != 70 {
+		t.Errorf("did not take the cost of each ticket\ngot - %v\nwant - %v", balance.Points, 70)
+	}
+}
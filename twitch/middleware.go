@@ -0,0 +1,149 @@
+// middleware.go holds the middleware every command runs through, such as permission checks and cooldowns
+
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+var (
+	errNotPermitted = errors.New("you don't have permission to use that command")
+	errOnCooldown   = errors.New("the command is on cooldown")
+)
+
+// Recover stops a panicking action from taking down the bot, the panic is returned as an error instead
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("the %s command panicked: %v\n%s", ctx.Item.Type, r, debug.Stack())
+					err = fmt.Errorf("the %s command failed", ctx.Item.Type)
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// LogCommands passes every command that is run to publish, and prints the ones that fail
+func LogCommands(publish func(bot.CommandEvent)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			err := next(ctx)
+			if errors.Is(err, ErrContinue) {
+				return err
+			}
+
+			event := bot.CommandEvent{Type: ctx.Item.Type, Command: ctx.Item.Command, Key: ctx.Item.Key, User: ctx.Item.Sender.Name}
+			if err != nil {
+				event.Error = err.Error()
+				if !errors.Is(err, errOnCooldown) {
+					fmt.Printf("%s's %s command failed: %v\n", ctx.Item.Sender.Name, ctx.Item.Type, err)
+				}
+			}
+			publish(event)
+			return err
+		}
+	}
+}
+
+// RequirePerm stops users below the route's permission level from using it
+func RequirePerm() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.Item.Sender.Perm < ctx.Route.Perm {
+				ctx.Messenger.Message(fmt.Sprintf("@%s %s", ctx.Item.Sender.Name, errNotPermitted))
+				return errNotPermitted
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Cooldown stops a user from using a command again until its route's cooldown is up. Moderators are never on cooldown, and
+// users on cooldown are ignored rather than told so, to keep chat quiet.
+func Cooldown() Middleware {
+	type key struct {
+		route       *Route
+		commandType string // routes without types handle many commands, each with its own cooldown
+		user        string
+	}
+	var mu sync.Mutex
+	lastUsed := make(map[key]time.Time)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.Route.Cooldown <= 0 || ctx.Item.Sender.IsModerator() {
+				return next(ctx)
+			}
+
+			k := key{route: ctx.Route, commandType: ctx.Item.Type, user: ctx.Item.Sender.Name}
+			mu.Lock()
+			if time.Since(lastUsed[k]) < ctx.Route.Cooldown {
+				mu.Unlock()
+				return errOnCooldown
+			}
+			lastUsed[k] = time.Now()
+			mu.Unlock()
+			return next(ctx)
+		}
+	}
+}
+
+// CommandMetrics counts how a single command type has been used
+type CommandMetrics struct {
+	Uses     int           `json:"uses"`
+	Errors   int           `json:"errors"`
+	Duration time.Duration `json:"duration"` // total time spent running the command
+}
+
+// Metrics records CommandMetrics for every command type that is run
+type Metrics struct {
+	mu       sync.Mutex
+	commands map[string]CommandMetrics
+}
+
+// Middleware records the use of each command
+func (m *Metrics) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			start := time.Now()
+			err := next(ctx)
+			if errors.Is(err, ErrContinue) {
+				return err
+			}
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.commands == nil {
+				m.commands = make(map[string]CommandMetrics)
+			}
+			metrics := m.commands[ctx.Item.Type]
+			metrics.Uses++
+			metrics.Duration += time.Since(start)
+			if err != nil {
+				metrics.Errors++
+			}
+			m.commands[ctx.Item.Type] = metrics
+			return err
+		}
+	}
+}
+
+// Snapshot returns a copy of the metrics for every command type
+func (m *Metrics) Snapshot() map[string]CommandMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]CommandMetrics, len(m.commands))
+	for commandType, metrics := range m.commands {
+		snapshot[commandType] = metrics
+	}
+	return snapshot
+}
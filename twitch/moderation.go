@@ -0,0 +1,126 @@
+// moderation.go runs chat messages through the bot's moderation rules before they are handled as commands
+
+package twitch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+var (
+	linkReason        = "please ask a moderator before posting links"
+	deniedLinkReason  = "links to that site are not allowed"
+	longMessageReason = "that message is too long"
+)
+
+// modEchoWindow is how long after the bot logs an action the same action seen in chat is taken to be the bot's own
+const modEchoWindow = 10 * time.Second
+
+// moderate checks a chat message against the bot's moderation rules and punishes the sender if needed.
+// Returns true if the message was acted on, in which case it should not be handled any further.
+func (t *Twitch) moderate(item bot.Item) bool {
+	if item.IsServerInfo || item.Sender.IsModerator() {
+		return false
+	}
+
+	if found, badWord := t.Bot.ParseForBadWord(item.Contents); found {
+		punishment := bot.Punishment{Severity: badWord.Severity, Duration: time.Duration(t.Bot.BadWordTimeout) * time.Second}
+		t.infraction(item, bot.InfractionBadWord, punishment, t.Bot.BadWordReason(badWord.Severity))
+		return true
+	}
+
+	switch t.Bot.CheckLinks(item) {
+	case bot.LinkDenied:
+		t.infraction(item, bot.InfractionDeniedLink, bot.Punishment{Severity: bot.SeverityBan}, deniedLinkReason)
+		return true
+	case bot.LinkNotPermitted:
+		if t.Bot.PurgeForLinks {
+			t.infraction(item, bot.InfractionLink, bot.Punishment{Severity: bot.SeverityPurge}, linkReason)
+			return true
+		}
+	}
+
+	if t.Bot.PurgeForLongMsg && t.Bot.LongMsgAmount > 0 && len(item.Contents) > t.Bot.LongMsgAmount {
+		t.infraction(item, bot.InfractionLongMessage, bot.Punishment{Severity: bot.SeverityPurge}, longMessageReason)
+		return true
+	}
+
+	if found, filter := t.Bot.CheckSpamFilters(item); found {
+		t.infraction(item, filter.Name, filter.Punishment, filter.Reason)
+		return true
+	}
+
+	return false
+}
+
+// infraction handles a broken rule. When strikes are enabled the sender gets strikes, and the escalation ladder's
+// punishment is used in place of the rule's own punishment if it is harsher.
+func (t *Twitch) infraction(item bot.Item, infraction bot.Infraction, punishment bot.Punishment, reason string) {
+	if t.Bot.StrikesEnabled {
+		total, step, err := t.Bot.AddStrike(item.Sender.Name, infraction)
+		if err != nil {
+			fmt.Printf("could not save strike for %s: %v\n", item.Sender.Name, err)
+		}
+		if step.Harsher(punishment) {
+			punishment = step
+		}
+		if total > 0 {
+			reason = fmt.Sprintf("%s (strike %d)", reason, total)
+		}
+	}
+
+	t.punish(item, punishment, string(infraction), reason)
+}
+
+// punish performs the punishment against the sender of item and records it in the moderation log, then tells chat why
+// if a reason is given. rule is the name of the rule that was broken.
+func (t *Twitch) punish(item bot.Item, punishment bot.Punishment, rule, reason string) {
+	username := item.Sender.Name
+	switch punishment.Severity {
+	case bot.SeverityDelete:
+		t.DeleteMessage(item.ID)
+	case bot.SeverityPurge:
+		t.purgeUser(username)
+	case bot.SeverityTimeout:
+		t.Timeout(username, int(punishment.Duration.Seconds()))
+	case bot.SeverityBan:
+		t.Ban(username)
+	}
+
+	err := t.Bot.LogModAction(bot.ModLogEntry{Action: bot.ActionForSeverity(punishment.Severity), Actor: t.Bot.Name,
+		Target: username, TargetID: item.Sender.ID, Reason: reason, Rule: rule, Excerpt: item.Contents, Duration: punishment.Duration})
+	if err != nil {
+		fmt.Printf("could not log moderation action against %s: %v\n", username, err)
+	}
+
+	if reason != "" {
+		t.Message(fmt.Sprintf("@%s %s", username, reason))
+	}
+}
+
+// logChatModeration records a ban, timeout or deleted message that Twitch announced in chat, so actions moderators take
+// on Twitch itself are logged too. Twitch doesn't say who took the action, so the actor is left empty. Actions the bot
+// took are announced as well, and are skipped since they are already in the log.
+func (t *Twitch) logChatModeration(entry bot.ModLogEntry) {
+	if t.Bot.Storage == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recent, err := t.Bot.ModLog(bot.ModLogFilter{User: entry.Target, Since: time.Now().Add(-modEchoWindow)})
+	if err != nil {
+		fmt.Printf("could not read the moderation log for %s: %v\n", entry.Target, err)
+		return
+	}
+	for _, logged := range recent {
+		if logged.Action == entry.Action && logged.Target == entry.Target {
+			return
+		}
+	}
+	if err = t.Bot.LogModAction(entry); err != nil {
+		fmt.Printf("could not log moderation action against %s: %v\n", entry.Target, err)
+	}
+}
@@ -0,0 +1,141 @@
+package twitch
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+// recordConn stores everything written to it so tests can check what the bot sent to Twitch
+type recordConn struct {
+	net.Conn
+	written []string
+}
+
+func (rc *recordConn) Write(b []byte) (int, error) {
+	rc.written = append(rc.written, strings.TrimSuffix(string(b), "\r\n"))
+	return len(b), nil
+}
+
+func TestModerate(t *testing.T) {
+	reasons := map[bot.Severity]string{bot.SeverityTimeout: "take a break"}
+	tests := []struct {
+		description string
+		inputItem   bot.Item
+		badWords    []bot.BadWord
+		wantActed   bool
+		wantWritten []string
+	}{
+		{
+			description: "should delete a message containing a delete severity bad word",
+			inputItem:   bot.Item{ID: "abc", Sender: bot.User{Name: "viewer"}, Contents: "I love COOKIES"},
+			badWords:    []bot.BadWord{{Phrase: "cookies", Severity: bot.SeverityDelete}},
+			wantActed:   true,
+			wantWritten: []string{"PRIVMSG #channel :/delete abc"},
+		},
+		{
+			description: "should timeout with the configured duration and send the reason",
+			inputItem:   bot.Item{ID: "abc", Sender: bot.User{Name: "viewer"}, Contents: "cookies"},
+			badWords:    []bot.BadWord{{Phrase: "cookies", Severity: bot.SeverityTimeout}},
+			wantActed:   true,
+			wantWritten: []string{"PRIVMSG #channel :/timeout viewer 60", "PRIVMSG #channel :@viewer take a break"},
+		},
+		{
+			description: "should ban for a denied domain",
+			inputItem:   bot.Item{ID: "abc", Sender: bot.User{Name: "viewer", Perm: bot.PermSubscriber}, Contents: "free stuff at scam dot ru"},
+			wantActed:   true,
+			wantWritten: []string{"PRIVMSG #channel :/ban viewer", "PRIVMSG #channel :@viewer links to that site are not allowed"},
+		},
+		{
+			description: "should ignore moderators",
+			inputItem:   bot.Item{ID: "abc", Sender: bot.User{Name: "mod", Perm: bot.PermModerator}, Contents: "cookies"},
+			badWords:    []bot.BadWord{{Phrase: "cookies", Severity: bot.SeverityBan}},
+			wantActed:   false,
+		},
+		{
+			description: "should do nothing for a clean message",
+			inputItem:   bot.Item{ID: "abc", Sender: bot.User{Name: "viewer"}, Contents: "cupcakes"},
+			badWords:    []bot.BadWord{{Phrase: "cookies", Severity: bot.SeverityBan}},
+			wantActed:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			conn := &recordConn{}
+			tw := &Twitch{Bot: &bot.Bot{ChannelName: "channel", Conn: conn, BadWords: test.badWords,
+				BadWordReasons: reasons, BadWordTimeout: 60,
+				DeniedDomains: []string{"scam.ru"}}}
+
+			acted := tw.moderate(test.inputItem)
+			if acted != test.wantActed {
+				t.Errorf("did not get the expected result\ngot - %v\nwant - %v", acted, test.wantActed)
+			}
+
+			if !reflect.DeepEqual(conn.written, test.wantWritten) {
+				t.Errorf("did not send the expected messages\ngot - %v\nwant - %v", conn.written, test.wantWritten)
+			}
+		})
+	}
+}
+
+func TestModerateStrikes(t *testing.T) {
+	conn := &recordConn{}
+	tw := &Twitch{Bot: &bot.Bot{ChannelName: "channel", Conn: conn, PurgeForLinks: true, PostLinkPerm: bot.PermSubscriber, StrikesEnabled: true,
+		StrikeDecay: time.Hour, StrikeLadder: []bot.Punishment{{Severity: bot.SeverityWarn}, {Severity: bot.SeverityTimeout, Duration: time.Minute}}}}
+	item := bot.Item{ID: "abc", Sender: bot.User{Name: "viewer"}, Contents: "check out example.com"}
+
+	tw.moderate(item)
+	tw.moderate(item)
+
+	want := []string{
+		"PRIVMSG #channel :/timeout viewer 1",
+		"PRIVMSG #channel :@viewer please ask a moderator before posting links (strike 1)",
+		"PRIVMSG #channel :/timeout viewer 60",
+		"PRIVMSG #channel :@viewer please ask a moderator before posting links (strike 2)",
+	}
+	if !reflect.DeepEqual(conn.written, want) {
+		t.Errorf("did not send the expected messages\ngot - %v\nwant - %v", conn.written, want)
+	}
+}
+
+func TestLogChatModeration(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database, Name: "pleasantbot"}
+	tw := &Twitch{Bot: b}
+	if _, err := b.TrackChatter(bot.Item{Sender: bot.User{ID: "99", Name: "viewer"}, Contents: "hi"}); err != nil {
+		t.Fatalf("could not track the chatter: %v", err)
+	}
+
+	// the bot's own ban is announced in chat too, and isn't logged twice
+	b.LogModAction(bot.ModLogEntry{Action: bot.ModActionBan, Actor: b.Name, Target: "spammer", TargetID: "98"})
+	tw.logChatModeration(bot.ModLogEntry{Action: bot.ModActionBan, Target: "spammer", TargetID: "98"})
+	// a moderator timing someone out on Twitch is logged
+	tw.logChatModeration(bot.ModLogEntry{Action: bot.ModActionTimeout, Target: "viewer", TargetID: "99", Duration: time.Minute})
+	// an unban is logged with the user's ID from their profile
+	router := tw.newRouter()
+	router.Dispatch(bot.Item{Type: "!unban", Command: "@viewer", Sender: bot.User{Name: "test-mod", Perm: bot.PermModerator}}, b,
+		&recordMessenger{})
+
+	entries, err := b.ModLog(bot.ModLogFilter{})
+	if err != nil {
+		t.Fatalf("could not read the moderation log: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, strings.Join([]string{string(entry.Action), entry.Actor, entry.Target, entry.TargetID}, " "))
+	}
+	want := []string{"unban test-mod viewer 99", "timeout  viewer 99", "ban pleasantbot spammer 98"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("did not get the expected moderation log\ngot - %q\nwant - %q", got, want)
+	}
+}
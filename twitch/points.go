@@ -0,0 +1,171 @@
+// points.go holds the chat commands for loyalty points and keeps track of who is in the channel to earn them
+
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+const (
+	defaultTopBalances = 5
+	maxTopBalances     = 10
+)
+
+type PointsAction struct{}
+
+type GiveAction struct{}
+
+type TopAction struct{}
+
+type AdjustPointsAction struct{}
+
+func (pa *PointsAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!points" && b.PointsEnabled
+}
+
+// Action for a PointsAction shows a user's points, '!points' for the sender or '!points @user' for someone else
+func (pa *PointsAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	if username == "" {
+		username = item.Sender.Name
+	}
+
+	balance, err := b.GetBalance(username)
+	if err != nil {
+		return err
+	}
+	messenger.Message(fmt.Sprintf("@%s has %s and has watched for %dh%dm", username, b.FormatPoints(balance.Points),
+		balance.Minutes/60, balance.Minutes%60))
+	return nil
+}
+
+func (ga *GiveAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!give" && b.PointsEnabled
+}
+
+// Action for a GiveAction moves points from the sender to another user with '!give @user 100'
+func (ga *GiveAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	amount, err := strconv.ParseInt(item.Contents, 10, 64)
+	if username == "" || err != nil {
+		err = fmt.Errorf("usage: !give @user <amount>")
+		messenger.Message(err.Error())
+		return err
+	}
+
+	err = b.TransferPoints(item.Sender.Name, username, amount)
+	if err != nil {
+		messenger.Message(fmt.Sprintf("@%s %s", item.Sender.Name, err.Error()))
+		return err
+	}
+	messenger.Message(fmt.Sprintf("@%s gave %s to @%s", item.Sender.Name, b.FormatPoints(amount), username))
+	return nil
+}
+
+func (ta *TopAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!top" && b.PointsEnabled
+}
+
+// Action for a TopAction shows the users with the most points, '!top' or '!top 10'
+func (ta *TopAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	n := defaultTopBalances
+	if item.Command != "" {
+		var err error
+		if n, err = strconv.Atoi(item.Command); err != nil || n <= 0 {
+			err = fmt.Errorf("usage: !top [amount]")
+			messenger.Message(err.Error())
+			return err
+		}
+	}
+	if n > maxTopBalances {
+		n = maxTopBalances
+	}
+
+	balances, err := b.TopBalances(n)
+	if err != nil {
+		return err
+	}
+	if len(balances) == 0 {
+		messenger.Message("nobody has earned any points yet")
+		return nil
+	}
+
+	var ranks []string
+	for i, balance := range balances {
+		ranks = append(ranks, fmt.Sprintf("%d. %s (%d)", i+1, balance.User, balance.Points))
+	}
+	messenger.Message(strings.Join(ranks, ", "))
+	return nil
+}
+
+func (apa *AdjustPointsAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return (item.Type == "!addpoints" || item.Type == "!setpoints") && b.PointsEnabled
+}
+
+// Action for an AdjustPointsAction lets moderators change a user's points, '!addpoints @user 100' adds to their
+// balance (or takes away, with a negative amount) while '!setpoints @user 100' replaces it
+func (apa *AdjustPointsAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	username := targetUser(item.Command)
+	amount, err := strconv.ParseInt(item.Contents, 10, 64)
+	if username == "" || err != nil {
+		err = fmt.Errorf("usage: %s @user <amount>", item.Type)
+		messenger.Message(err.Error())
+		return err
+	}
+
+	balance := amount
+	if item.Type == "!addpoints" {
+		balance, err = b.AddPoints(username, amount)
+	} else {
+		err = b.SetPoints(username, amount)
+	}
+	if errors.Is(err, bot.ErrNotEnoughPoints) {
+		messenger.Message(fmt.Sprintf("@%s can't have less than 0 points", username))
+		return err
+	} else if err != nil {
+		return err
+	}
+	messenger.Message(fmt.Sprintf("@%s now has %s", username, b.FormatPoints(balance)))
+	return nil
+}
+
+// trackViewer follows users joining and leaving the channel, so they earn points while they're in it
+func (t *Twitch) trackViewer(event bot.Event) {
+	data, ok := event.Data.(bot.UserEvent)
+	if !ok || data.User == strings.ToLower(t.Bot.Name) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if event.Type == bot.EventJoin {
+		t.Bot.MarkPresent(data.User, bot.PermAll)
+	} else {
+		t.Bot.MarkAbsent(data.User)
+	}
+}
+
+// awardWatchTime gives everyone in the channel their points every minute, it is only stopped by the bot exiting
+func (t *Twitch) awardWatchTime() {
+	for range time.NewTicker(time.Minute).C {
+		t.mu.Lock()
+		err := t.Bot.AwardWatchTime()
+		t.mu.Unlock()
+		if err != nil {
+			fmt.Printf("could not award points for watching: %v\n", err)
+		}
+	}
+}
+
+// earnPoints awards the sender of a chat message their points for it
+func (t *Twitch) earnPoints(item bot.Item) {
+	if err := t.Bot.EarnMessagePoints(item); err != nil {
+		fmt.Printf("could not award points to %s: %v\n", item.Sender.Name, err)
+	}
+}
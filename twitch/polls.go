@@ -0,0 +1,153 @@
+// polls.go holds the chat side of polls, starting and ending them and counting votes
+
+package twitch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// questionRegex matches the arguments of '!poll "question" option one | option two [duration]'
+var questionRegex = regexp.MustCompile(`^"([^"]+)"\s+(.+)$`)
+
+type PollAction struct {
+	Lock sync.Locker // held while a poll closes by itself, so it can't race with chat
+}
+
+type VoteAction struct{}
+
+func (pa *PollAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!poll"
+}
+
+// Action for a PollAction runs polls: '!poll "question" option one | option two | option three [duration]' starts
+// one, '!poll end' closes it and posts the results and '!poll cancel' throws it away
+func (pa *PollAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+
+	switch item.Command {
+	case "end", "close":
+		var poll *bot.Poll
+		if poll, err = b.EndPoll(bot.PollClosed); err == nil {
+			response = fmt.Sprintf("the poll '%s' has ended! %s", poll.Question, poll.Results())
+		}
+	case "cancel":
+		var poll *bot.Poll
+		if poll, err = b.EndPoll(bot.PollCancelled); err == nil {
+			response = fmt.Sprintf("the poll '%s' was cancelled", poll.Question)
+		}
+	case "":
+		if b.Poll == nil {
+			err = fmt.Errorf(`usage: !poll "question" option one | option two [duration]`)
+			break
+		}
+		response = fmt.Sprintf("%s %s", b.Poll.Question, b.Poll.Results())
+	default:
+		question, options, duration, parseErr := parsePoll(strings.Join(strings.Fields(strings.Join([]string{item.Command,
+			item.Key, item.Contents}, " ")), " "))
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+		if duration == 0 {
+			duration = b.PollDuration
+		}
+
+		var poll *bot.Poll
+		if poll, err = b.StartPoll(question, options, duration, item.Sender.Name); err == nil {
+			var choices []string
+			for i, option := range options {
+				choices = append(choices, fmt.Sprintf("%d) %s", i+1, option))
+			}
+			response = fmt.Sprintf("poll: %s %s - vote with !vote <number>", question, strings.Join(choices, " "))
+			if duration > 0 {
+				response += fmt.Sprintf(", voting closes in %s", duration)
+				pa.closeAfter(b, messenger, poll.ID, duration)
+			}
+		}
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+	return err
+}
+
+// closeAfter closes the poll with the given ID once duration is up, if it is still running
+func (pa *PollAction) closeAfter(b *bot.Bot, messenger bot.Messenger, id int64, duration time.Duration) {
+	time.AfterFunc(duration, func() {
+		pa.Lock.Lock()
+		defer pa.Lock.Unlock()
+		if b.Poll == nil || b.Poll.ID != id {
+			return
+		}
+		poll, err := b.EndPoll(bot.PollClosed)
+		if err != nil {
+			fmt.Printf("could not close the poll: %v\n", err)
+			return
+		}
+		messenger.Message(fmt.Sprintf("the poll '%s' has closed! %s", poll.Question, poll.Results()))
+	})
+}
+
+// parsePoll reads the question, options and optional duration from the arguments of a !poll command. A duration is
+// the last word of the last option, if it can be read as one.
+func parsePoll(args string) (question string, options []string, duration time.Duration, err error) {
+	match := questionRegex.FindStringSubmatch(strings.TrimSpace(args))
+	if match == nil {
+		return "", nil, 0, fmt.Errorf(`usage: !poll "question" option one | option two [duration]`)
+	}
+
+	for _, option := range strings.Split(match[2], "|") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	if len(options) > 0 {
+		last := strings.Fields(options[len(options)-1])
+		if len(last) > 1 {
+			if parsed, parseErr := bot.ParseDuration(last[len(last)-1]); parseErr == nil && parsed > 0 {
+				duration = parsed
+				options[len(options)-1] = strings.Join(last[:len(last)-1], " ")
+			}
+		}
+	}
+	return match[1], options, duration, nil
+}
+
+func (va *VoteAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!vote"
+}
+
+// Action for a VoteAction votes in the running poll with '!vote 2'. Votes are counted quietly so chat isn't flooded.
+func (va *VoteAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	option, err := strconv.Atoi(item.Command)
+	if err != nil {
+		return fmt.Errorf("usage: !vote <number>")
+	}
+	_, err = b.Vote(item.Sender, option)
+	return err
+}
+
+// pollMessage counts a chat message that is only a number as a vote in the running poll
+func (t *Twitch) pollMessage(item bot.Item) {
+	if t.Bot.Poll == nil || item.Type != "" {
+		return
+	}
+	option, err := strconv.Atoi(strings.TrimSpace(item.Contents))
+	if err != nil || option < 1 || option > len(t.Bot.Poll.Options) {
+		return
+	}
+	if _, err = t.Bot.Vote(item.Sender, option); err != nil {
+		fmt.Printf("could not count %s's vote: %v\n", item.Sender.Name, err)
+	}
+}
@@ -0,0 +1,54 @@
+package twitch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePoll(t *testing.T) {
+	tests := []struct {
+		description  string
+		input        string
+		wantQuestion string
+		wantOptions  []string
+		wantDuration time.Duration
+		wantErr      bool
+	}{
+		{
+			description:  "should read the question and options",
+			input:        `"what should we play next?" some game | another game | a third game`,
+			wantQuestion: "what should we play next?",
+			wantOptions:  []string{"some game", "another game", "a third game"},
+		},
+		{
+			description:  "should read a duration at the end",
+			input:        `"pizza?" yes | no 5m`,
+			wantQuestion: "pizza?",
+			wantOptions:  []string{"yes", "no"},
+			wantDuration: 5 * time.Minute,
+		},
+		{
+			description:  "should not take a single word option as a duration",
+			input:        `"pick a number" 1 | 2`,
+			wantQuestion: "pick a number",
+			wantOptions:  []string{"1", "2"},
+		},
+		{
+			description: "should need a quoted question",
+			input:       `pizza? yes | no`,
+			wantErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		question, options, duration, err := parsePoll(test.input)
+		if question != test.wantQuestion || !reflect.DeepEqual(options, test.wantOptions) || duration != test.wantDuration {
+			t.Errorf("%s\ndid not get the expected poll\ngot - %q %q %v\nwant - %q %q %v", test.description, question,
+				options, duration, test.wantQuestion, test.wantOptions, test.wantDuration)
+		}
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant error - %v", test.description, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// queue.go holds the chat side of the viewer queue, joining and leaving it and taking viewers from the front
+
+package twitch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// maxQueueList is how many viewers '!queue list' names, to keep the message short
+const maxQueueList = 10
+
+type JoinQueueAction struct{}
+
+type QueueAction struct{}
+
+func (ja *JoinQueueAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!join" || item.Type == "!leave" || item.Type == "!position"
+}
+
+// Action for a JoinQueueAction lets viewers '!join' and '!leave' the queue, and see where they are with '!position'
+func (ja *JoinQueueAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+	user := item.Sender.Name
+
+	switch item.Type {
+	case "!join":
+		var position int
+		var joined bool
+		if position, joined, err = b.JoinQueue(item.Sender); err != nil {
+			break
+		}
+		if joined {
+			response = fmt.Sprintf("@%s joined the queue at #%d", user, position)
+		} else {
+			response = fmt.Sprintf("@%s you're already in the queue at #%d", user, position)
+		}
+	case "!leave":
+		var left bool
+		if left, err = b.LeaveQueue(user); err == nil && left {
+			response = fmt.Sprintf("@%s left the queue", user)
+		} else if err == nil {
+			response = fmt.Sprintf("@%s you aren't in the queue", user)
+		}
+	case "!position":
+		if position := b.QueuePosition(user); position > 0 {
+			response = fmt.Sprintf("@%s you're #%d of %d in the queue", user, position, len(b.Queue.Entries))
+		} else {
+			response = fmt.Sprintf("@%s you aren't in the queue", user)
+		}
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(fmt.Sprintf("@%s %s", user, err.Error()))
+	}
+	return err
+}
+
+func (qa *QueueAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!queue" || item.Type == "!next"
+}
+
+// Action for a QueueAction runs the queue: '!next [n]' takes viewers from the front, and '!queue open', '!queue close',
+// '!queue clear' and '!queue list' do what they say
+func (qa *QueueAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var err error
+	var response string
+
+	// '!queue next 2' has the count in Contents, while '!next 2' has it as the Command
+	command, count := item.Command, strings.TrimSpace(strings.Join([]string{item.Key, item.Contents}, " "))
+	if item.Type == "!next" {
+		command, count = "next", strings.TrimSpace(strings.Join([]string{item.Command, count}, " "))
+	}
+	switch command {
+	case "open", "close":
+		if err = b.SetQueueOpen(command == "open"); err == nil && command == "open" {
+			response = "the queue is now open, type !join to join"
+		} else if err == nil {
+			response = "the queue is now closed"
+		}
+	case "clear":
+		if err = b.ClearQueue(); err == nil {
+			response = "the queue has been cleared"
+		}
+	case "next":
+		n := 1
+		if count != "" {
+			if n, err = strconv.Atoi(count); err != nil || n < 1 {
+				err = fmt.Errorf("usage: !next [number of viewers]")
+				break
+			}
+		}
+		var next []bot.QueueEntry
+		if next, err = b.NextInQueue(n); err != nil {
+			break
+		}
+		if len(next) == 0 {
+			response = "the queue is empty"
+			break
+		}
+		var names []string
+		for _, entry := range next {
+			names = append(names, "@"+entry.User)
+		}
+		response = fmt.Sprintf("you're up %s!", strings.Join(names, " "))
+	case "list", "":
+		response = queueSummary(b.Queue)
+	default:
+		err = fmt.Errorf("usage: !queue <open|close|clear|list|next>")
+	}
+
+	if err == nil {
+		messenger.Message(response)
+	} else {
+		messenger.Message(err.Error())
+	}
+	return err
+}
+
+// queueSummary describes the queue and names the first few viewers in it
+func queueSummary(queue bot.Queue) string {
+	state := "closed"
+	if queue.Open {
+		state = "open"
+	}
+	if len(queue.Entries) == 0 {
+		return fmt.Sprintf("the queue is %s and empty", state)
+	}
+
+	var names []string
+	for i, entry := range queue.Entries {
+		if i == maxQueueList {
+			names = append(names, fmt.Sprintf("and %d more", len(queue.Entries)-maxQueueList))
+			break
+		}
+		names = append(names, fmt.Sprintf("%d) %s", i+1, entry.User))
+	}
+	return fmt.Sprintf("the queue is %s with %d viewers: %s", state, len(queue.Entries), strings.Join(names, " "))
+}
@@ -0,0 +1,59 @@
+package twitch
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+func TestQueueCommands(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database}
+
+	// messages are sent as raw chat lines, so the commands are split up the same way they are in chat
+	tests := []struct {
+		description  string
+		sender       string
+		badges       string
+		message      string
+		wantMessages []string
+	}{
+		{description: "should open the queue", sender: "test-mod", badges: "moderator/1", message: "!queue open",
+			wantMessages: []string{"the queue is now open, type !join to join"}},
+		{description: "should let viewers join", sender: "one", message: "!join", wantMessages: []string{"@one joined the queue at #1"}},
+		{description: "should add to the back", sender: "two", message: "!join", wantMessages: []string{"@two joined the queue at #2"}},
+		{description: "should add to the back", sender: "three", message: "!join", wantMessages: []string{"@three joined the queue at #3"}},
+		{description: "should add to the back", sender: "four", message: "!join", wantMessages: []string{"@four joined the queue at #4"}},
+		{description: "should take several with !queue next", sender: "test-mod", badges: "moderator/1", message: "!queue next 2",
+			wantMessages: []string{"you're up @one @two!"}},
+		{description: "should take one with !next", sender: "test-mod", badges: "moderator/1", message: "!next",
+			wantMessages: []string{"you're up @three!"}},
+		{description: "should take several with !next", sender: "test-mod", badges: "moderator/1", message: "!next 5",
+			wantMessages: []string{"you're up @four!"}},
+		{description: "should show how to take viewers", sender: "test-mod", badges: "moderator/1", message: "!queue next lots",
+			wantMessages: []string{"usage: !next [number of viewers]"}},
+		{description: "should say when the queue is empty", sender: "test-mod", badges: "moderator/1", message: "!queue next 2",
+			wantMessages: []string{"the queue is empty"}},
+	}
+
+	router := (&Twitch{Bot: b}).newRouter()
+	for _, test := range tests {
+		item, err := newTwitchItem(fmt.Sprintf("@badges=%s;display-name=%s;emotes=;first-msg=0;id=1;mod=0;user-id=1 :%s!%s@%s.tmi.twitch.tv PRIVMSG #test-user :%s",
+			test.badges, test.sender, test.sender, test.sender, test.sender, test.message))
+		if err != nil {
+			t.Fatalf("%s\ncould not parse the message: %v", test.description, err)
+		}
+		messenger := &recordMessenger{}
+		router.Dispatch(item, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+	}
+}
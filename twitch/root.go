@@ -4,13 +4,19 @@ package twitch
 
 import (
 	"bufio"
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/liamphmurphy/pleasantbot/api"
 	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/plugin"
 	"github.com/liamphmurphy/pleasantbot/storage"
+	"github.com/liamphmurphy/pleasantbot/wasm"
 )
 
 var (
@@ -24,26 +30,18 @@ var (
 )
 
 type Twitch struct {
-	Bot *bot.Bot
+	Bot     *bot.Bot
+	Metrics Metrics    // how each chat command has been used
+	mu      sync.Mutex // held while a message is handled, so the API server can safely change the bot's data
+	router  *Router
+	ran     []bot.CommandEvent // commands run while the lock was held, published once it is released
+	plugins []*plugin.Context  // plugins enabled for the bot's channel
+	wasm    *wasm.Host         // runs the WebAssembly modules in the config directory, nil if they're disabled
 }
 
-// this should only run in a sqlite Init call, when the database file is not found in the config directory
-func prepareDatabase(db *sql.DB) error {
-	stmt := `
-	CREATE TABLE IF NOT EXISTS commands (id INTEGER PRIMARY KEY, commandname TEXT UNIQUE, commandresponse TEXT, perm TEXT, count INTEGER);
-	CREATE TABLE IF NOT EXISTS badwords (id INTEGER PRIMARY KEY, phrase TEXT, severity INTEGER);
-	CREATE TABLE IF NOT EXISTS quotes (id INTEGER PRIMARY KEY, quote TEXT, timestamp TEXT, submitter TEXT);
-	CREATE TABLE IF NOT EXISTS ban_history (user TEXT, reason TEXT, timestamp TEXT);
-	CREATE TABLE IF NOT EXISTS chatters (username TEXT PRIMARY KEY, count INT);
-	CREATE TABLE IF NOT EXISTS timers (timername TEXT UNIQUE, message TEXT, minutes INTEGER, enabled INTEGER);
-	`
-	_, err := db.Exec(stmt)
-
-	return err
-}
-
-// Run defines the main entry point for a Twitch bot
-func (t *Twitch) Run() error {
+// Setup loads the config and database and creates the bot, without connecting to Twitch. This is all that is needed
+// for commands that only work with the bot's data.
+func (t *Twitch) Setup() error {
 	configDir, err := bot.GetConfigDirectory()
 	if err != nil {
 		return err
@@ -57,11 +55,36 @@ func (t *Twitch) Run() error {
 	database := bot.Database{Path: fmt.Sprintf("%s/%s", configDir, databaseFileName), CommandColumns: commandCols,
 		QuoteColumns: quoteCols, TimerColumns: timerCols}
 
-	t.Bot, err = bot.CreateBot(v, database, storage.Init, bot.LoadBot, prepareDatabase)
+	t.Bot, err = bot.CreateBot(v, database, storage.Init, bot.LoadBot, bot.PrepareDatabase)
+	if err != nil {
+		return bot.FatalError{Err: err}
+	}
+
+	return nil
+}
+
+// Run defines the main entry point for a Twitch bot
+func (t *Twitch) Run() error {
+	err := t.Setup()
+	if err != nil {
+		return err
+	}
+
+	t.plugins, err = plugin.Load(t.Bot)
 	if err != nil {
 		return bot.FatalError{Err: err}
 	}
 
+	if t.Bot.WasmEnabled {
+		done := make(chan struct{})
+		defer close(done)
+		err = t.loadModules(done)
+		if err != nil {
+			return bot.FatalError{Err: err}
+		}
+		defer t.wasm.Close()
+	}
+
 	// Prepare the bot's net.Conn struct
 	err = t.Bot.Connect()
 	if err != nil {
@@ -79,7 +102,27 @@ func (t *Twitch) Run() error {
 	reader := bufio.NewReader(t.Bot.Conn)
 	proto := textproto.NewReader(reader)
 
-	fmt.Printf("Connected to Twitch!\nBot: %s\nChannel: %s\n", t.Bot.Name, t.Bot.ChannelName)
+	// the bot's features follow what happens in the channel through the event bus
+	for _, sub := range t.subscribe() {
+		defer sub.Close()
+	}
+
+	t.Bot.Publish(bot.EventConnection, bot.ConnectionEvent{State: bot.StateConnected, Channel: t.Bot.ChannelName})
+	t.Bot.RunTimers(t)
+	t.runJobs()
+	if t.Bot.PointsEnabled {
+		go t.awardWatchTime()
+	}
+	if t.Bot.ChatLogEnabled && t.Bot.ChatLogKeep > 0 {
+		go t.pruneChatLog()
+	}
+	if t.Bot.StatusFile != "" && t.Bot.StatusInterval > 0 {
+		go t.followStatusFile()
+	}
+
+	if t.Bot.EnableServer {
+		go t.serve()
+	}
 
 	var item bot.Item
 	var line string
@@ -98,16 +141,107 @@ func (t *Twitch) Run() error {
 			continue
 		}
 
+		if alert, ok := newAlert(line); ok {
+			t.Bot.Publish(bot.EventAlert, alert)
+			continue
+		}
+		if entry, ok := newModEvent(line); ok {
+			t.logChatModeration(entry)
+			continue
+		}
+		if eventType, user, ok := newMembership(line); ok {
+			t.Bot.Publish(eventType, user)
+			continue
+		}
+
 		item, err = newTwitchItem(line)
 		if err != nil {
 			t.Message(fmt.Sprintf("@%s - %s", item.Sender.Name, err.Error()))
 			continue
 		}
-		err = t.Handler(item, setupDefaultActions())
-		if err != nil {
-			continue
+		if !item.IsServerInfo {
+			t.Bot.Publish(bot.EventMessage, item)
 		}
 	}
 
+	t.Bot.Publish(bot.EventConnection, bot.ConnectionEvent{State: bot.StateDisconnected, Channel: t.Bot.ChannelName, Error: err.Error()})
 	return err
 }
+
+// handle moderates a single item and greets its sender. An item that passes moderation is published as an EventChat
+// once the lock is released, for the features that act on chat.
+func (t *Twitch) handle(item bot.Item) {
+	t.mu.Lock()
+	visit, err := t.Bot.TrackChatter(item)
+	if err != nil {
+		fmt.Printf("could not update %s's profile: %v\n", item.Sender.Name, err)
+	}
+	moderated := t.moderate(item)
+	if greeting := t.Bot.Greeting(visit); greeting != "" && !moderated {
+		t.Message(greeting)
+	}
+	t.mu.Unlock()
+
+	if !moderated {
+		t.Bot.Publish(bot.EventChat, item)
+	}
+}
+
+// serve runs the API server, it is only stopped by the bot exiting
+func (t *Twitch) serve() {
+	fmt.Printf("Serving the API on %s\n", t.Bot.ServerAddress)
+	server := api.NewServer(t.Bot, &t.mu)
+	server.Moderator = t
+	for _, p := range t.plugins {
+		server.AddPluginRoutes(p.Name, p.Routes)
+	}
+	if configDir, err := bot.GetConfigDirectory(); err == nil {
+		server.OverlayDir = filepath.Join(configDir, "overlays")
+	}
+	err := server.Run(t.Bot.ServerAddress)
+	if err != nil {
+		fmt.Printf("the API server stopped: %v\n", err)
+	}
+}
+
+// runJobs runs each plugin's scheduled jobs, it is only stopped by the bot exiting
+func (t *Twitch) runJobs() {
+	for _, p := range t.plugins {
+		for _, job := range p.Jobs {
+			go func(name string, job plugin.Job) {
+				for range time.NewTicker(job.Interval).C {
+					t.mu.Lock()
+					err := job.Run(t.Bot, t)
+					t.mu.Unlock()
+					if err != nil {
+						fmt.Printf("the %s plugin's %s job failed: %v\n", name, job.Name, err)
+					}
+				}
+			}(p.Name, job)
+		}
+	}
+}
+
+// loadModules loads the WebAssembly modules in the config directory's wasm folder and reloads them as they change,
+// until done is closed
+func (t *Twitch) loadModules(done <-chan struct{}) error {
+	configDir, err := bot.GetConfigDirectory()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(configDir, "wasm")
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	t.wasm, err = wasm.NewHost(t.Bot, dir, wasm.Limits{MemoryPages: t.Bot.WasmMemoryPages, Timeout: t.Bot.WasmTimeout,
+		Messages: t.Bot.WasmMessages})
+	if err != nil {
+		return err
+	}
+	if err = t.wasm.Load(); err != nil {
+		return err
+	}
+	fmt.Printf("Loaded WebAssembly modules: %v\n", t.wasm.Modules())
+	return t.wasm.Watch(done)
+}
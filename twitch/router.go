@@ -0,0 +1,87 @@
+// router.go sends chat commands to the actions that handle them. Routes are keyed by command type such as "!quote",
+// and every action runs through the router's middleware chain.
+
+package twitch
+
+import (
+	"errors"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// ErrContinue is returned by an action to let the next matching route handle the item as well
+var ErrContinue = errors.New("continue to the next route")
+
+// Route connects command types to the action that handles them
+type Route struct {
+	Types    []string // command types handled, e.g. "!quote". A route without types is tried for every command.
+	Action   ActionTaker
+	Perm     uint8         // lowest permission level that can use the route, e.g. bot.PermModerator
+	Cooldown time.Duration // how long a user waits between uses, moderators never wait
+}
+
+// Context is what the middleware and action get for a single route
+type Context struct {
+	Item      bot.Item
+	Route     *Route
+	Bot       *bot.Bot
+	Messenger bot.Messenger
+}
+
+// HandlerFunc handles an item for a route
+type HandlerFunc func(ctx *Context) error
+
+// Middleware wraps a HandlerFunc, it can run code before or after it or stop it from running
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Router sends a command to the routes for its type in the order they were added, followed by the routes without
+// types. An action's Condition must also match for its route to be used.
+type Router struct {
+	routes    map[string][]*Route
+	fallbacks []*Route
+	handler   HandlerFunc // runs the route's action wrapped in every middleware
+}
+
+// NewRouter creates a Router whose routes run through middleware, the first middleware given is the outermost
+func NewRouter(middleware ...Middleware) *Router {
+	router := &Router{routes: make(map[string][]*Route)}
+	router.handler = func(ctx *Context) error {
+		return ctx.Route.Action.Action(ctx.Item, ctx.Bot, ctx.Messenger)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		router.handler = middleware[i](router.handler)
+	}
+	return router
+}
+
+// Add adds a route to the router
+func (r *Router) Add(route Route) {
+	if len(route.Types) == 0 {
+		r.fallbacks = append(r.fallbacks, &route)
+		return
+	}
+	for _, commandType := range route.Types {
+		r.routes[commandType] = append(r.routes[commandType], &route)
+	}
+}
+
+// Dispatch runs item through the routes that match it until one returns anything other than ErrContinue, and returns
+// that error. Items that are not commands are ignored.
+func (r *Router) Dispatch(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	if item.Type == "" {
+		return nil
+	}
+
+	for _, route := range append(r.routes[item.Type], r.fallbacks...) {
+		if !route.Action.Condition(item, b) {
+			continue
+		}
+
+		err := r.handler(&Context{Item: item, Route: route, Bot: b, Messenger: messenger})
+		if !errors.Is(err, ErrContinue) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package twitch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+// recordMessenger stores every message sent through it
+type recordMessenger struct {
+	messages []string
+}
+
+func (rm *recordMessenger) Message(msg string) error {
+	rm.messages = append(rm.messages, msg)
+	return nil
+}
+
+// testAction records the order actions run in and returns a set error
+type testAction struct {
+	name string
+	err  error
+	ran  *[]string
+}
+
+func (ta *testAction) Condition(item bot.Item, b *bot.Bot) bool { return true }
+
+func (ta *testAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	*ta.ran = append(*ta.ran, ta.name)
+	return ta.err
+}
+
+func TestRouterDispatch(t *testing.T) {
+	tests := []struct {
+		description string
+		inputItem   bot.Item
+		routes      func(ran *[]string) []Route
+		wantRan     []string
+		wantErr     error
+	}{
+		{
+			description: "should run only the first matching route",
+			inputItem:   bot.Item{Type: "!test"},
+			routes: func(ran *[]string) []Route {
+				return []Route{
+					{Types: []string{"!test"}, Action: &testAction{name: "first", ran: ran}},
+					{Types: []string{"!test"}, Action: &testAction{name: "second", ran: ran}},
+				}
+			},
+			wantRan: []string{"first"},
+		},
+		{
+			description: "should run the next route and then the fallback when actions return ErrContinue",
+			inputItem:   bot.Item{Type: "!test"},
+			routes: func(ran *[]string) []Route {
+				return []Route{
+					{Action: &testAction{name: "fallback", ran: ran}},
+					{Types: []string{"!test"}, Action: &testAction{name: "first", err: ErrContinue, ran: ran}},
+					{Types: []string{"!test"}, Action: &testAction{name: "second", err: ErrContinue, ran: ran}},
+				}
+			},
+			wantRan: []string{"first", "second", "fallback"},
+		},
+		{
+			description: "should only run routes for the item's type",
+			inputItem:   bot.Item{Type: "!other"},
+			routes: func(ran *[]string) []Route {
+				return []Route{{Types: []string{"!test"}, Action: &testAction{name: "test", ran: ran}}}
+			},
+		},
+		{
+			description: "should ignore items that are not commands",
+			inputItem:   bot.Item{Contents: "hello"},
+			routes: func(ran *[]string) []Route {
+				return []Route{{Action: &testAction{name: "fallback", ran: ran}}}
+			},
+		},
+		{
+			description: "should stop users without permission",
+			inputItem:   bot.Item{Type: "!test", Sender: bot.User{Name: "viewer", Perm: bot.PermSubscriber}},
+			routes: func(ran *[]string) []Route {
+				return []Route{{Types: []string{"!test"}, Action: &testAction{name: "test", ran: ran}, Perm: bot.PermModerator}}
+			},
+			wantErr: errNotPermitted,
+		},
+		{
+			description: "should turn a panic into an error",
+			inputItem:   bot.Item{Type: "!test"},
+			routes: func(ran *[]string) []Route {
+				return []Route{{Types: []string{"!test"}, Action: &panicAction{}}}
+			},
+			wantErr: errors.New("the !test command failed"),
+		},
+	}
+
+	for _, test := range tests {
+		var ran []string
+		router := NewRouter(Recover(), RequirePerm())
+		for _, route := range test.routes(&ran) {
+			router.Add(route)
+		}
+
+		err := router.Dispatch(test.inputItem, &bot.Bot{}, &recordMessenger{})
+		if !reflect.DeepEqual(ran, test.wantRan) {
+			t.Errorf("%s\ndid not run the expected actions\ngot - %v\nwant - %v", test.description, ran, test.wantRan)
+		}
+		if (err == nil) != (test.wantErr == nil) || (err != nil && err.Error() != test.wantErr.Error()) {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant - %v", test.description, err, test.wantErr)
+		}
+	}
+}
+
+type panicAction struct{}
+
+func (pa *panicAction) Condition(item bot.Item, b *bot.Bot) bool { return true }
+
+func (pa *panicAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	panic("something went wrong")
+}
+
+func TestCooldown(t *testing.T) {
+	var ran []string
+	router := NewRouter(Cooldown())
+	router.Add(Route{Types: []string{"!test"}, Action: &testAction{name: "test", ran: &ran}, Cooldown: time.Hour})
+
+	viewer := bot.Item{Type: "!test", Sender: bot.User{Name: "viewer"}}
+	moderator := bot.Item{Type: "!test", Sender: bot.User{Name: "mod", Perm: bot.PermModerator}}
+	for _, item := range []bot.Item{viewer, viewer, moderator, moderator} {
+		router.Dispatch(item, &bot.Bot{}, &recordMessenger{})
+	}
+
+	// the viewer's second use is on cooldown, moderators are never on cooldown
+	if want := []string{"test", "test", "test"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("did not run the expected actions\ngot - %v\nwant - %v", ran, want)
+	}
+}
+
+func TestCustomCommands(t *testing.T) {
+	b := &bot.Bot{Commands: map[string]*bot.CommandValue{
+		"!hello":  {Response: "hi there", Perm: "all"},
+		"!secret": {Response: "mods only", Perm: "moderator"},
+	}}
+	tests := []struct {
+		description  string
+		inputItem    bot.Item
+		wantMessages []string
+		wantUses     int
+	}{
+		{
+			description:  "should send only the command's response",
+			inputItem:    bot.Item{Type: "!hello", Sender: bot.User{Name: "viewer"}},
+			wantMessages: []string{"hi there"},
+			wantUses:     1,
+		},
+		{
+			description: "should not respond to users without the command's permission",
+			inputItem:   bot.Item{Type: "!secret", Sender: bot.User{Name: "viewer"}},
+			wantUses:    1,
+		},
+		{
+			description:  "should respond to users with the command's permission",
+			inputItem:    bot.Item{Type: "!secret", Sender: bot.User{Name: "mod", Perm: bot.PermModerator}},
+			wantMessages: []string{"mods only"},
+			wantUses:     2,
+		},
+		{
+			description: "should ignore commands that don't exist",
+			inputItem:   bot.Item{Type: "!missing", Sender: bot.User{Name: "viewer"}},
+		},
+	}
+
+	tw := &Twitch{Bot: b}
+	metrics := &tw.Metrics
+	router := tw.newRouter()
+	for _, test := range tests {
+		messenger := &recordMessenger{}
+		router.Dispatch(test.inputItem, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+		if got := metrics.Snapshot()[test.inputItem.Type].Uses; got != test.wantUses {
+			t.Errorf("%s\ndid not get the expected uses\ngot - %v\nwant - %v", test.description, got, test.wantUses)
+		}
+	}
+}
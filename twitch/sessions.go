@@ -0,0 +1,124 @@
+// sessions.go holds the chat side of stream sessions, started and ended with !live and !offline or by following a
+// status file, and tallies chat activity towards the live session
+
+package twitch
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+type SessionAction struct{}
+
+func (sa *SessionAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!live" || item.Type == "!offline"
+}
+
+// Action for a SessionAction starts a stream session with '!live' and ends it with '!offline'
+func (sa *SessionAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	var response string
+	var err error
+	if item.Type == "!live" {
+		response, err = startSession(b, item.Sender.Name)
+	} else {
+		response, err = endSession(b)
+	}
+
+	if err != nil {
+		messenger.Message(fmt.Sprintf("@%s %s", item.Sender.Name, err.Error()))
+		return err
+	}
+	messenger.Message(response)
+	return nil
+}
+
+// startSession starts a session and returns the message announcing it
+func startSession(b *bot.Bot, startedBy string) (string, error) {
+	session, err := b.StartSession(startedBy)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("the stream is live! started session #%d", session.ID), nil
+}
+
+// endSession ends the live session and returns the message summing it up
+func endSession(b *bot.Bot) (string, error) {
+	session, err := b.EndSession()
+	if err != nil {
+		return "", err
+	}
+	summary := session.Summary
+	response := fmt.Sprintf("the stream is over after %d minutes: %d messages from %d chatters, %d of them new",
+		summary.Minutes, summary.Messages, summary.UniqueChatters, len(summary.NewChatters))
+	if summary.QuotesAdded > 0 {
+		response += fmt.Sprintf(", %d quotes added", summary.QuotesAdded)
+	}
+	return response, nil
+}
+
+// tallySession counts chat messages and commands towards the live session
+func (t *Twitch) tallySession(event bot.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Bot.TallySession(event)
+}
+
+// readStatus reads whether the stream is live from a status file. ok is false if the file can't be read or holds
+// anything other than "live" or "offline", in which case the session is left as it is.
+func readStatus(path string) (live bool, ok bool) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return false, false
+	}
+	switch strings.ToLower(strings.TrimSpace(string(contents))) {
+	case "live", "online":
+		return true, true
+	case "offline":
+		return false, true
+	}
+	return false, false
+}
+
+// followStatusFile starts and ends sessions as the status file changes between live and offline, it is only stopped
+// by the bot exiting. Only changes to the file count, so a session started or ended by hand isn't undone until the
+// file changes again.
+func (t *Twitch) followStatusFile() {
+	last, known := readStatus(t.Bot.StatusFile)
+	t.mu.Lock()
+	if known && last != (t.Bot.Session != nil) {
+		t.changeSession(last)
+	}
+	t.mu.Unlock()
+
+	for range time.NewTicker(t.Bot.StatusInterval).C {
+		live, ok := readStatus(t.Bot.StatusFile)
+		if !ok || (known && live == last) {
+			continue
+		}
+		last, known = live, true
+		t.mu.Lock()
+		t.changeSession(live)
+		t.mu.Unlock()
+	}
+}
+
+// changeSession starts or ends the session for the status file and announces it in chat
+func (t *Twitch) changeSession(live bool) {
+	var response string
+	var err error
+	if live && t.Bot.Session == nil {
+		response, err = startSession(t.Bot, "status file")
+	} else if !live && t.Bot.Session != nil {
+		response, err = endSession(t.Bot)
+	}
+
+	if err != nil {
+		fmt.Printf("could not follow the stream status: %v\n", err)
+	} else if response != "" {
+		t.Message(response)
+	}
+}
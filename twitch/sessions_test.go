@@ -0,0 +1,36 @@
+package twitch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadStatus(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		description string
+		contents    string
+		wantLive    bool
+		wantOk      bool
+	}{
+		{description: "should read a live stream", contents: "live\n", wantLive: true, wantOk: true},
+		{description: "should ignore case", contents: "  Online ", wantLive: true, wantOk: true},
+		{description: "should read an offline stream", contents: "offline", wantLive: false, wantOk: true},
+		{description: "should not guess from anything else", contents: "starting soon", wantLive: false, wantOk: false},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, "status")
+		if err := os.WriteFile(path, []byte(test.contents), 0644); err != nil {
+			t.Fatalf("could not write the status file: %v", err)
+		}
+		if live, ok := readStatus(path); live != test.wantLive || ok != test.wantOk {
+			t.Errorf("%s\ndid not get the expected status\ngot - %v %v\nwant - %v %v", test.description, live, ok,
+				test.wantLive, test.wantOk)
+		}
+	}
+
+	if _, ok := readStatus(filepath.Join(dir, "missing")); ok {
+		t.Errorf("read a status from a file that doesn't exist")
+	}
+}
@@ -0,0 +1,127 @@
+// stats.go holds the chat side of the channel's stats, recording each message and showing the stats and leaderboards
+
+package twitch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+const (
+	statsWindow     = 30 * 24 * time.Hour // how far back !stats and !top look
+	defaultTopStats = 5
+)
+
+type StatsAction struct{}
+
+type TopStatsAction struct{}
+
+// recordStats counts a chat message towards the channel's stats. It doesn't need the lock, since it only writes to
+// the database.
+func (t *Twitch) recordStats(event bot.Event) {
+	if item, ok := event.Data.(bot.Item); ok {
+		if err := t.Bot.RecordStats(item, event.Timestamp); err != nil {
+			fmt.Printf("could not record the chat stats: %v\n", err)
+		}
+	}
+}
+
+func (sa *StatsAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!stats"
+}
+
+// Action for a StatsAction sums up the last 30 days of chat with '!stats', or a single user's messages with
+// '!stats @user'
+func (sa *StatsAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	since := time.Now().Add(-statsWindow)
+	if username := targetUser(item.Command); username != "" {
+		days, err := b.UserStats(username, since)
+		if err != nil {
+			return err
+		}
+		var messages int64
+		for _, day := range days {
+			messages += day.Messages
+		}
+		return messenger.Message(fmt.Sprintf("%s has sent %d messages on %d days in the last 30 days", username, messages,
+			len(days)))
+	}
+
+	stats, err := b.Stats(since)
+	if err != nil {
+		return err
+	}
+	if stats.Messages == 0 {
+		return messenger.Message("nobody has chatted in the last 30 days")
+	}
+	busiest := 0
+	for hour, messages := range stats.Hours {
+		if messages > stats.Hours[busiest] {
+			busiest = hour
+		}
+	}
+	response := fmt.Sprintf("last 30 days: %d messages from %d chatters, busiest at %02d:00", stats.Messages,
+		stats.Chatters, busiest)
+	if len(stats.TopEmotes) > 0 {
+		response += fmt.Sprintf(", top emote %s (%d)", stats.TopEmotes[0].Emote, stats.TopEmotes[0].Uses)
+	}
+	if len(stats.TopCommands) > 0 {
+		response += fmt.Sprintf(", top command %s (%d)", stats.TopCommands[0].Command, stats.TopCommands[0].Uses)
+	}
+	return messenger.Message(response)
+}
+
+func (ta *TopStatsAction) Condition(item bot.Item, b *bot.Bot) bool {
+	return item.Type == "!top" && (item.Command == "chatters" || item.Command == "emotes" || item.Command == "commands")
+}
+
+// Action for a TopStatsAction shows a leaderboard for the last 30 days, '!top chatters', '!top emotes' or
+// '!top commands', followed by how many to show
+func (ta *TopStatsAction) Action(item bot.Item, b *bot.Bot, messenger bot.Messenger) error {
+	n := defaultTopStats
+	if count := strings.TrimSpace(item.Key + item.Contents); count != "" {
+		var err error
+		if n, err = strconv.Atoi(count); err != nil || n <= 0 {
+			err = fmt.Errorf("usage: !top %s [amount]", item.Command)
+			messenger.Message(err.Error())
+			return err
+		}
+	}
+	if n > maxTopBalances {
+		n = maxTopBalances
+	}
+
+	var ranks []string
+	since := time.Now().Add(-statsWindow)
+	switch item.Command {
+	case "chatters":
+		chatters, err := b.TopChatters(since, n)
+		if err != nil {
+			return err
+		}
+		for i, chatter := range chatters {
+			ranks = append(ranks, fmt.Sprintf("%d. %s (%d)", i+1, chatter.User, chatter.Messages))
+		}
+	case "emotes":
+		emotes, err := b.TopEmotes(since, n)
+		if err != nil {
+			return err
+		}
+		for i, emote := range emotes {
+			ranks = append(ranks, fmt.Sprintf("%d. %s (%d)", i+1, emote.Emote, emote.Uses))
+		}
+	case "commands":
+		for i, command := range b.TopCommands(n) {
+			ranks = append(ranks, fmt.Sprintf("%d. %s (%d)", i+1, command.Command, command.Uses))
+		}
+	}
+
+	if len(ranks) == 0 {
+		return messenger.Message(fmt.Sprintf("there are no %s to rank yet", item.Command))
+	}
+	return messenger.Message(fmt.Sprintf("top %s: %s", item.Command, strings.Join(ranks, " ")))
+}
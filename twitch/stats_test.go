@@ -0,0 +1,91 @@
+package twitch
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+func TestStatsCommands(t *testing.T) {
+	var database bot.Database
+	if err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase); err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+	b := &bot.Bot{Storage: &database, Commands: map[string]*bot.CommandValue{
+		"!discord": {Response: "join the discord", Perm: "all", Count: 3},
+		"!hug":     {Response: "hugs all around", Perm: "all"},
+	}}
+
+	now := time.Now()
+	messages := []bot.Item{
+		{Sender: bot.User{Name: "regular"}, Contents: "Kappa Kappa", Emotes: []string{"Kappa", "Kappa"}},
+		{Sender: bot.User{Name: "regular"}, Contents: "hello"},
+		{Sender: bot.User{Name: "viewer"}, Contents: "PogChamp", Emotes: []string{"PogChamp"}},
+	}
+	for _, item := range messages {
+		if err := b.RecordStats(item, now); err != nil {
+			t.Fatalf("could not record the stats: %v", err)
+		}
+	}
+
+	viewer := bot.User{Name: "viewer"}
+	tests := []struct {
+		description  string
+		inputItem    bot.Item
+		wantMessages []string
+	}{
+		{
+			description:  "should sum up the channel's chat",
+			inputItem:    bot.Item{Type: "!stats", Sender: viewer},
+			wantMessages: []string{fmt.Sprintf("last 30 days: 3 messages from 2 chatters, busiest at %02d:00, top emote Kappa (2), top command !discord (3)", now.Hour())},
+		},
+		{
+			description:  "should sum up a single user's chat",
+			inputItem:    bot.Item{Type: "!stats", Command: "@Regular", Sender: viewer},
+			wantMessages: []string{"regular has sent 2 messages on 1 days in the last 30 days"},
+		},
+		{
+			description:  "should rank the chatters",
+			inputItem:    bot.Item{Type: "!top", Command: "chatters", Sender: viewer},
+			wantMessages: []string{"top chatters: 1. regular (2) 2. viewer (1)"},
+		},
+		{
+			description:  "should rank the emotes, as many as asked for",
+			inputItem:    bot.Item{Type: "!top", Command: "emotes", Contents: "1", Sender: viewer},
+			wantMessages: []string{"top emotes: 1. Kappa (2)"},
+		},
+		{
+			description:  "should rank only the commands that have been used",
+			inputItem:    bot.Item{Type: "!top", Command: "commands", Sender: viewer},
+			wantMessages: []string{"top commands: 1. !discord (3)"},
+		},
+		{
+			description:  "should count a custom command when it is used",
+			inputItem:    bot.Item{Type: "!hug", Sender: viewer},
+			wantMessages: []string{"hugs all around"},
+		},
+		{
+			description:  "should show how to use it",
+			inputItem:    bot.Item{Type: "!top", Command: "chatters", Contents: "lots", Sender: viewer},
+			wantMessages: []string{"usage: !top chatters [amount]"},
+		},
+	}
+
+	router := (&Twitch{Bot: b}).newRouter()
+	for _, test := range tests {
+		messenger := &recordMessenger{}
+		router.Dispatch(test.inputItem, b, messenger)
+		if !reflect.DeepEqual(messenger.messages, test.wantMessages) {
+			t.Errorf("%s\ndid not send the expected messages\ngot - %v\nwant - %v", test.description, messenger.messages, test.wantMessages)
+		}
+	}
+
+	if uses := b.TopCommands(0); !reflect.DeepEqual(uses, []bot.CommandUses{{Command: "!discord", Uses: 3}, {Command: "!hug", Uses: 1}}) {
+		t.Errorf("did not count the command's use, got - %+v", uses)
+	}
+}
@@ -0,0 +1,161 @@
+// subscribers.go holds the features that follow the bot's event bus rather than being called from the read loop
+
+package twitch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/wasm"
+)
+
+// chatBuffer is how many chat messages can wait to be handled before the read loop waits for them
+const chatBuffer = 256
+
+// subscribe sets up the features that follow the bot's events. The returned subscriptions must be closed when the bot
+// stops.
+func (t *Twitch) subscribe() []*bot.Subscription {
+	subs := []*bot.Subscription{
+		// every chat message has to be moderated, so the read loop waits rather than dropping one
+		t.Bot.Events.Handle("chat", bot.SubscribeOptions{Types: []bot.EventType{bot.EventMessage}, Buffer: chatBuffer,
+			Backpressure: bot.Block}, t.handleMessage),
+		t.Bot.Events.Handle("console", bot.SubscribeOptions{Types: []bot.EventType{bot.EventConnection, bot.EventModeration},
+			Backpressure: bot.DropOldest}, t.logEvent),
+		t.Bot.Events.Handle("viewers", bot.SubscribeOptions{Types: []bot.EventType{bot.EventJoin, bot.EventPart},
+			Buffer: chatBuffer, Backpressure: bot.Block}, t.trackViewer),
+		t.Bot.Events.Handle("commands", bot.SubscribeOptions{Types: []bot.EventType{bot.EventChat}, Buffer: chatBuffer,
+			Backpressure: bot.Block}, t.runCommands),
+		t.Bot.Events.Handle("points", bot.SubscribeOptions{Types: []bot.EventType{bot.EventChat}, Buffer: chatBuffer,
+			Backpressure: bot.Block}, t.chatFeature(t.earnPoints)),
+		t.Bot.Events.Handle("giveaways", bot.SubscribeOptions{Types: []bot.EventType{bot.EventChat}, Buffer: chatBuffer,
+			Backpressure: bot.Block}, t.chatFeature(t.giveawayMessage)),
+		t.Bot.Events.Handle("polls", bot.SubscribeOptions{Types: []bot.EventType{bot.EventChat}, Buffer: chatBuffer,
+			Backpressure: bot.Block}, t.chatFeature(t.pollMessage)),
+		// commands are published once the lock is released, so the tally can wait for the lock without missing any
+		t.Bot.Events.Handle("session", bot.SubscribeOptions{Types: []bot.EventType{bot.EventMessage, bot.EventCommand},
+			Buffer: chatBuffer, Backpressure: bot.Block}, t.tallySession),
+	}
+	if t.Bot.Storage != nil {
+		subs = append(subs, t.Bot.Events.Handle("stats", bot.SubscribeOptions{Types: []bot.EventType{bot.EventMessage},
+			Buffer: chatBuffer, Backpressure: bot.Block}, t.recordStats))
+	}
+	if t.Bot.ChatLogEnabled {
+		subs = append(subs, t.Bot.Events.Handle("chatlog", bot.SubscribeOptions{Types: []bot.EventType{bot.EventMessage,
+			bot.EventModeration}, Buffer: chatLogBuffer, Backpressure: bot.Block}, t.logChat))
+	}
+	if t.wasm != nil {
+		// modules can be slow, so they miss messages rather than holding up chat
+		subs = append(subs, t.Bot.Events.Handle("wasm", bot.SubscribeOptions{Types: []bot.EventType{bot.EventMessage},
+			Buffer: chatBuffer, Backpressure: bot.DropOldest}, t.runModules))
+	}
+	return subs
+}
+
+// handleMessage moderates a chat message, passing it on to the other features if it's allowed
+func (t *Twitch) handleMessage(event bot.Event) {
+	if item, ok := event.Data.(bot.Item); ok {
+		t.handle(item)
+	}
+}
+
+// runCommands runs any command in a chat message. The commands that were run are published once the lock is released,
+// so their subscribers can take the lock themselves.
+func (t *Twitch) runCommands(event bot.Event) {
+	item, ok := event.Data.(bot.Item)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	if t.router == nil {
+		t.router = t.newRouter()
+	}
+	t.router.Dispatch(item, t.Bot, t)
+	ran := t.ran
+	t.ran = nil
+	t.mu.Unlock()
+
+	for _, command := range ran {
+		t.Bot.Publish(bot.EventCommand, command)
+	}
+}
+
+// queueCommand holds on to a command that was run until runCommands publishes it, it's called with the lock held
+func (t *Twitch) queueCommand(command bot.CommandEvent) {
+	t.ran = append(t.ran, command)
+}
+
+// chatFeature returns a handler that calls feature with each chat message, holding the lock while it runs
+func (t *Twitch) chatFeature(feature func(bot.Item)) func(bot.Event) {
+	return func(event bot.Event) {
+		if item, ok := event.Data.(bot.Item); ok {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			feature(item)
+		}
+	}
+}
+
+// logEvent prints connection changes and moderation actions to the console
+func (t *Twitch) logEvent(event bot.Event) {
+	switch data := event.Data.(type) {
+	case bot.ConnectionEvent:
+		if data.State == bot.StateConnected {
+			fmt.Printf("Connected to Twitch!\nBot: %s\nChannel: %s\n", t.Bot.Name, data.Channel)
+		} else {
+			fmt.Printf("Disconnected from Twitch: %s\n", data.Error)
+		}
+	case bot.ModLogEntry:
+		action := string(data.Action)
+		if data.Duration > 0 {
+			action = fmt.Sprintf("%s (%s)", action, data.Duration)
+		}
+		fmt.Printf("[%s] %s: %s %s %s\n", data.Timestamp.Format("15:04:05"), data.Actor, action, data.Target, data.Reason)
+	}
+}
+
+// runModules runs the WebAssembly modules for a chat message and carries out the actions they ask for. The modules run
+// without the lock, which is only held while their actions are carried out. Moderators can't be moderated by a module.
+func (t *Twitch) runModules(event bot.Event) {
+	item, ok := event.Data.(bot.Item)
+	if !ok {
+		return
+	}
+
+	actions := t.wasm.OnMessage(item)
+	if len(actions) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, action := range actions {
+		var err error
+		entry := bot.ModLogEntry{Actor: t.Bot.Name, Target: item.Sender.Name, TargetID: item.Sender.ID, Reason: action.Reason,
+			Rule: fmt.Sprintf("wasm:%s", action.Module), Excerpt: item.Contents}
+		switch action.Kind {
+		case wasm.ActionMessage:
+			err = t.Message(action.Message)
+		case wasm.ActionDelete:
+			if item.Sender.IsModerator() {
+				continue
+			}
+			entry.Action = bot.ModActionDelete
+			if err = t.DeleteMessage(item.ID); err == nil {
+				err = t.Bot.LogModAction(entry)
+			}
+		case wasm.ActionTimeout:
+			if item.Sender.IsModerator() || action.Seconds <= 0 {
+				continue
+			}
+			entry.Action = bot.ModActionTimeout
+			entry.Duration = time.Duration(action.Seconds) * time.Second
+			if err = t.Timeout(item.Sender.Name, action.Seconds); err == nil {
+				err = t.Bot.LogModAction(entry)
+			}
+		}
+		if err != nil {
+			fmt.Printf("could not carry out the %s module's %s action: %v\n", action.Module, action.Kind, err)
+		}
+	}
+}
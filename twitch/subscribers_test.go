@@ -0,0 +1,107 @@
+package twitch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+)
+
+func TestChatSubscriber(t *testing.T) {
+	conn := &recordConn{}
+	tw := &Twitch{Bot: &bot.Bot{ChannelName: "channel", Conn: conn, Events: bot.NewEventBus(0),
+		BadWords: []bot.BadWord{{Phrase: "cookies", Severity: bot.SeverityDelete}}}}
+	for _, sub := range tw.subscribe() {
+		defer sub.Close()
+	}
+
+	// the moderation action is published once the message has been handled
+	done := tw.Bot.Events.Subscribe("test", bot.SubscribeOptions{Types: []bot.EventType{bot.EventModeration}})
+	defer done.Close()
+
+	tw.Bot.Publish(bot.EventMessage, bot.Item{ID: "abc", Sender: bot.User{Name: "viewer"}, Contents: "cookies"})
+	select {
+	case <-done.Events():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the message to be moderated")
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if want := []string{"PRIVMSG #channel :/delete abc"}; !reflect.DeepEqual(conn.written, want) {
+		t.Errorf("did not send the expected messages\ngot - %v\nwant - %v", conn.written, want)
+	}
+}
+
+func TestCommandSubscriber(t *testing.T) {
+	conn := &recordConn{}
+	tw := &Twitch{Bot: &bot.Bot{ChannelName: "channel", Conn: conn, Events: bot.NewEventBus(0)}}
+	for _, sub := range tw.subscribe() {
+		defer sub.Close()
+	}
+
+	commands := tw.Bot.Events.Subscribe("test", bot.SubscribeOptions{Types: []bot.EventType{bot.EventCommand}})
+	defer commands.Close()
+
+	tw.Bot.Publish(bot.EventMessage, bot.Item{Type: "!permit", Command: "@viewer", Sender: bot.User{Name: "mod",
+		Perm: bot.PermModerator}})
+	select {
+	case event := <-commands.Events():
+		// the command is published once the lock is released, so taking it here must not wait on the handler
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		want := bot.CommandEvent{Type: "!permit", Command: "@viewer", User: "mod"}
+		if command, _ := event.Data.(bot.CommandEvent); command != want {
+			t.Errorf("did not get the expected command\ngot - %v\nwant - %v", command, want)
+		}
+		if _, ok := tw.Bot.PermittedUsers["viewer"]; !ok {
+			t.Errorf("did not run the command")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the command to be published")
+	}
+}
+
+func TestNewMembership(t *testing.T) {
+	tests := []struct {
+		description string
+		inputMsg    string
+		wantType    bot.EventType
+		wantUser    bot.UserEvent
+		wantOk      bool
+	}{
+		{
+			description: "should read a join",
+			inputMsg:    ":Viewer!viewer@viewer.tmi.twitch.tv JOIN #test-user",
+			wantType:    bot.EventJoin,
+			wantUser:    bot.UserEvent{User: "viewer"},
+			wantOk:      true,
+		},
+		{
+			description: "should read a part",
+			inputMsg:    ":viewer!viewer@viewer.tmi.twitch.tv PART #test-user",
+			wantType:    bot.EventPart,
+			wantUser:    bot.UserEvent{User: "viewer"},
+			wantOk:      true,
+		},
+		{
+			description: "should ignore chat messages",
+			inputMsg:    "@id=d1 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #test-user :JOIN #test-user",
+		},
+		{
+			description: "should ignore server messages",
+			inputMsg:    ":tmi.twitch.tv 372 whitegirlcoffeebot :You are in a maze of twisty passages, all alike.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			eventType, user, ok := newMembership(test.inputMsg)
+			if ok != test.wantOk || eventType != test.wantType || user != test.wantUser {
+				t.Errorf("did not get the expected membership\ngot - %v %v %v\nwant - %v %v %v", eventType, user, ok,
+					test.wantType, test.wantUser, test.wantOk)
+			}
+		})
+	}
+}
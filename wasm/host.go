@@ -0,0 +1,457 @@
+// Package wasm runs WebAssembly modules that react to chat, so extensions can be written in any language that
+// compiles to WebAssembly. Modules run in wazero, a pure Go runtime, with no access to the filesystem, network or
+// clock beyond what the host functions below give them.
+//
+// A module exports:
+//
+//	memory                        its linear memory
+//	alloc(size i32) -> i32        returns a pointer to size free bytes, used to pass data into the module
+//	on_message(ptr, len i32) -> i64
+//
+// on_message is called with a JSON encoded bot.Item for each chat message. It returns a pointer and length packed as
+// (ptr << 32 | len) to a JSON array of Actions, or 0 for none.
+//
+// A module can import these functions from the "pleasantbot" module:
+//
+//	send(ptr, len i32)                              queues a chat message
+//	store_get(key_ptr, key_len i32) -> i64          reads a stored value into memory from alloc, 0 if not found
+//	store_set(key_ptr, key_len, val_ptr, val_len i32) -> i32   stores a value, returns 0 on success
+//	store_delete(key_ptr, key_len i32)
+//	log(ptr, len i32)                               prints a line to the bot's console
+//	now() -> i64                                    the current unix time in milliseconds
+//
+// Stored values are scoped to the module, so modules can't read each other's data. WebAssembly has no instruction
+// counting in wazero, so each call is limited by time instead, along with a cap on memory and on messages sent.
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// kinds of Action
+const (
+	ActionMessage = "message" // send Message to chat
+	ActionDelete  = "delete"  // delete the message
+	ActionTimeout = "timeout" // time out the sender for Seconds
+)
+
+const (
+	defaultTimeout = 100 * time.Millisecond
+	maxValueSize   = 500 // length of a value a module can store
+	maxStoreKeys   = 100 // keys a single module can store
+	hostModule     = "pleasantbot"
+	moduleFileExt  = ".wasm"
+)
+
+var errNoStorage = errors.New("modules need a database to store values")
+
+// Action is something a module asks the bot to do about a message
+type Action struct {
+	Kind    string `json:"kind"` // one of ActionMessage, ActionDelete or ActionTimeout
+	Message string `json:"message,omitempty"`
+	Seconds int    `json:"seconds,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Module  string `json:"-"` // the module that asked for the action
+}
+
+// Limits bounds what a single call into a module can use
+type Limits struct {
+	MemoryPages uint32        // 64KiB pages of memory a module can have
+	Timeout     time.Duration // how long a call can run for, defaultTimeout if not set
+	Messages    int           // messages a call can send
+}
+
+// Host loads the modules in a directory and runs them
+type Host struct {
+	Bot     *bot.Bot
+	Dir     string
+	limits  Limits
+	runtime wazero.Runtime
+	mu      sync.RWMutex // read locked for the whole of a call, so a module isn't closed by a reload while it runs
+	modules map[string]loadedModule
+}
+
+// loadedModule is a compiled module along with a hash of its source
+type loadedModule struct {
+	compiled wazero.CompiledModule
+	hash     [sha256.Size]byte
+}
+
+// call is the state of a single call into a module, host functions find it in their context
+type call struct {
+	module  string
+	actions []Action
+	err     error // an error from a host function, such as a limit being hit
+}
+
+type callKey struct{}
+
+// NewHost creates a Host for the modules in dir, Load must be called before it runs any
+func NewHost(b *bot.Bot, dir string, limits Limits) (*Host, error) {
+	if limits.Timeout <= 0 {
+		limits.Timeout = defaultTimeout
+	}
+	ctx := context.Background()
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if limits.MemoryPages > 0 {
+		config = config.WithMemoryLimitPages(limits.MemoryPages)
+	}
+	h := &Host{Bot: b, Dir: dir, limits: limits, runtime: wazero.NewRuntimeWithConfig(ctx, config),
+		modules: make(map[string]loadedModule)}
+
+	_, err := h.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().WithFunc(h.send).Export("send").
+		NewFunctionBuilder().WithFunc(h.storeGet).Export("store_get").
+		NewFunctionBuilder().WithFunc(h.storeSet).Export("store_set").
+		NewFunctionBuilder().WithFunc(h.storeDelete).Export("store_delete").
+		NewFunctionBuilder().WithFunc(h.log).Export("log").
+		NewFunctionBuilder().WithFunc(func() int64 { return time.Now().UnixNano() / int64(time.Millisecond) }).Export("now").
+		Instantiate(ctx)
+	if err != nil {
+		h.runtime.Close(ctx)
+		return nil, err
+	}
+	return h, nil
+}
+
+// Close frees everything the host's modules use
+func (h *Host) Close() error {
+	return h.runtime.Close(context.Background())
+}
+
+// Load compiles every module in the host's directory, a directory that doesn't exist holds no modules
+func (h *Host) Load() error {
+	files, err := os.ReadDir(h.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != moduleFileExt {
+			continue
+		}
+		if err = h.LoadModule(filepath.Join(h.Dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadModule compiles the module at path, replacing any module already loaded from it. The module is named after its
+// file without the extension. A module whose source hasn't changed is left as it is, since wazero shares compiled
+// modules with the same source and closing the old one would close the new one too.
+func (h *Host) LoadModule(path string) error {
+	name := strings.TrimSuffix(filepath.Base(path), moduleFileExt)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(source)
+	h.mu.RLock()
+	old, ok := h.modules[name]
+	h.mu.RUnlock()
+	if ok && old.hash == hash {
+		return nil
+	}
+
+	compiled, err := h.runtime.CompileModule(context.Background(), source)
+	if err != nil {
+		return fmt.Errorf("could not compile the %s module: %w", name, err)
+	}
+	for _, export := range []string{"memory", "alloc", "on_message"} {
+		_, isFunction := compiled.ExportedFunctions()[export]
+		_, isMemory := compiled.ExportedMemories()[export]
+		if !isFunction && !isMemory {
+			compiled.Close(context.Background())
+			return fmt.Errorf("the %s module does not export %s", name, export)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.modules[name]; ok {
+		if old.hash == hash {
+			return nil // loaded by another call in the meantime
+		}
+		h.closeModule(name, old)
+	}
+	h.modules[name] = loadedModule{compiled: compiled, hash: hash}
+	return nil
+}
+
+// closeModule frees a module that is being replaced or unloaded, unless another loaded module shares its source and
+// so its compiled code. h.mu must be locked.
+func (h *Host) closeModule(name string, old loadedModule) {
+	for other, loaded := range h.modules {
+		if other != name && loaded.hash == old.hash {
+			return
+		}
+	}
+	old.compiled.Close(context.Background())
+}
+
+// UnloadModule stops running the module loaded from path
+func (h *Host) UnloadModule(path string) {
+	name := strings.TrimSuffix(filepath.Base(path), moduleFileExt)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.modules[name]; ok {
+		h.closeModule(name, old)
+		delete(h.modules, name)
+	}
+}
+
+// Modules returns the names of the loaded modules, sorted
+func (h *Host) Modules() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var names []string
+	for name := range h.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnMessage runs every module for item and returns the actions they ask for. A module that fails is reported and
+// skipped.
+func (h *Host) OnMessage(item bot.Item) []Action {
+	var actions []Action
+	for _, name := range h.Modules() {
+		moduleActions, err := h.Call(name, item)
+		if err != nil {
+			fmt.Printf("the %s module failed: %v\n", name, err)
+			continue
+		}
+		actions = append(actions, moduleActions...)
+	}
+	return actions
+}
+
+// Call runs a single module's on_message for item. Each call gets a fresh instance of the module, so nothing but
+// stored values carries over between messages. Loading or unloading a module waits for running calls to finish.
+func (h *Host) Call(name string, item bot.Item) ([]Action, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	loaded, ok := h.modules[name]
+	if !ok {
+		return nil, fmt.Errorf("the %s module is not loaded", name)
+	}
+
+	input, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &call{module: name}
+	ctx := context.WithValue(context.Background(), callKey{}, state)
+	ctx, cancel := context.WithTimeout(ctx, h.limits.Timeout)
+	defer cancel()
+
+	module, err := h.runtime.InstantiateModule(ctx, loaded.compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, err
+	}
+	defer module.Close(context.Background())
+
+	ptr, err := writeToModule(ctx, module, input)
+	if err == nil {
+		var results []uint64
+		results, err = module.ExportedFunction("on_message").Call(ctx, uint64(ptr), uint64(len(input)))
+		if err == nil && len(results) > 0 && results[0] != 0 {
+			err = state.readActions(module, results[0])
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("took longer than %s", h.limits.Timeout)
+	}
+	if state.err != nil {
+		return nil, state.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range state.actions {
+		state.actions[i].Module = name
+	}
+	return state.actions, nil
+}
+
+// readActions reads the JSON array of actions a module returned as a packed pointer and length
+func (c *call) readActions(module api.Module, packed uint64) error {
+	data, ok := module.Memory().Read(uint32(packed>>32), uint32(packed))
+	if !ok {
+		return fmt.Errorf("returned actions outside of its memory")
+	}
+	var actions []Action
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return fmt.Errorf("returned invalid actions: %w", err)
+	}
+	for _, action := range actions {
+		switch action.Kind {
+		case ActionMessage, ActionDelete, ActionTimeout:
+			c.actions = append(c.actions, action)
+		default:
+			return fmt.Errorf("returned an unknown action '%s'", action.Kind)
+		}
+	}
+	return nil
+}
+
+// writeToModule copies data into memory the module allocates for it
+func writeToModule(ctx context.Context, module api.Module, data []byte) (uint32, error) {
+	results, err := module.ExportedFunction("alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(results[0])
+	if !module.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("alloc returned memory outside of the module's memory")
+	}
+	return ptr, nil
+}
+
+// fail stops the module with err
+func (c *call) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+	panic(err)
+}
+
+// read reads a string from a module's memory, stopping the module if it is out of bounds
+func (c *call) read(module api.Module, ptr, length uint32) string {
+	data, ok := module.Memory().Read(ptr, length)
+	if !ok {
+		c.fail(fmt.Errorf("read outside of its memory"))
+	}
+	return string(data)
+}
+
+func callState(ctx context.Context) *call {
+	return ctx.Value(callKey{}).(*call)
+}
+
+func (h *Host) send(ctx context.Context, module api.Module, ptr, length uint32) {
+	c := callState(ctx)
+	messages := 0
+	for _, action := range c.actions {
+		if action.Kind == ActionMessage {
+			messages++
+		}
+	}
+	if h.limits.Messages > 0 && messages >= h.limits.Messages {
+		c.fail(fmt.Errorf("a module can only send %d messages for each chat message", h.limits.Messages))
+	}
+	c.actions = append(c.actions, Action{Kind: ActionMessage, Message: c.read(module, ptr, length)})
+}
+
+func (h *Host) log(ctx context.Context, module api.Module, ptr, length uint32) {
+	c := callState(ctx)
+	fmt.Printf("[%s] %s\n", c.module, c.read(module, ptr, length))
+}
+
+func (h *Host) storeGet(ctx context.Context, module api.Module, keyPtr, keyLen uint32) uint64 {
+	c := callState(ctx)
+	value, found, err := h.value(c.module, c.read(module, keyPtr, keyLen))
+	if err != nil {
+		c.fail(err)
+	}
+	if !found {
+		return 0
+	}
+	ptr, err := writeToModule(ctx, module, []byte(value))
+	if err != nil {
+		c.fail(err)
+	}
+	return uint64(ptr)<<32 | uint64(len(value))
+}
+
+func (h *Host) storeSet(ctx context.Context, module api.Module, keyPtr, keyLen, valuePtr, valueLen uint32) uint32 {
+	c := callState(ctx)
+	if err := h.setValue(c.module, c.read(module, keyPtr, keyLen), c.read(module, valuePtr, valueLen)); err != nil {
+		fmt.Printf("[%s] could not store a value: %v\n", c.module, err)
+		return 1
+	}
+	return 0
+}
+
+func (h *Host) storeDelete(ctx context.Context, module api.Module, keyPtr, keyLen uint32) {
+	c := callState(ctx)
+	if h.Bot.Storage == nil {
+		c.fail(errNoStorage)
+	}
+	err := h.Bot.Storage.DB.ArbitraryExec("delete from wasm_data where module = ? and key = ?", c.module, c.read(module, keyPtr, keyLen))
+	if err != nil {
+		c.fail(err)
+	}
+}
+
+// value reads a value stored by a module
+func (h *Host) value(module, key string) (string, bool, error) {
+	if h.Bot.Storage == nil {
+		return "", false, errNoStorage
+	}
+	rows, err := h.Bot.Storage.DB.Query("select value from wasm_data where module = ? and key = ?", module, key)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", false, rows.Err()
+	}
+	var value string
+	err = rows.Scan(&value)
+	return value, err == nil, err
+}
+
+// setValue stores a value for a module
+func (h *Host) setValue(module, key, value string) error {
+	if h.Bot.Storage == nil {
+		return errNoStorage
+	}
+	if len(value) > maxValueSize {
+		return fmt.Errorf("stored values can't be longer than %d", maxValueSize)
+	}
+
+	if _, found, err := h.value(module, key); err != nil {
+		return err
+	} else if found {
+		return h.Bot.Storage.DB.ArbitraryExec("update wasm_data set value = ? where module = ? and key = ?", value, module, key)
+	}
+
+	rows, err := h.Bot.Storage.DB.Query("select count(*) from wasm_data where module = ?", module)
+	if err != nil {
+		return err
+	}
+	var count int
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if count >= maxStoreKeys {
+		return fmt.Errorf("a module can only store %d values", maxStoreKeys)
+	}
+	return h.Bot.Storage.DB.Insert("wasm_data", []string{"module", "key", "value"}, []string{module, key, value})
+}
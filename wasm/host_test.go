@@ -0,0 +1,279 @@
+package wasm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liamphmurphy/pleasantbot/bot"
+	"github.com/liamphmurphy/pleasantbot/storage"
+)
+
+// instructions used by the test modules' on_message functions. The functions are numbered send 0, store_set 1,
+// alloc 2 and on_message 3.
+var (
+	sendPong = []byte{0x41, 0x00, 0x41, 0x04, 0x10, 0x00}             // send(0, 4), with "pong" at 0
+	storeKV  = []byte{0x41, 0x00, 0x41, 0x01, 0x41, 0x01, 0x41, 0x01, // store_set(0, 1, 1, 1), with "kv" at 0
+		0x10, 0x01, 0x1a}
+	loopForever = []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}
+	returnNone  = []byte{0x42, 0x00}
+)
+
+// returnPacked returns a pointer to 0 and length packed into an i64
+func returnPacked(length int64) []byte {
+	return append([]byte{0x42}, sleb(length)...)
+}
+
+// testModule assembles a module exporting memory, alloc and on_message, with data copied to the start of its memory
+func testModule(data string, onMessage ...[]byte) []byte {
+	var body []byte
+	for _, instructions := range onMessage {
+		body = append(body, instructions...)
+	}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, vec(
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},                   // (i32, i32)
+		[]byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f}, // (i32, i32, i32, i32) -> i32
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},                   // (i32) -> i32
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e},             // (i32, i32) -> i64
+	))...)
+	module = append(module, section(2, vec(
+		append(append(name("pleasantbot"), name("send")...), 0x00, 0x00),
+		append(append(name("pleasantbot"), name("store_set")...), 0x00, 0x01),
+	))...)
+	module = append(module, section(3, vec([]byte{0x02}, []byte{0x03}))...)
+	module = append(module, section(5, vec([]byte{0x00, 0x01}))...)
+	module = append(module, section(7, vec(
+		append(name("memory"), 0x02, 0x00),
+		append(name("alloc"), 0x00, 0x02),
+		append(name("on_message"), 0x00, 0x03),
+	))...)
+	alloc := []byte{0x00, 0x41, 0x80, 0x08, 0x0b} // return 1024
+	onMessageBody := append(append([]byte{0x00}, body...), 0x0b)
+	module = append(module, section(10, vec(
+		append(uleb(uint64(len(alloc))), alloc...),
+		append(uleb(uint64(len(onMessageBody))), onMessageBody...),
+	))...)
+	return append(module, section(11, vec(append([]byte{0x00, 0x41, 0x00, 0x0b}, name(data)...)))...)
+}
+
+func section(id byte, contents []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(contents)))...), contents...)
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func TestCall(t *testing.T) {
+	var database bot.Database
+	err := storage.Init(t.TempDir()+"/test.db", &database.DB, bot.PrepareDatabase)
+	if err != nil {
+		t.Fatalf("could not prepare the test database: %v", err)
+	}
+	defer database.DB.Close()
+
+	actions := `[{"kind":"delete"},{"kind":"timeout","seconds":60,"reason":"caps"}]`
+	tests := []struct {
+		description string
+		module      []byte
+		wantActions []Action
+		wantErr     string
+	}{
+		{
+			description: "should queue messages sent by the module",
+			module:      testModule("pong", sendPong, returnNone),
+			wantActions: []Action{{Kind: ActionMessage, Message: "pong", Module: "test"}},
+		},
+		{
+			description: "should read the actions the module returns",
+			module:      testModule(actions, returnPacked(int64(len(actions)))),
+			wantActions: []Action{{Kind: ActionDelete, Module: "test"},
+				{Kind: ActionTimeout, Seconds: 60, Reason: "caps", Module: "test"}},
+		},
+		{
+			description: "should reject unknown actions",
+			module:      testModule(`[{"kind":"ban"}]`, returnPacked(16)),
+			wantErr:     "unknown action",
+		},
+		{
+			description: "should stop a module that runs too long",
+			module:      testModule("", loopForever, returnNone),
+			wantErr:     "took longer than",
+		},
+		{
+			description: "should limit how many messages are sent",
+			module:      testModule("pong", sendPong, sendPong, sendPong, returnNone),
+			wantErr:     "can only send",
+		},
+		{
+			description: "should store values for the module",
+			module:      testModule("kv", storeKV, returnNone),
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "test.wasm"), test.module, 0644); err != nil {
+			t.Fatalf("could not write the test module: %v", err)
+		}
+		host, err := NewHost(&bot.Bot{Storage: &database}, dir, Limits{MemoryPages: 2, Timeout: 100 * time.Millisecond, Messages: 2})
+		if err != nil {
+			t.Fatalf("could not create the host: %v", err)
+		}
+		if err = host.Load(); err != nil {
+			t.Fatalf("%s\ncould not load the module: %v", test.description, err)
+		}
+
+		got, err := host.Call("test", bot.Item{ID: "abc", Contents: "hello"})
+		if !reflect.DeepEqual(got, test.wantActions) {
+			t.Errorf("%s\ndid not get the expected actions\ngot - %+v\nwant - %+v", test.description, got, test.wantActions)
+		}
+		if (err == nil && test.wantErr != "") || (err != nil && (test.wantErr == "" || !strings.Contains(err.Error(), test.wantErr))) {
+			t.Errorf("%s\ndid not get the expected error\ngot - %v\nwant - %v", test.description, err, test.wantErr)
+		}
+		host.Close()
+	}
+
+	host := &Host{Bot: &bot.Bot{Storage: &database}}
+	if value, found, err := host.value("test", "k"); err != nil || !found || value != "v" {
+		t.Errorf("did not get the expected stored value\ngot - %v (found %v, %v)\nwant - %v", value, found, err, "v")
+	}
+}
+
+func TestLoadModule(t *testing.T) {
+	dir := t.TempDir()
+	host, err := NewHost(&bot.Bot{}, dir, Limits{})
+	if err != nil {
+		t.Fatalf("could not create the host: %v", err)
+	}
+	defer host.Close()
+
+	// a module missing on_message should not load
+	module := testModule("", returnNone)
+	module = []byte(strings.Replace(string(module), "on_message", "on_massage", 1))
+	os.WriteFile(filepath.Join(dir, "broken.wasm"), module, 0644)
+	if err = host.Load(); err == nil || !strings.Contains(err.Error(), "does not export on_message") {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, "does not export on_message")
+	}
+
+	os.WriteFile(filepath.Join(dir, "hello.wasm"), testModule("", returnNone), 0644)
+	if err = host.LoadModule(filepath.Join(dir, "hello.wasm")); err != nil {
+		t.Fatalf("could not load the module: %v", err)
+	}
+	if want := []string{"hello"}; !reflect.DeepEqual(host.Modules(), want) {
+		t.Errorf("did not get the expected modules\ngot - %v\nwant - %v", host.Modules(), want)
+	}
+
+	// reloading a module that hasn't changed, or unloading another module with the same source, must leave it working
+	os.WriteFile(filepath.Join(dir, "copy.wasm"), testModule("", returnNone), 0644)
+	for _, file := range []string{"hello.wasm", "copy.wasm"} {
+		if err = host.LoadModule(filepath.Join(dir, file)); err != nil {
+			t.Fatalf("could not load %s: %v", file, err)
+		}
+	}
+	host.UnloadModule(filepath.Join(dir, "copy.wasm"))
+	if _, err = host.Call("hello", bot.Item{Contents: "hello"}); err != nil {
+		t.Errorf("could not call the module after reloading it: %v", err)
+	}
+
+	host.UnloadModule(filepath.Join(dir, "hello.wasm"))
+	if len(host.Modules()) != 0 {
+		t.Errorf("the module was not unloaded: %v", host.Modules())
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "loop.wasm"), testModule("", loopForever, returnNone), 0644); err != nil {
+		t.Fatalf("could not write the test module: %v", err)
+	}
+	host, err := NewHost(&bot.Bot{}, dir, Limits{})
+	if err != nil {
+		t.Fatalf("could not create the host: %v", err)
+	}
+	defer host.Close()
+	if err = host.Load(); err != nil {
+		t.Fatalf("could not load the module: %v", err)
+	}
+
+	// a config from before modules could be run has no timeout, and a looping module must still be stopped
+	if _, err = host.Call("loop", bot.Item{Contents: "hello"}); err == nil || !strings.Contains(err.Error(), "took longer than") {
+		t.Errorf("did not get the expected error\ngot - %v\nwant - %v", err, "took longer than")
+	}
+}
+
+func TestReloadDuringCall(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pong.wasm")
+	if err := os.WriteFile(path, testModule("pong", sendPong, returnNone), 0644); err != nil {
+		t.Fatalf("could not write the test module: %v", err)
+	}
+	host, err := NewHost(&bot.Bot{}, dir, Limits{Messages: 1})
+	if err != nil {
+		t.Fatalf("could not create the host: %v", err)
+	}
+	defer host.Close()
+	if err = host.Load(); err != nil {
+		t.Fatalf("could not load the module: %v", err)
+	}
+
+	// reloading the module closes the old one, which must not happen while a call is still using it
+	sources := [][]byte{testModule("pong", sendPong, returnNone), testModule("pong!", sendPong, returnNone)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if err := os.WriteFile(path, sources[i%2], 0644); err != nil {
+				t.Errorf("could not write the test module: %v", err)
+				return
+			}
+			if err := host.LoadModule(path); err != nil {
+				t.Errorf("could not reload the module: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if _, err := host.Call("pong", bot.Item{Contents: "ping"}); err != nil {
+			t.Fatalf("the call failed while the module was reloaded: %v", err)
+		}
+	}
+	<-done
+}
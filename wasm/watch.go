@@ -0,0 +1,47 @@
+package wasm
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch reloads modules as their files in the host's directory change, until done is closed. The directory must
+// exist.
+func (h *Host) Watch(done <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = watcher.Add(h.Dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event := <-watcher.Events:
+				if filepath.Ext(event.Name) != moduleFileExt {
+					continue
+				}
+				if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+					h.UnloadModule(event.Name)
+					fmt.Printf("unloaded the %s module\n", filepath.Base(event.Name))
+				} else if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+					if err := h.LoadModule(event.Name); err != nil {
+						fmt.Printf("could not reload %s: %v\n", filepath.Base(event.Name), err)
+					}
+				}
+			case err := <-watcher.Errors:
+				fmt.Printf("stopped watching for module changes: %v\n", err)
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+	return nil
+}